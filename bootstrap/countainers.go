@@ -1,9 +1,11 @@
 package bootstrap
 
 import (
+	"log"
 	"os"
 
-	"firebase.google.com/go/auth"
+	"firebase.google.com/go/v4/auth"
+	"github.com/gin-gonic/gin"
 	"github.com/muhammadfarrasfajri/login-google/controllers"
 	"github.com/muhammadfarrasfajri/login-google/database"
 	"github.com/muhammadfarrasfajri/login-google/middleware"
@@ -12,29 +14,130 @@ import (
 )
 
 type Container struct {
-	AuthAdminController *controllers.AuthController
-	AuthUserController  *controllers.AuthController
-	UserController      *controllers.UserController
-	JWTManager          *middleware.JWTManager
+	AuthAdminController     *controllers.AuthController
+	AuthUserController      *controllers.AuthController
+	MeController            *controllers.MeController
+	MFAController           *controllers.MFAController
+	UserController          *controllers.UserController
+	JWKSController          *controllers.JWKSController
+	InviteController        *controllers.InviteController
+	RoleController          *controllers.RoleController
+	RBAC                    *middleware.RBAC
+	Policies                *middleware.PolicyEngine
+	APIKeyController        *controllers.APIKeyController
+	OIDCController          *controllers.OIDCController
+	WebAuthnController      *controllers.WebAuthnController
+	ImpersonationController *controllers.ImpersonationController
+	StepUp                  gin.HandlerFunc
+	AdminJWTManager         *middleware.JWTManager
+	UserJWTManager          *middleware.JWTManager
+	CSRF                    *middleware.CSRFProtection
+	RateLimiter             *middleware.RateLimiter
+	ReEncryptionJob         *services.ReEncryptionJob
+	// nil bila Firebase Admin SDK tidak dipakai
+	FirebaseReconciliationJob *services.FirebaseReconciliationJob
 }
 
 func InitContainer(adminAuth, userAuth *auth.Client) *Container {
 	adminRepo := repository.NewAdminRepository(database.DB)
 	userRepo := repository.NewUserRepository(database.DB)
 
-	jwtManager := middleware.NewJWTManager(
-		os.Getenv("JWT_SECRET"),
-		os.Getenv("REFRESH_SECRET"),
-	)
+	// setiap realm punya JWTManager sendiri (realm, audience, keyring)
+	keys := newAccessKeyring()
+	revocations := newRevocationStore()
+	adminJWT := newJWTManager("ADMIN", keys, revocations)
+	userJWT := newJWTManager("USER", keys, revocations)
 
-	authAdminService := services.NewAuthService(adminRepo, adminAuth, jwtManager)
-	authUserService := services.NewAuthService(userRepo, userAuth, jwtManager)
-	userService := services.NewUserSevice(userRepo)
+	adminVerifier := newIDTokenVerifier("ADMIN", adminAuth)
+	userVerifier := newIDTokenVerifier("USER", userAuth)
+
+	inviteService := services.NewInviteService(repository.NewInviteRepository(database.DB))
+
+	// registrasi admin hanya lewat undangan, login admin bisa meminta TOTP
+	mfaService := newMFAService(adminJWT)
+	authAdminService := services.NewAuthService(adminRepo, adminVerifier, adminJWT)
+	authAdminService.Invites = inviteService
+	authAdminService.MFA = mfaService
+	authUserService := services.NewAuthService(userRepo, userVerifier, userJWT)
+
+	// rate limit login/register/refresh per IP (router), Google UID dan
+	// refresh token family (AuthService)
+	rateLimiter := newRateLimiter()
+	authAdminService.RateLimit = rateLimiter
+	authUserService.RateLimit = rateLimiter
+
+	// nama, email dan foto diperbarui dari profil Google saat login
+	profileSync := newProfileSyncRules()
+	authAdminService.ProfileSync = profileSync
+	authUserService.ProfileSync = profileSync
+
+	roleRepo := repository.NewRoleRepository(database.DB)
+	rbac := middleware.NewRBAC(roleRepo)
+	roleService := services.NewRoleService(roleRepo, rbac)
+	userService := services.NewUserSevice(userRepo, authUserService, roleService)
+
+	// API key diterima AuthMiddleware kedua realm lewat header X-API-Key
+	apiKeyService := services.NewAPIKeyService(repository.NewAPIKeyRepository(database.DB), adminRepo, userRepo, roleService)
+	adminJWT.APIKeys = apiKeyService
+	userJWT.APIKeys = apiKeyService
+	userService.APIKeys = apiKeyService
+
+	// impersonasi user oleh admin, setiap request dicatat AuthMiddleware realm user
+	impersonationService := newImpersonationService(adminRepo, userRepo, adminJWT, userJWT)
+	userJWT.Impersonation = impersonationService
+
+	// OIDC provider (opsional, nil bila OIDC_ISSUER kosong)
+	var oidcController *controllers.OIDCController
+	if oidcService := newOIDCService(authAdminService, authUserService); oidcService != nil {
+		oidcService.Permissions = roleRepo
+		oidcController = controllers.NewOIDCController(oidcService)
+	}
+
+	// passkey admin untuk step-up route sensitif (opsional, bila WEBAUTHN_RP_ID diisi)
+	var webAuthnController *controllers.WebAuthnController
+	webAuthnService, stepUp := newWebAuthnService(adminRepo, adminJWT)
+	if webAuthnService != nil {
+		webAuthnController = controllers.NewWebAuthnController(webAuthnService)
+	}
+
+	csrf := newCSRFProtection()
+	delivery := newTokenDelivery(csrf)
 
 	return &Container{
-		AuthAdminController: controllers.NewAuthController(authAdminService),
-		AuthUserController:  controllers.NewAuthController(authUserService),
-		UserController:      controllers.NewUserController(userService, userRepo),
-		JWTManager:          jwtManager,
+		AuthAdminController:       controllers.NewAuthController(authAdminService, delivery, "/api/auth/admin/refresh"),
+		AuthUserController:        controllers.NewAuthController(authUserService, delivery, "/api/auth/user/refresh"),
+		MeController:              controllers.NewMeController(authAdminService, authUserService),
+		MFAController:             controllers.NewMFAController(mfaService),
+		UserController:            controllers.NewUserController(userService, userRepo, rbac),
+		JWKSController:            controllers.NewJWKSController(adminJWT, userJWT),
+		InviteController:          controllers.NewInviteController(inviteService),
+		RoleController:            controllers.NewRoleController(roleService),
+		RBAC:                      rbac,
+		Policies:                  newPolicyEngine(),
+		APIKeyController:          controllers.NewAPIKeyController(apiKeyService),
+		OIDCController:            oidcController,
+		WebAuthnController:        webAuthnController,
+		ImpersonationController:   controllers.NewImpersonationController(impersonationService),
+		StepUp:                    stepUp,
+		AdminJWTManager:           adminJWT,
+		UserJWTManager:            userJWT,
+		CSRF:                      csrf,
+		RateLimiter:               rateLimiter,
+		ReEncryptionJob:           services.NewReEncryptionJob(adminRepo, userRepo),
+		FirebaseReconciliationJob: newFirebaseReconciliationJob(adminAuth, userAuth, authAdminService, authUserService),
 	}
-}
\ No newline at end of file
+}
+
+// REVOCATION_STORE: "mysql" (default, dipakai bersama semua instance) atau
+// "memory" (hanya untuk satu instance)
+func newRevocationStore() middleware.RevocationStore {
+	switch os.Getenv("REVOCATION_STORE") {
+	case "", "mysql":
+		return repository.NewRevocationRepository(database.DB)
+	case "memory":
+		return middleware.NewMemoryRevocationStore()
+	default:
+		log.Fatalf("unknown REVOCATION_STORE %q", os.Getenv("REVOCATION_STORE"))
+		return nil
+	}
+}
@@ -0,0 +1,76 @@
+package bootstrap
+
+import (
+	"log"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/muhammadfarrasfajri/login-google/controllers"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// Konfigurasi pengiriman refresh token:
+//   - REFRESH_TOKEN_DELIVERY: mode per client type, default "web=cookie,mobile=body"
+//   - REFRESH_TOKEN_DEFAULT_CLIENT: client type bila client tidak mengirim
+//     client_type / X-Client-Type, default "mobile" (refresh token di body)
+//   - COOKIE_SECURE (default true), COOKIE_SAMESITE (Strict, Lax, None;
+//     default Strict) dan COOKIE_DOMAIN untuk cookie refresh_token
+//   - ALLOWED_ORIGINS: origin browser yang boleh memakai cookie auth (CORS dan
+//     pengecekan CSRF), dipisah koma
+//   - CSRF_SECRET: kunci tanda tangan CSRF token, default subkey HKDF
+//     dari REFRESH_SECRET
+func AllowedOrigins() []string {
+	origins := []string{}
+	for _, o := range strings.Split(os.Getenv("ALLOWED_ORIGINS"), ",") {
+		if o = strings.TrimRight(strings.TrimSpace(o), "/"); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	return origins
+}
+
+func newCSRFProtection() *middleware.CSRFProtection {
+	return middleware.NewCSRFProtection(purposeSecret("CSRF_SECRET", "csrf-token"), AllowedOrigins())
+}
+
+func newTokenDelivery(csrf *middleware.CSRFProtection) *controllers.TokenDelivery {
+	delivery := &controllers.TokenDelivery{
+		Modes:          map[string]string{},
+		DefaultClient:  os.Getenv("REFRESH_TOKEN_DEFAULT_CLIENT"),
+		CookieSecure:   os.Getenv("COOKIE_SECURE") != "false",
+		CookieSameSite: http.SameSiteStrictMode,
+		CookieDomain:   os.Getenv("COOKIE_DOMAIN"),
+		CookieMaxAge:   services.RefreshTokenTTL,
+		CSRF:           csrf,
+	}
+	if delivery.DefaultClient == "" {
+		delivery.DefaultClient = "mobile"
+	}
+
+	modes := os.Getenv("REFRESH_TOKEN_DELIVERY")
+	if modes == "" {
+		modes = "web=cookie,mobile=body"
+	}
+	for _, entry := range strings.Split(modes, ",") {
+		client, mode, ok := strings.Cut(strings.TrimSpace(entry), "=")
+		if !ok || (mode != controllers.DeliveryCookie && mode != controllers.DeliveryBody) {
+			log.Fatalf("REFRESH_TOKEN_DELIVERY entry %q must be <client>=cookie|body", entry)
+		}
+		delivery.Modes[strings.ToLower(client)] = mode
+	}
+
+	switch strings.ToLower(os.Getenv("COOKIE_SAMESITE")) {
+	case "", "strict":
+	case "lax":
+		delivery.CookieSameSite = http.SameSiteLaxMode
+	case "none":
+		delivery.CookieSameSite = http.SameSiteNoneMode
+		delivery.CookieSecure = true
+	default:
+		log.Fatalf("unknown COOKIE_SAMESITE %q", os.Getenv("COOKIE_SAMESITE"))
+	}
+
+	return delivery
+}
@@ -1,6 +1,8 @@
 package bootstrap
 
 import (
+	"crypto/hkdf"
+	"crypto/sha256"
 	"log"
 	"os"
 
@@ -13,7 +15,35 @@ func InitEnv() {
 		log.Println("Warning: .env not found")
 	}
 
-	if os.Getenv("JWT_SECRET") == "" || os.Getenv("REFRESH_SECRET") == "" {
+	if os.Getenv("REFRESH_SECRET") == "" {
 		log.Fatal("JWT Secret cannot be empty")
 	}
+
+	// key dari JWT_KEYRING_FILE divalidasi saat keyring dimuat
+	if os.Getenv("JWT_KEYRING_FILE") != "" {
+		return
+	}
+
+	if signingAlg() == "HS256" && os.Getenv("JWT_SECRET") == "" {
+		log.Fatal("JWT Secret cannot be empty")
+	}
+	if signingAlg() != "HS256" && os.Getenv("JWT_PRIVATE_KEY_FILE") == "" {
+		log.Fatal("JWT_PRIVATE_KEY_FILE cannot be empty for ", signingAlg())
+	}
+}
+
+// purposeSecret mengembalikan kunci HMAC untuk satu keperluan: nilai env bila
+// diisi, selain itu subkey HKDF-SHA256 dari REFRESH_SECRET dengan info
+// purpose. REFRESH_SECRET sendiri tidak pernah dipakai langsung, jadi kunci
+// hash refresh token tidak bisa dipakai untuk memalsukan tanda tangan lain.
+func purposeSecret(env, purpose string) []byte {
+	if secret := os.Getenv(env); secret != "" {
+		return []byte(secret)
+	}
+
+	key, err := hkdf.Key(sha256.New, []byte(os.Getenv("REFRESH_SECRET")), nil, "login-google "+purpose, 32)
+	if err != nil {
+		log.Fatalf("derive %s: %v", env, err)
+	}
+	return key
 }
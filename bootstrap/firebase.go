@@ -3,9 +3,13 @@ package bootstrap
 import (
 	"context"
 	"log"
+	"os"
+	"time"
 
-	"firebase.google.com/go/auth"
+	"firebase.google.com/go/v4/auth"
 	"github.com/muhammadfarrasfajri/login-google/config"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/services"
 )
 
 func InitFirebase() (adminAuth, userAuth *auth.Client) {
@@ -23,3 +27,34 @@ func InitFirebase() (adminAuth, userAuth *auth.Client) {
 
 	return adminApp, userApp
 }
+
+// FIREBASE_RECONCILE_INTERVAL: jarak antar rekonsiliasi akun yang di-disable,
+// dihapus atau di-revoke di Firebase (durasi Go, default 15m, "0" mematikan).
+// Hanya berjalan bila kredensial Firebase Admin dimuat: ID_TOKEN_VERIFIER=firebase,
+// atau ID_TOKEN_VERIFIER=jwks dengan FIREBASE_RECONCILE=true.
+func newFirebaseReconciliationJob(adminAuth, userAuth *auth.Client, adminService, userService *services.AuthService) *services.FirebaseReconciliationJob {
+	if adminAuth == nil || userAuth == nil {
+		// verifier jwks tidak bisa cek revocation saat login, tanpa job ini
+		// session akun tersebut tetap hidup sampai logout/expired
+		log.Println("WARNING: Firebase Admin credentials not loaded: sessions of disabled, deleted or revoked Firebase accounts are NOT closed. Set FIREBASE_RECONCILE=true to run the reconciliation job with ID_TOKEN_VERIFIER=jwks")
+		return nil
+	}
+
+	interval := 15 * time.Minute
+	if v := os.Getenv("FIREBASE_RECONCILE_INTERVAL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d < 0 {
+			log.Fatalf("invalid FIREBASE_RECONCILE_INTERVAL %q", v)
+		}
+		interval = d
+	}
+	if interval == 0 {
+		log.Println("FIREBASE_RECONCILE_INTERVAL=0: Firebase reconciliation job is disabled")
+		return nil
+	}
+
+	return services.NewFirebaseReconciliationJob(interval,
+		services.FirebaseRealm{Name: middleware.RealmAdmin, Auth: adminService, Lookup: adminAuth},
+		services.FirebaseRealm{Name: middleware.RealmUser, Auth: userService, Lookup: userAuth},
+	)
+}
@@ -0,0 +1,27 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/database"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// IMPERSONATION_TTL: masa berlaku token impersonasi (durasi Go, default 15m,
+// maksimal 1h)
+func newImpersonationService(adminRepo, userRepo repository.AuthRepository, adminJWT, userJWT *middleware.JWTManager) *services.ImpersonationService {
+	ttl := services.DefaultImpersonationTTL
+	if v := os.Getenv("IMPERSONATION_TTL"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 || d > services.MaxImpersonationTTL {
+			log.Fatalf("invalid IMPERSONATION_TTL %q (max %s)", v, services.MaxImpersonationTTL)
+		}
+		ttl = d
+	}
+
+	return services.NewImpersonationService(repository.NewImpersonationRepository(database.DB), adminRepo, userRepo, adminJWT, userJWT, ttl)
+}
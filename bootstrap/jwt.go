@@ -0,0 +1,190 @@
+package bootstrap
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+// Signing key access token bisa dikonfigurasi dengan dua cara:
+//
+//  1. JWT_KEYRING_FILE: file JSON berisi beberapa key, contoh
+//
+//     {"keys": [
+//       {"kid": "2026-10", "alg": "ES256", "private_key_file": "keys/2026-10.pem", "status": "active"},
+//       {"kid": "2026-04", "alg": "RS256", "private_key_file": "keys/2026-04.pem", "status": "retired", "retire_at": "2026-10-20T00:00:00Z"},
+//       {"kid": "hs-1", "alg": "HS256", "secret_env": "JWT_SECRET", "status": "pending"}
+//     ]}
+//
+//     File dibaca ulang otomatis bila berubah, jadi key bisa ditambah
+//     (pending), diaktifkan dan di-retire tanpa restart server. kid boleh
+//     kosong untuk key PEM (diisi JWK thumbprint). Saat pindah dari
+//     konfigurasi tanpa file, pakai kid "default" untuk JWT_SECRET lama supaya
+//     token yang sudah beredar tetap valid.
+//
+//  2. Tanpa file: satu key aktif dari JWT_SIGNING_ALG ("HS256" default dengan
+//     JWT_SECRET, atau RS256/ES256/EdDSA dengan JWT_PRIVATE_KEY_FILE)
+//
+// ADMIN_JWT_KEYRING_FILE / USER_JWT_KEYRING_FILE memberi realm itu keyring
+// sendiri; realm tanpa file tersebut memakai keyring bersama di atas.
+// Audience token per realm diatur lewat ADMIN_TOKEN_AUDIENCE /
+// USER_TOKEN_AUDIENCE (default nama realm). JWT_ISSUER mengganti issuer
+// (default "login-google") dan JWT_CLOCK_LEEWAY toleransi jam saat validasi
+// exp/nbf/iat (durasi Go, default 30s).
+
+type keyringFile struct {
+	Keys []struct {
+		KID            string    `json:"kid"`
+		Alg            string    `json:"alg"`
+		PrivateKeyFile string    `json:"private_key_file"`
+		SecretEnv      string    `json:"secret_env"`
+		Status         string    `json:"status"`
+		RetireAt       time.Time `json:"retire_at"`
+	} `json:"keys"`
+}
+
+func signingAlg() string {
+	alg := os.Getenv("JWT_SIGNING_ALG")
+	if alg == "" {
+		return "HS256"
+	}
+	return alg
+}
+
+// newJWTManager membuat JWTManager untuk realm "ADMIN" atau "USER"
+func newJWTManager(realm string, shared *middleware.Keyring, revocations middleware.RevocationStore) *middleware.JWTManager {
+	keys := shared
+	if path := os.Getenv(realm + "_JWT_KEYRING_FILE"); path != "" {
+		keys = loadAccessKeyring(path)
+	}
+
+	name := strings.ToLower(realm)
+	audience := os.Getenv(realm + "_TOKEN_AUDIENCE")
+	if audience == "" {
+		audience = name
+	}
+
+	manager := middleware.NewJWTManager(name, audience, keys, os.Getenv("REFRESH_SECRET"), revocations)
+	if issuer := os.Getenv("JWT_ISSUER"); issuer != "" {
+		manager.Issuer = issuer
+	}
+	if v := os.Getenv("JWT_CLOCK_LEEWAY"); v != "" {
+		leeway, err := time.ParseDuration(v)
+		if err != nil || leeway < 0 {
+			log.Fatalf("JWT_CLOCK_LEEWAY %q is not a valid duration", v)
+		}
+		manager.Leeway = leeway
+	}
+	return manager
+}
+
+func newAccessKeyring() *middleware.Keyring {
+	path := os.Getenv("JWT_KEYRING_FILE")
+	if path == "" {
+		return middleware.NewSingleKeyring(newAccessSigningKey())
+	}
+	return loadAccessKeyring(path)
+}
+
+func loadAccessKeyring(path string) *middleware.Keyring {
+
+	keys, err := loadKeyringFile(path)
+	if err != nil {
+		log.Fatal("Failed to load JWT keyring: ", err)
+	}
+
+	ring := middleware.NewKeyring()
+	if err := ring.Replace(keys); err != nil {
+		log.Fatal("Invalid JWT keyring: ", err)
+	}
+
+	go watchKeyringFile(ring, path, 30*time.Second)
+	return ring
+}
+
+func newAccessSigningKey() *middleware.SigningKey {
+	alg := signingAlg()
+	if alg == "HS256" {
+		return middleware.NewHMACKey("default", []byte(os.Getenv("JWT_SECRET")))
+	}
+
+	key, err := middleware.LoadSigningKeyPEM(os.Getenv("JWT_PRIVATE_KEY_FILE"), alg)
+	if err != nil {
+		log.Fatal("Failed to load JWT signing key: ", err)
+	}
+	return key
+}
+
+func loadKeyringFile(path string) ([]middleware.KeyringKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	var file keyringFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return nil, err
+	}
+
+	keys := []middleware.KeyringKey{}
+	for _, k := range file.Keys {
+		var key *middleware.SigningKey
+		if k.Alg == "HS256" {
+			if k.KID == "" {
+				return nil, fmt.Errorf("HS256 signing key without kid")
+			}
+			secret := os.Getenv(k.SecretEnv)
+			if k.SecretEnv == "" || secret == "" {
+				return nil, fmt.Errorf("signing key %s: secret_env is empty", k.KID)
+			}
+			key = middleware.NewHMACKey(k.KID, []byte(secret))
+		} else {
+			key, err = middleware.LoadSigningKeyPEM(k.PrivateKeyFile, k.Alg)
+			if err != nil {
+				return nil, fmt.Errorf("signing key %s: %w", k.KID, err)
+			}
+			if k.KID != "" {
+				key.KID = k.KID
+			}
+		}
+
+		keys = append(keys, middleware.KeyringKey{
+			Key:      key,
+			Status:   k.Status,
+			RetireAt: k.RetireAt,
+		})
+	}
+	return keys, nil
+}
+
+// watchKeyringFile membaca ulang file keyring setiap kali berubah. Bila file
+// baru tidak valid, keyring lama tetap dipakai.
+func watchKeyringFile(ring *middleware.Keyring, path string, interval time.Duration) {
+	var lastMod time.Time
+	if info, err := os.Stat(path); err == nil {
+		lastMod = info.ModTime()
+	}
+
+	for range time.Tick(interval) {
+		info, err := os.Stat(path)
+		if err != nil || !info.ModTime().After(lastMod) {
+			continue
+		}
+		lastMod = info.ModTime()
+
+		keys, err := loadKeyringFile(path)
+		if err == nil {
+			err = ring.Replace(keys)
+		}
+		if err != nil {
+			log.Println("JWT keyring reload failed:", err)
+			continue
+		}
+		log.Println("JWT keyring reloaded")
+	}
+}
@@ -0,0 +1,33 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+
+	"github.com/muhammadfarrasfajri/login-google/database"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// ADMIN_MFA: "optional" (default, hanya admin yang sudah enroll TOTP diminta
+// kode) atau "required" (admin tanpa TOTP harus enroll saat login).
+// MFA_TOTP_ISSUER: nama yang tampil di authenticator app, default
+// "login-google".
+func newMFAService(adminJWT *middleware.JWTManager) *services.MFAService {
+	mode := os.Getenv("ADMIN_MFA")
+	switch mode {
+	case "":
+		mode = services.MFAOptional
+	case services.MFAOptional, services.MFARequired:
+	default:
+		log.Fatalf("unknown ADMIN_MFA %q", mode)
+	}
+
+	issuer := os.Getenv("MFA_TOTP_ISSUER")
+	if issuer == "" {
+		issuer = "login-google"
+	}
+
+	return services.NewMFAService(repository.NewMFARepository(database.DB), adminJWT, mode, issuer)
+}
@@ -0,0 +1,46 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+
+	"github.com/muhammadfarrasfajri/login-google/database"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// Mode OIDC provider aktif bila OIDC_ISSUER diisi (URL publik service ini,
+// misalnya https://auth.example.com).
+//
+//   - OIDC_LOGIN_URL: halaman login Google yang menerima ?request=... lalu
+//     memanggil POST /oauth/authorize/complete (wajib)
+//   - OIDC_REQUEST_SECRET: kunci HMAC request authorize, default subkey
+//     HKDF dari REFRESH_SECRET
+//
+// /oauth/introspect dan /oauth/revoke juga hanya tersedia di mode ini, karena
+// pemanggilnya harus client OAuth yang terdaftar.
+//
+// ID token ditandatangani key aktif realm client, jadi keyring harus memakai
+// RS256/ES256/EdDSA.
+func newOIDCService(adminAuth, userAuth *services.AuthService) *services.OIDCService {
+	issuer := os.Getenv("OIDC_ISSUER")
+	if issuer == "" {
+		return nil
+	}
+
+	loginURL := os.Getenv("OIDC_LOGIN_URL")
+	if loginURL == "" {
+		log.Fatal("OIDC_LOGIN_URL is required when OIDC_ISSUER is set")
+	}
+
+	secret := purposeSecret("OIDC_REQUEST_SECRET", "oidc-authorize-request")
+
+	for _, a := range []*services.AuthService{adminAuth, userAuth} {
+		key, err := a.JWTSecret.AccessKeys.Active()
+		if err != nil || !key.Asymmetric() {
+			log.Fatalf("OIDC_ISSUER requires an asymmetric active signing key for realm %s", a.JWTSecret.Realm)
+		}
+	}
+
+	return services.NewOIDCService(repository.NewOAuthRepository(database.DB), issuer, loginURL, secret, adminAuth, userAuth)
+}
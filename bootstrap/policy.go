@@ -0,0 +1,36 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+// POLICY_FILE: file JSON policy CEL (lihat middleware.Policy). Tanpa file,
+// route hanya dicek dengan permission RBAC.
+//
+// POLICY_DECISION_LOG: file decision log (JSON per baris), default stdout.
+// POLICY_LOG_ALLOWS=true ikut mencatat keputusan allow.
+func newPolicyEngine() *middleware.PolicyEngine {
+	out := os.Stdout
+	if path := os.Getenv("POLICY_DECISION_LOG"); path != "" {
+		f, err := os.OpenFile(path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0o640)
+		if err != nil {
+			log.Fatal("Failed to open POLICY_DECISION_LOG: ", err)
+		}
+		out = f
+	}
+
+	engine := middleware.NewPolicyEngine(&middleware.JSONDecisionLogger{
+		Out:       out,
+		LogAllows: os.Getenv("POLICY_LOG_ALLOWS") == "true",
+	})
+
+	if path := os.Getenv("POLICY_FILE"); path != "" {
+		if err := engine.LoadPolicyFile(path); err != nil {
+			log.Fatal("Failed to load POLICY_FILE: ", err)
+		}
+	}
+	return engine
+}
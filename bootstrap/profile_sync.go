@@ -0,0 +1,30 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// PROFILE_SYNC_NAME, PROFILE_SYNC_EMAIL, PROFILE_SYNC_PICTURE: aturan
+// sinkronisasi profil Google saat login (always, unless_customized atau
+// never). Default: nama unless_customized, email dan foto always.
+func newProfileSyncRules() *services.ProfileSyncRules {
+	rules := services.DefaultProfileSyncRules
+	for env, rule := range map[string]*string{
+		"PROFILE_SYNC_NAME":    &rules.Name,
+		"PROFILE_SYNC_EMAIL":   &rules.Email,
+		"PROFILE_SYNC_PICTURE": &rules.Picture,
+	} {
+		v := os.Getenv(env)
+		if v == "" {
+			continue
+		}
+		if !services.ValidProfileSyncRule(v) {
+			log.Fatalf("invalid %s %q (always, unless_customized or never)", env, v)
+		}
+		*rule = v
+	}
+	return &rules
+}
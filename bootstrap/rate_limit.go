@@ -0,0 +1,93 @@
+package bootstrap
+
+import (
+	"log"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/database"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+// RATE_LIMIT_STORE: "memory" (default, hanya untuk satu instance) atau
+// "mysql" (dipakai bersama semua instance).
+// RATE_LIMIT_FILE: file JSON yang menimpa limit bawaan per route, lihat
+// middleware.LoadRateLimitFile.
+func newRateLimiter() *middleware.RateLimiter {
+	var store middleware.RateLimitStore
+	switch os.Getenv("RATE_LIMIT_STORE") {
+	case "", "memory":
+		store = middleware.NewMemoryRateLimitStore()
+	case "mysql":
+		store = repository.NewRateLimitRepository(database.DB)
+	default:
+		log.Fatalf("unknown RATE_LIMIT_STORE %q", os.Getenv("RATE_LIMIT_STORE"))
+	}
+
+	limiter := middleware.NewRateLimiter(store, defaultRateLimits())
+	if path := os.Getenv("RATE_LIMIT_FILE"); path != "" {
+		if err := limiter.LoadRateLimitFile(path); err != nil {
+			log.Fatal("Failed to load RATE_LIMIT_FILE: ", err)
+		}
+	}
+	return limiter
+}
+
+// Limit bawaan, sama untuk realm admin dan user
+func defaultRateLimits() map[string]map[string]middleware.RateLimit {
+	rules := map[string]map[string]middleware.RateLimit{}
+	for _, realm := range []string{middleware.RealmAdmin, middleware.RealmUser} {
+		rules[realm+".login"] = map[string]middleware.RateLimit{
+			middleware.RateKeyIP:        {Burst: 20, Per: time.Minute},
+			middleware.RateKeyGoogleUID: {Burst: 5, Per: time.Minute},
+		}
+		rules[realm+".register"] = map[string]middleware.RateLimit{
+			middleware.RateKeyIP:        {Burst: 10, Per: 10 * time.Minute},
+			middleware.RateKeyGoogleUID: {Burst: 3, Per: 10 * time.Minute},
+		}
+		rules[realm+".refresh"] = map[string]middleware.RateLimit{
+			middleware.RateKeyIP:            {Burst: 60, Per: time.Minute},
+			middleware.RateKeyRefreshFamily: {Burst: 10, Per: time.Minute},
+		}
+	}
+
+	// langkah kedua login admin (kode TOTP) dan endpoint OIDC provider yang
+	// memverifikasi ID token atau kredensial client
+	rules["admin.mfa"] = map[string]middleware.RateLimit{
+		middleware.RateKeyIP: {Burst: 10, Per: time.Minute},
+	}
+	rules["oauth.authorize"] = map[string]middleware.RateLimit{
+		middleware.RateKeyIP: {Burst: 20, Per: time.Minute},
+	}
+	rules["oauth.token"] = map[string]middleware.RateLimit{
+		middleware.RateKeyIP: {Burst: 60, Per: time.Minute},
+	}
+	return rules
+}
+
+// TRUSTED_PROXIES: IP/CIDR proxy dipisah koma yang boleh mengisi
+// X-Forwarded-For. Kosong (atau "none") berarti tidak ada proxy yang
+// dipercaya dan IP client diambil dari koneksi, supaya client tidak bisa
+// memalsukan IP-nya untuk rate limit per IP.
+func TrustedProxies() []string {
+	v := strings.TrimSpace(os.Getenv("TRUSTED_PROXIES"))
+	if v == "" || v == "none" {
+		return nil
+	}
+
+	proxies := []string{}
+	for _, p := range strings.Split(v, ",") {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if _, _, err := net.ParseCIDR(p); err != nil && net.ParseIP(p) == nil {
+			log.Fatalf("invalid TRUSTED_PROXIES entry %q", p)
+		}
+		proxies = append(proxies, p)
+	}
+	return proxies
+}
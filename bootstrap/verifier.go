@@ -0,0 +1,61 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+
+	"firebase.google.com/go/v4/auth"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// ID_TOKEN_VERIFIER memilih cara verifikasi ID token:
+//   - "firebase" (default): Firebase Admin SDK, butuh firebase-key-*.json
+//   - "jwks": verifikasi offline dengan JWKS, dikonfigurasi per realm lewat
+//     <REALM>_JWKS_SOURCE, <REALM>_ID_TOKEN_ISSUER dan <REALM>_ID_TOKEN_AUDIENCE
+//     dengan REALM = ADMIN atau USER
+func verifierMode() string {
+	mode := os.Getenv("ID_TOKEN_VERIFIER")
+	if mode == "" {
+		return "firebase"
+	}
+	return mode
+}
+
+// FirebaseEnabled true bila verifier butuh Firebase Admin SDK. Dengan
+// verifier jwks, FIREBASE_RECONCILE=true tetap memuat kredensial Firebase
+// Admin untuk FirebaseReconciliationJob saja.
+func FirebaseEnabled() bool {
+	return verifierMode() == "firebase" || os.Getenv("FIREBASE_RECONCILE") == "true"
+}
+
+func newIDTokenVerifier(realm string, client *auth.Client) services.IDTokenVerifier {
+	switch verifierMode() {
+	case "firebase":
+		if client == nil {
+			log.Fatalf("Firebase %s client is not initialized", realm)
+		}
+		return services.NewFirebaseVerifier(client)
+
+	case "jwks":
+		audience := os.Getenv(realm + "_ID_TOKEN_AUDIENCE")
+		if audience == "" {
+			log.Fatalf("%s_ID_TOKEN_AUDIENCE cannot be empty", realm)
+		}
+
+		source := os.Getenv(realm + "_JWKS_SOURCE")
+		if source == "" {
+			source = services.FirebaseJWKSURL
+		}
+
+		issuer := os.Getenv(realm + "_ID_TOKEN_ISSUER")
+		if issuer == "" {
+			issuer = "https://securetoken.google.com/" + audience
+		}
+
+		return services.NewJWKSVerifier(source, issuer, audience)
+
+	default:
+		log.Fatalf("unknown ID_TOKEN_VERIFIER %q", verifierMode())
+		return nil
+	}
+}
@@ -0,0 +1,69 @@
+package bootstrap
+
+import (
+	"log"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/muhammadfarrasfajri/login-google/database"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// Passkey admin dan step-up aktif bila WEBAUTHN_RP_ID diisi.
+//
+//   - WEBAUTHN_RP_ID: domain relying party, misalnya "admin.example.com"
+//   - WEBAUTHN_RP_ORIGINS: origin frontend dipisah koma (wajib)
+//   - WEBAUTHN_RP_NAME: nama yang tampil saat membuat passkey, default "login-google"
+//   - STEP_UP_MAX_AGE: umur maksimal step-up dan masa berlaku elevated token
+//     (durasi Go, default 5m)
+//
+// Tanpa WebAuthn, step-up tetap dipasang: elevated token tidak bisa dibuat,
+// jadi route sensitif selalu menolak dengan 401 step-up required.
+func newWebAuthnService(adminRepo repository.AuthRepository, adminJWT *middleware.JWTManager) (*services.WebAuthnService, gin.HandlerFunc) {
+	rpID := os.Getenv("WEBAUTHN_RP_ID")
+	if rpID == "" {
+		log.Println("WARNING: WEBAUTHN_RP_ID not set: sensitive admin routes (update/delete/impersonate user) will reject every request until passkey step-up is configured")
+		return nil, middleware.RequireRecentStepUp(middleware.DefaultStepUpMaxAge)
+	}
+
+	origins := []string{}
+	for _, o := range strings.Split(os.Getenv("WEBAUTHN_RP_ORIGINS"), ",") {
+		if o = strings.TrimSpace(o); o != "" {
+			origins = append(origins, o)
+		}
+	}
+	if len(origins) == 0 {
+		log.Fatal("WEBAUTHN_RP_ORIGINS is required when WEBAUTHN_RP_ID is set")
+	}
+
+	name := os.Getenv("WEBAUTHN_RP_NAME")
+	if name == "" {
+		name = "login-google"
+	}
+
+	maxAge := middleware.DefaultStepUpMaxAge
+	if v := os.Getenv("STEP_UP_MAX_AGE"); v != "" {
+		d, err := time.ParseDuration(v)
+		if err != nil || d <= 0 {
+			log.Fatalf("invalid STEP_UP_MAX_AGE %q", v)
+		}
+		maxAge = d
+	}
+
+	wa, err := webauthn.New(&webauthn.Config{
+		RPID:          rpID,
+		RPDisplayName: name,
+		RPOrigins:     origins,
+	})
+	if err != nil {
+		log.Fatal("Failed to configure WebAuthn: ", err)
+	}
+
+	service := services.NewWebAuthnService(wa, repository.NewWebAuthnRepository(database.DB), adminRepo, adminJWT, maxAge)
+	return service, middleware.RequireRecentStepUp(maxAge)
+}
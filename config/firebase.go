@@ -3,8 +3,9 @@ package config
 import (
 	"context"
 	"log"
+	"os"
 
-	firebase "firebase.google.com/go"
+	firebase "firebase.google.com/go/v4"
 	"google.golang.org/api/option"
 )
 
@@ -12,14 +13,12 @@ var FirebaseAppAdmin *firebase.App
 var FirebaseAppUser *firebase.App
 
 func InitFirebase() {
-	optAdmin := option.WithCredentialsFile("firebase-key-admin.json")
-	appAdmin, err := firebase.NewApp(context.Background(), nil, optAdmin)
+	appAdmin, err := newFirebaseApp("firebase-key-admin.json", "ADMIN_FIREBASE_PROJECT_ID")
 	if err != nil {
 		log.Fatalf("Failed to init Firebase: %v", err)
 	}
-	
-	optUser := option.WithCredentialsFile("firebase-key-user.json")
-	appUser, err := firebase.NewApp(context.Background(), nil, optUser)
+
+	appUser, err := newFirebaseApp("firebase-key-user.json", "USER_FIREBASE_PROJECT_ID")
 	if err != nil {
 		log.Fatalf("Failed to init Firebase: %v", err)
 	}
@@ -27,3 +26,19 @@ func InitFirebase() {
 	FirebaseAppUser = appUser
 
 }
+
+// Dengan FIREBASE_AUTH_EMULATOR_HOST (misalnya "localhost:9099") SDK memakai
+// Firebase Auth emulator: file key tidak dibutuhkan, project id diambil dari
+// env projectEnv
+func newFirebaseApp(keyFile, projectEnv string) (*firebase.App, error) {
+	if os.Getenv("FIREBASE_AUTH_EMULATOR_HOST") != "" {
+		projectID := os.Getenv(projectEnv)
+		if projectID == "" {
+			log.Fatalf("%s is required when FIREBASE_AUTH_EMULATOR_HOST is set", projectEnv)
+		}
+		return firebase.NewApp(context.Background(), &firebase.Config{ProjectID: projectID})
+	}
+
+	opt := option.WithCredentialsFile(keyFile)
+	return firebase.NewApp(context.Background(), nil, opt)
+}
@@ -0,0 +1,223 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+type APIKeyController struct {
+	APIKeyService *services.APIKeyService
+}
+
+func NewAPIKeyController(apiKeyService *services.APIKeyService) *APIKeyController {
+	return &APIKeyController{
+		APIKeyService: apiKeyService,
+	}
+}
+
+type createAPIKeyRequest struct {
+	Name          string   `json:"name"`
+	Scopes        []string `json:"scopes"`
+	ExpiresInDays int      `json:"expires_in_days"`
+}
+
+// ------------------------------ PERSONAL KEY -----------------------------
+
+// POST /admin/api-keys, POST /users/api-keys
+// Key hanya muncul di response ini
+func (c *APIKeyController) CreatePersonal(ctx *gin.Context) {
+	var body createAPIKeyRequest
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	key, secret, err := c.APIKeyService.CreatePersonal(ctx.GetString("realm"), ctx.GetInt("user_id"), body.Name, body.Scopes, ttl)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "API key created",
+		"api_key": key,
+		"key":     secret,
+	})
+}
+
+// GET /admin/api-keys, GET /users/api-keys
+func (c *APIKeyController) ListPersonal(ctx *gin.Context) {
+	keys, err := c.APIKeyService.ListPersonal(ctx.GetString("realm"), ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Success get api keys",
+		"api_keys": keys,
+	})
+}
+
+// DELETE /admin/api-keys/:key_id, DELETE /users/api-keys/:key_id
+func (c *APIKeyController) RevokePersonal(ctx *gin.Context) {
+	keyID, err := strconv.Atoi(ctx.Param("key_id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid api key id"})
+		return
+	}
+
+	err = c.APIKeyService.RevokePersonal(ctx.GetString("realm"), ctx.GetInt("user_id"), keyID)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+// ----------------------------- SERVICE ACCOUNT ---------------------------
+
+// POST /admin/service-accounts
+func (c *APIKeyController) CreateServiceAccount(ctx *gin.Context) {
+	var body struct {
+		Realm        string `json:"realm"`
+		Name         string `json:"name"`
+		Description  string `json:"description"`
+		Role         string `json:"role"`
+		Organization string `json:"organization"`
+	}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	sa, err := c.APIKeyService.CreateServiceAccount(ctx.GetInt("user_id"), body.Realm, body.Name, body.Description, body.Role, body.Organization)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":         "Service account created",
+		"service_account": sa,
+	})
+}
+
+// GET /admin/service-accounts
+func (c *APIKeyController) GetAllServiceAccounts(ctx *gin.Context) {
+	accounts, err := c.APIKeyService.GetAllServiceAccounts()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":          "Success get service accounts",
+		"service_accounts": accounts,
+	})
+}
+
+// DELETE /admin/service-accounts/:id
+// Service account dinonaktifkan dan semua key-nya dicabut
+func (c *APIKeyController) DisableServiceAccount(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account id"})
+		return
+	}
+
+	if err := c.APIKeyService.DisableServiceAccount(id); err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Service account disabled"})
+}
+
+// POST /admin/service-accounts/:id/keys
+func (c *APIKeyController) CreateServiceAccountKey(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account id"})
+		return
+	}
+
+	var body createAPIKeyRequest
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	ttl := time.Duration(body.ExpiresInDays) * 24 * time.Hour
+	key, secret, err := c.APIKeyService.CreateForServiceAccount(id, body.Name, body.Scopes, ttl)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "API key created",
+		"api_key": key,
+		"key":     secret,
+	})
+}
+
+// GET /admin/service-accounts/:id/keys
+func (c *APIKeyController) ListServiceAccountKeys(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid service account id"})
+		return
+	}
+
+	keys, err := c.APIKeyService.ListForServiceAccount(id)
+	if err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Success get api keys",
+		"api_keys": keys,
+	})
+}
+
+// DELETE /admin/service-accounts/:id/keys/:key_id
+func (c *APIKeyController) RevokeServiceAccountKey(ctx *gin.Context) {
+	id, err1 := strconv.Atoi(ctx.Param("id"))
+	keyID, err2 := strconv.Atoi(ctx.Param("key_id"))
+	if err1 != nil || err2 != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid id"})
+		return
+	}
+
+	if err := c.APIKeyService.RevokeServiceAccountKey(id, keyID); err != nil {
+		respondAPIKeyError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "API key revoked"})
+}
+
+func respondAPIKeyError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrAPIKeyNotFound),
+		errors.Is(err, services.ErrServiceAccountNotFound),
+		errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrInvalidScope),
+		errors.Is(err, services.ErrAPIKeyTTL),
+		errors.Is(err, services.ErrAPIKeyName),
+		errors.Is(err, services.ErrInvalidServiceAccount):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -8,8 +8,9 @@ import (
 
 func (c *AuthController) RegisterAdmin(ctx *gin.Context) {
 	var body struct {
-		IDToken string `json:"id_token"`
-		Name    string `json:"name"`
+		IDToken    string `json:"id_token"`
+		Name       string `json:"name"`
+		InviteCode string `json:"invite_code"`
 	}
 
 	if err := ctx.BindJSON(&body); err != nil {
@@ -17,7 +18,10 @@ func (c *AuthController) RegisterAdmin(ctx *gin.Context) {
 		return
 	}
 
-	admin, err := c.AuthService.Register(body.IDToken, body.Name)
+	admin, err := c.AuthService.Register(body.IDToken, body.Name, body.InviteCode)
+	if respondRateLimited(ctx, err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -30,54 +34,113 @@ func (c *AuthController) RegisterAdmin(ctx *gin.Context) {
 }
 
 func (c *AuthController) LoginAdmin(ctx *gin.Context) {
-    var req struct {
-        IDToken    string `json:"id_token"`
-        DeviceInfo string `json:"device_info"`
-    }
-
-    if err := ctx.BindJSON(&req); err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-        return
-    }
-
-    ip := ctx.ClientIP()
-
-    result, err := c.AuthService.Login(req.IDToken, req.DeviceInfo, ip)
-	
-    if err != nil {
-        ctx.JSON((http.StatusBadRequest), gin.H{"error": err.Error()})
-        return
-    }
-
-    ctx.JSON(http.StatusOK, result)
+	var req struct {
+		IDToken    string `json:"id_token"`
+		DeviceInfo string `json:"device_info"`
+		ClientType string `json:"client_type"`
+	}
+
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	ip := ctx.ClientIP()
+
+	result, err := c.AuthService.Login(req.IDToken, req.DeviceInfo, ip)
+	if respondRateLimited(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	c.deliverTokens(ctx, result, c.Delivery.Mode(clientType(ctx, req.ClientType)))
 }
 
 func (c *AuthController) RefreshTokenAdmin(ctx *gin.Context) {
+	refreshToken, mode := c.readRefreshToken(ctx)
+	if refreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
+		return
+	}
+
+	result, err := c.AuthService.RefreshToken(refreshToken, ctx.ClientIP())
+	if respondRateLimited(ctx, err) {
+		return
+	}
+	if err != nil {
+		if mode == DeliveryCookie {
+			c.clearRefreshCookie(ctx)
+		}
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		return
+	}
 
-    refreshToken, err := ctx.Cookie("refresh_token")
-	
-    if err != nil {
-        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
-        return
-    }
-    result, err := c.AuthService.RefreshToken(refreshToken) 
-    if err != nil {
-        ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
-        return
-    }
-
-    ctx.JSON(http.StatusOK, result)
+	c.deliverTokens(ctx, result, mode)
 }
 
 func (c *AuthController) LogoutAdmin(ctx *gin.Context) {
-	userID := ctx.GetInt("user_id") 
+	userID := ctx.GetInt("user_id")
+	sessionID := ctx.GetInt("session_id")
 
-	err := c.AuthService.Logout(userID)
+	err := c.AuthService.Logout(userID, sessionID)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.clearRefreshCookie(ctx)
 	ctx.JSON(http.StatusOK, gin.H{"message": "logout success"})
 }
 
+// POST /api/auth/admin/mfa/verify
+// Menyelesaikan login dengan mfa_token dari LoginAdmin dan kode TOTP (atau
+// recovery code)
+func (c *AuthController) VerifyMFAAdmin(ctx *gin.Context) {
+	var req struct {
+		MFAToken   string `json:"mfa_token"`
+		Code       string `json:"code"`
+		ClientType string `json:"client_type"`
+	}
+
+	if err := ctx.BindJSON(&req); err != nil || req.MFAToken == "" || req.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token and code are required"})
+		return
+	}
+
+	result, err := c.AuthService.CompleteMFALogin(req.MFAToken, req.Code, ctx.ClientIP())
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	c.deliverTokens(ctx, result, c.Delivery.Mode(clientType(ctx, req.ClientType)))
+}
+
+// POST /api/auth/admin/mfa/enroll
+// Hanya untuk mfa_token dengan enrollment_required (ADMIN_MFA=required dan
+// admin belum punya TOTP). Kode pertama dikirim ke /mfa/verify.
+func (c *AuthController) EnrollMFAAdmin(ctx *gin.Context) {
+	var req struct {
+		MFAToken string `json:"mfa_token"`
+	}
+
+	if err := ctx.BindJSON(&req); err != nil || req.MFAToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "mfa_token is required"})
+		return
+	}
+
+	enrollment, err := c.AuthService.BeginMFAEnrollment(req.MFAToken)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Scan the QR code, then verify the first code",
+		"secret":      enrollment.Secret,
+		"otpauth_uri": enrollment.OTPAuthURI,
+	})
+}
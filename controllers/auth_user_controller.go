@@ -1,22 +1,39 @@
 package controllers
 
 import (
+	"errors"
 	"net/http"
 
 	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
 	"github.com/muhammadfarrasfajri/login-google/services"
 )
 
 type AuthController struct {
 	AuthService *services.AuthService
+	Delivery    *TokenDelivery
+	RefreshPath string
 }
 
-func NewAuthController(authservice *services.AuthService) *AuthController{
+func NewAuthController(authservice *services.AuthService, delivery *TokenDelivery, refreshPath string) *AuthController {
 	return &AuthController{
 		AuthService: authservice,
+		Delivery:    delivery,
+		RefreshPath: refreshPath,
 	}
 }
 
+// respondRateLimited mengirim 429 bila err berasal dari rate limit per
+// Google UID atau refresh token family
+func respondRateLimited(ctx *gin.Context, err error) bool {
+	var limited *middleware.RateLimitedError
+	if !errors.As(err, &limited) {
+		return false
+	}
+	middleware.RespondRateLimited(ctx, limited)
+	return true
+}
+
 func (c *AuthController) RegisterUser(ctx *gin.Context) {
 	var body struct {
 		IDToken string `json:"id_token"`
@@ -28,7 +45,10 @@ func (c *AuthController) RegisterUser(ctx *gin.Context) {
 		return
 	}
 
-	user, err := c.AuthService.Register(body.IDToken, body.Name)
+	user, err := c.AuthService.Register(body.IDToken, body.Name, "")
+	if respondRateLimited(ctx, err) {
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -41,56 +61,64 @@ func (c *AuthController) RegisterUser(ctx *gin.Context) {
 }
 
 func (c *AuthController) LoginUser(ctx *gin.Context) {
-    var req struct {
-        IDToken    string `json:"id_token"`
-        DeviceInfo string `json:"device_info"`
-    }
-
-    if err := ctx.BindJSON(&req); err != nil {
-        ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
-        return
-    }
-
-    ip := ctx.ClientIP()
-
-    result, err := c.AuthService.Login(req.IDToken, req.DeviceInfo, ip)
-	
-    if err != nil {
-        ctx.JSON((http.StatusBadRequest), gin.H{"error": err.Error()})
-        return
-    }
-
-    ctx.JSON(http.StatusOK, result)
-}
+	var req struct {
+		IDToken    string `json:"id_token"`
+		DeviceInfo string `json:"device_info"`
+		ClientType string `json:"client_type"`
+	}
 
-func (c *AuthController) RefreshTokenUser(ctx *gin.Context) {
+	if err := ctx.BindJSON(&req); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	ip := ctx.ClientIP()
+
+	result, err := c.AuthService.Login(req.IDToken, req.DeviceInfo, ip)
+	if respondRateLimited(ctx, err) {
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
 
-	refreshToken, err := ctx.Cookie("refresh_token")
+	c.deliverTokens(ctx, result, c.Delivery.Mode(clientType(ctx, req.ClientType)))
+}
 
-    if err != nil {
-        ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
-        return
-    }
+func (c *AuthController) RefreshTokenUser(ctx *gin.Context) {
+	refreshToken, mode := c.readRefreshToken(ctx)
+	if refreshToken == "" {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing refresh token"})
+		return
+	}
 
-	result, err := c.AuthService.RefreshToken(refreshToken)
+	result, err := c.AuthService.RefreshToken(refreshToken, ctx.ClientIP())
+	if respondRateLimited(ctx, err) {
+		return
+	}
 	if err != nil {
+		if mode == DeliveryCookie {
+			c.clearRefreshCookie(ctx)
+		}
 		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
 		return
 	}
 
-	ctx.JSON(http.StatusOK, result)
+	c.deliverTokens(ctx, result, mode)
 }
 
 func (c *AuthController) LogoutUser(ctx *gin.Context) {
 
 	userID := ctx.GetInt("user_id")
+	sessionID := ctx.GetInt("session_id")
 
-	err := c.AuthService.Logout(userID)
+	err := c.AuthService.Logout(userID, sessionID)
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
 	}
 
+	c.clearRefreshCookie(ctx)
 	ctx.JSON(http.StatusOK, gin.H{"message": "logout success"})
 }
-
@@ -0,0 +1,116 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+type ImpersonationController struct {
+	ImpersonationService *services.ImpersonationService
+}
+
+func NewImpersonationController(impersonationService *services.ImpersonationService) *ImpersonationController {
+	return &ImpersonationController{
+		ImpersonationService: impersonationService,
+	}
+}
+
+// POST /admin/users/:id/impersonate
+// Token impersonasi hanya muncul di response ini dan tidak bisa di-refresh
+func (c *ImpersonationController) Start(ctx *gin.Context) {
+	var body struct {
+		Reason string `json:"reason"`
+	}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	imp, token, err := c.ImpersonationService.Start(ctx.GetInt("user_id"), ctx.Param("id"), body.Reason, ctx.ClientIP())
+	if errors.Is(err, services.ErrImpersonationReason) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":       "Impersonation started",
+		"impersonation": imp,
+		"access_token":  token,
+		"token_type":    "Bearer",
+		"expires_in":    int(c.ImpersonationService.TTL.Seconds()),
+	})
+}
+
+// GET /admin/impersonations
+func (c *ImpersonationController) GetAll(ctx *gin.Context) {
+	imps, err := c.ImpersonationService.GetAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Success get impersonations",
+		"impersonations": imps,
+	})
+}
+
+// GET /admin/impersonations/:id/requests
+func (c *ImpersonationController) GetRequests(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid impersonation id"})
+		return
+	}
+
+	imp, reqs, err := c.ImpersonationService.GetRequests(id)
+	if errors.Is(err, services.ErrImpersonationNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":       "Success get impersonation requests",
+		"impersonation": imp,
+		"requests":      reqs,
+	})
+}
+
+// DELETE /admin/impersonations/:id
+// Mencabut token impersonasi sebelum expired
+func (c *ImpersonationController) End(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid impersonation id"})
+		return
+	}
+
+	err = c.ImpersonationService.End(ctx.GetInt("user_id"), id, ctx.ClientIP())
+	if errors.Is(err, services.ErrImpersonationNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Impersonation ended"})
+}
@@ -0,0 +1,88 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+type InviteController struct {
+	InviteService *services.InviteService
+}
+
+func NewInviteController(inviteService *services.InviteService) *InviteController {
+	return &InviteController{
+		InviteService: inviteService,
+	}
+}
+
+// POST /admin/invites
+// Kode undangan hanya muncul di response ini
+func (c *InviteController) Create(ctx *gin.Context) {
+	var body struct {
+		Email          string `json:"email"`
+		HostedDomain   string `json:"hosted_domain"`
+		ExpiresInHours int    `json:"expires_in_hours"`
+	}
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	ttl := time.Duration(body.ExpiresInHours) * time.Hour
+	invite, code, err := c.InviteService.Create(ctx.GetInt("user_id"), body.Email, body.HostedDomain, ttl)
+	if errors.Is(err, services.ErrInviteTarget) || errors.Is(err, services.ErrInviteTTL) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message":     "Invite created",
+		"invite":      invite,
+		"invite_code": code,
+	})
+}
+
+// GET /admin/invites
+func (c *InviteController) GetAll(ctx *gin.Context) {
+	invites, err := c.InviteService.GetAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get invites",
+		"invites": invites,
+	})
+}
+
+// DELETE /admin/invites/:id
+func (c *InviteController) Revoke(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid invite id"})
+		return
+	}
+
+	err = c.InviteService.Revoke(id)
+	if errors.Is(err, services.ErrInviteNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Invite revoked"})
+}
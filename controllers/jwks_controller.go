@@ -0,0 +1,37 @@
+package controllers
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+type JWKSController struct {
+	JWTManagers []*middleware.JWTManager
+}
+
+func NewJWKSController(jwtManagers ...*middleware.JWTManager) *JWKSController {
+	return &JWKSController{
+		JWTManagers: jwtManagers,
+	}
+}
+
+// GET /.well-known/jwks.json
+// Public key semua realm digabung, key yang dipakai bersama hanya muncul sekali
+func (c *JWKSController) JWKS(ctx *gin.Context) {
+	seen := map[string]bool{}
+	keys := []map[string]string{}
+	for _, m := range c.JWTManagers {
+		for _, jwk := range m.AccessKeys.JWKs() {
+			if seen[jwk["kid"]] {
+				continue
+			}
+			seen[jwk["kid"]] = true
+			keys = append(keys, jwk)
+		}
+	}
+
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, gin.H{"keys": keys})
+}
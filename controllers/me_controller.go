@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// Ekstensi foto profil yang boleh di-upload sendiri (folder /public disajikan
+// langsung, jadi file lain seperti .html ditolak)
+var profilePictureExts = map[string]bool{".jpg": true, ".jpeg": true, ".png": true, ".webp": true, ".gif": true}
+
+// MeController melayani /api/auth/me untuk akun yang sedang login di realm
+// mana pun (dipasang setelah middleware.AnyRealm)
+type MeController struct {
+	AuthServices map[string]*services.AuthService
+}
+
+func NewMeController(adminAuth, userAuth *services.AuthService) *MeController {
+	return &MeController{
+		AuthServices: map[string]*services.AuthService{
+			middleware.RealmAdmin: adminAuth,
+			middleware.RealmUser:  userAuth,
+		},
+	}
+}
+
+// GET /api/auth/me
+func (c *MeController) Me(ctx *gin.Context) {
+	authService := c.AuthServices[ctx.GetString("realm")]
+
+	user, sessions, err := authService.Me(ctx.GetInt("user_id"), ctx.GetInt("session_id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"message":  "Success get profile",
+		"realm":    ctx.GetString("realm"),
+		"user":     user,
+		"sessions": sessions,
+	}
+	// token impersonasi: frontend bisa menampilkan banner "acting as"
+	if impersonator := ctx.GetString("impersonator"); impersonator != "" {
+		resp["impersonated_by"] = impersonator
+	}
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// GET /api/auth/me/profile-history
+func (c *MeController) ProfileHistory(ctx *gin.Context) {
+	authService := c.AuthServices[ctx.GetString("realm")]
+
+	changes, err := authService.ProfileHistory(ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get profile history",
+		"changes": changes,
+	})
+}
+
+// PATCH /api/auth/me (form-data: Name, Profile_picture)
+// Email dan role tidak bisa diubah sendiri
+func (c *MeController) UpdateMe(ctx *gin.Context) {
+	if ctx.PostForm("Email") != "" || ctx.PostForm("Role") != "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "email and role cannot be changed here"})
+		return
+	}
+
+	name := ctx.PostForm("Name")
+
+	// Ambil file kalau ada
+	file, _ := ctx.FormFile("Profile_picture")
+	var publicPath string
+	if file != nil {
+		ext := strings.ToLower(filepath.Ext(file.Filename))
+		if !profilePictureExts[ext] {
+			ctx.JSON(http.StatusBadRequest, gin.H{"error": "profile picture must be jpg, png, webp or gif"})
+			return
+		}
+		filename := fmt.Sprintf("%d_%s", time.Now().UnixNano(), filepath.Base(file.Filename))
+		savePath := fmt.Sprintf("./public/uploads/images/%s", filename)
+		if err := ctx.SaveUploadedFile(file, savePath); err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to save file"})
+			return
+		}
+		publicPath = fmt.Sprintf("/public/uploads/images/%s", filename)
+	}
+
+	authService := c.AuthServices[ctx.GetString("realm")]
+	user, err := authService.UpdateProfile(ctx.GetInt("user_id"), name, publicPath)
+	if errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if errors.Is(err, services.ErrInvalidProfile) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Update success",
+		"user":    user,
+	})
+}
@@ -0,0 +1,125 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// MFAController mengelola TOTP admin yang sedang login
+type MFAController struct {
+	MFAService *services.MFAService
+}
+
+func NewMFAController(mfaService *services.MFAService) *MFAController {
+	return &MFAController{
+		MFAService: mfaService,
+	}
+}
+
+type mfaCodeRequest struct {
+	Code string `json:"code"`
+}
+
+// GET /api/auth/admin/mfa
+func (c *MFAController) Status(ctx *gin.Context) {
+	status, err := c.MFAService.Status(ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get mfa status",
+		"mfa":     status,
+	})
+}
+
+// POST /api/auth/admin/mfa/totp
+func (c *MFAController) BeginEnrollment(ctx *gin.Context) {
+	enrollment, err := c.MFAService.BeginEnrollment(ctx.GetInt("user_id"), ctx.GetString("email"))
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Scan the QR code, then confirm the first code",
+		"secret":      enrollment.Secret,
+		"otpauth_uri": enrollment.OTPAuthURI,
+	})
+}
+
+// POST /api/auth/admin/mfa/totp/confirm
+// Recovery code hanya muncul di response ini
+func (c *MFAController) ConfirmEnrollment(ctx *gin.Context) {
+	var body mfaCodeRequest
+	if err := ctx.BindJSON(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	codes, err := c.MFAService.ConfirmEnrollment(ctx.GetInt("user_id"), body.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "TOTP enabled",
+		"recovery_codes": codes,
+	})
+}
+
+// DELETE /api/auth/admin/mfa/totp
+func (c *MFAController) Disable(ctx *gin.Context) {
+	var body mfaCodeRequest
+	if err := ctx.BindJSON(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	if err := c.MFAService.Disable(ctx.GetInt("user_id"), body.Code); err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "TOTP disabled"})
+}
+
+// POST /api/auth/admin/mfa/recovery-codes
+func (c *MFAController) RegenerateRecoveryCodes(ctx *gin.Context) {
+	var body mfaCodeRequest
+	if err := ctx.BindJSON(&body); err != nil || body.Code == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "code is required"})
+		return
+	}
+
+	codes, err := c.MFAService.RegenerateRecoveryCodes(ctx.GetInt("user_id"), body.Code)
+	if err != nil {
+		respondMFAError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":        "Recovery codes regenerated",
+		"recovery_codes": codes,
+	})
+}
+
+func respondMFAError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidMFAToken), errors.Is(err, services.ErrInvalidMFACode):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFALocked):
+		ctx.JSON(http.StatusTooManyRequests, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrMFANotEnrolled), errors.Is(err, services.ErrMFAAlreadyEnrolled):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,276 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+type OIDCController struct {
+	OIDCService *services.OIDCService
+}
+
+func NewOIDCController(oidcService *services.OIDCService) *OIDCController {
+	return &OIDCController{
+		OIDCService: oidcService,
+	}
+}
+
+// GET /.well-known/openid-configuration
+func (c *OIDCController) Discovery(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "public, max-age=300")
+	ctx.JSON(http.StatusOK, c.OIDCService.Discovery())
+}
+
+// GET /oauth/authorize
+// Request yang valid diarahkan ke halaman login (OIDC_LOGIN_URL?request=...)
+func (c *OIDCController) Authorize(ctx *gin.Context) {
+	location, err := c.OIDCService.Authorize(services.AuthorizeRequest{
+		ResponseType:        ctx.Query("response_type"),
+		ClientID:            ctx.Query("client_id"),
+		RedirectURI:         ctx.Query("redirect_uri"),
+		Scope:               ctx.Query("scope"),
+		State:               ctx.Query("state"),
+		Nonce:               ctx.Query("nonce"),
+		CodeChallenge:       ctx.Query("code_challenge"),
+		CodeChallengeMethod: ctx.Query("code_challenge_method"),
+	})
+	if err != nil {
+		c.respondAuthorizeError(ctx, err, true)
+		return
+	}
+
+	ctx.Redirect(http.StatusFound, location)
+}
+
+// POST /oauth/authorize/complete
+// Dipanggil halaman login dengan request dari /oauth/authorize dan ID token
+// Google. Halaman login lalu mengarahkan browser ke redirect_to.
+func (c *OIDCController) CompleteAuthorization(ctx *gin.Context) {
+	var body struct {
+		Request string `json:"request"`
+		IDToken string `json:"id_token"`
+	}
+	if err := ctx.BindJSON(&body); err != nil || body.Request == "" || body.IDToken == "" {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "request and id_token are required"})
+		return
+	}
+
+	location, err := c.OIDCService.CompleteAuthorization(body.Request, body.IDToken, ctx.ClientIP())
+	if err != nil {
+		c.respondAuthorizeError(ctx, err, false)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"redirect_to": location})
+}
+
+// POST /oauth/token (application/x-www-form-urlencoded)
+// Client confidential memakai client_secret_basic atau client_secret_post
+func (c *OIDCController) Token(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+	ctx.Header("Pragma", "no-cache")
+
+	clientID, clientSecret, ok := clientCredentials(ctx)
+	if !ok {
+		respondOAuthError(ctx, &services.OAuthError{Code: "invalid_request", Description: "use only one client authentication method"})
+		return
+	}
+
+	tokens, err := c.OIDCService.Exchange(services.TokenRequest{
+		GrantType:    ctx.PostForm("grant_type"),
+		Code:         ctx.PostForm("code"),
+		RedirectURI:  ctx.PostForm("redirect_uri"),
+		CodeVerifier: ctx.PostForm("code_verifier"),
+		ClientID:     clientID,
+		ClientSecret: clientSecret,
+	})
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, tokens)
+}
+
+// GET/POST /oauth/userinfo
+func (c *OIDCController) UserInfo(ctx *gin.Context) {
+	token := strings.TrimPrefix(ctx.GetHeader("Authorization"), "Bearer ")
+	if token == "" || token == ctx.GetHeader("Authorization") {
+		ctx.Header("WWW-Authenticate", `Bearer`)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "missing token"})
+		return
+	}
+
+	info, err := c.OIDCService.UserInfo(token)
+	if err != nil {
+		ctx.Header("WWW-Authenticate", `Bearer error="invalid_token"`)
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, info)
+}
+
+// POST /oauth/introspect (RFC 7662)
+func (c *OIDCController) Introspect(ctx *gin.Context) {
+	ctx.Header("Cache-Control", "no-store")
+
+	clientID, clientSecret, ok := clientCredentials(ctx)
+	if !ok || ctx.PostForm("token") == "" {
+		respondOAuthError(ctx, &services.OAuthError{Code: "invalid_request", Description: "token and one client authentication method are required"})
+		return
+	}
+
+	resp, err := c.OIDCService.Introspect(clientID, clientSecret, ctx.PostForm("token"), ctx.PostForm("token_type_hint"))
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, resp)
+}
+
+// POST /oauth/revoke (RFC 7009)
+// Token yang tidak valid tetap dijawab 200
+func (c *OIDCController) Revoke(ctx *gin.Context) {
+	clientID, clientSecret, ok := clientCredentials(ctx)
+	if !ok || ctx.PostForm("token") == "" {
+		respondOAuthError(ctx, &services.OAuthError{Code: "invalid_request", Description: "token and one client authentication method are required"})
+		return
+	}
+
+	err := c.OIDCService.Revoke(clientID, clientSecret, ctx.PostForm("token"), ctx.PostForm("token_type_hint"))
+	if err != nil {
+		respondOAuthError(ctx, err)
+		return
+	}
+
+	ctx.Status(http.StatusOK)
+}
+
+// ---------------------------- CLIENT REGISTRY ----------------------------
+
+// POST /admin/oauth-clients
+// Secret client confidential hanya muncul di response ini
+func (c *OIDCController) RegisterClient(ctx *gin.Context) {
+	var body struct {
+		Name         string   `json:"name"`
+		Realm        string   `json:"realm"`
+		RedirectURIs []string `json:"redirect_uris"`
+		Scopes       []string `json:"scopes"`
+		Confidential bool     `json:"confidential"`
+	}
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	client, secret, err := c.OIDCService.RegisterClient(ctx.GetInt("user_id"), body.Name, body.Realm, body.RedirectURIs, body.Scopes, body.Confidential)
+	if errors.Is(err, services.ErrInvalidClientConfig) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	resp := gin.H{
+		"message": "OAuth client registered",
+		"client":  client,
+	}
+	if secret != "" {
+		resp["client_secret"] = secret
+	}
+	ctx.JSON(http.StatusCreated, resp)
+}
+
+// GET /admin/oauth-clients
+func (c *OIDCController) GetAllClients(ctx *gin.Context) {
+	clients, err := c.OIDCService.GetAllClients()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get oauth clients",
+		"clients": clients,
+	})
+}
+
+// DELETE /admin/oauth-clients/:client_id
+func (c *OIDCController) DisableClient(ctx *gin.Context) {
+	err := c.OIDCService.DisableClient(ctx.Param("client_id"))
+	if errors.Is(err, services.ErrClientNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "OAuth client disabled"})
+}
+
+// -------------------------------- HELPER ---------------------------------
+
+// Error yang bisa di-redirect dikirim ke client; error lain (client atau
+// redirect_uri tidak valid) ditampilkan langsung supaya tidak ada open
+// redirect
+func (c *OIDCController) respondAuthorizeError(ctx *gin.Context, err error, redirect bool) {
+	var redirectErr *services.RedirectError
+	if errors.As(err, &redirectErr) {
+		location := redirectErr.Location(c.OIDCService.Issuer)
+		if redirect {
+			ctx.Redirect(http.StatusFound, location)
+		} else {
+			ctx.JSON(http.StatusOK, gin.H{"redirect_to": location})
+		}
+		return
+	}
+
+	var oauthErr *services.OAuthError
+	if errors.As(err, &oauthErr) {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+		return
+	}
+	if respondRateLimited(ctx, err) {
+		return
+	}
+	ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+}
+
+// Error endpoint token, introspect dan revoke mengikuti RFC 6749 bagian 5.2
+func respondOAuthError(ctx *gin.Context, err error) {
+	var oauthErr *services.OAuthError
+	if !errors.As(err, &oauthErr) {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": "server_error"})
+		return
+	}
+
+	status := http.StatusBadRequest
+	if oauthErr.Code == "invalid_client" {
+		status = http.StatusUnauthorized
+		ctx.Header("WWW-Authenticate", `Basic realm="oauth"`)
+	}
+	ctx.JSON(status, gin.H{"error": oauthErr.Code, "error_description": oauthErr.Description})
+}
+
+// clientCredentials membaca client_secret_basic atau client_secret_post.
+// Client public cukup mengirim client_id di body.
+func clientCredentials(ctx *gin.Context) (string, string, bool) {
+	id, secret, hasBasic := ctx.Request.BasicAuth()
+	if hasBasic {
+		if ctx.PostForm("client_secret") != "" {
+			return "", "", false
+		}
+		return id, secret, true
+	}
+	return ctx.PostForm("client_id"), ctx.PostForm("client_secret"), true
+}
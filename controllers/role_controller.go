@@ -0,0 +1,106 @@
+package controllers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+type RoleController struct {
+	RoleService *services.RoleService
+}
+
+func NewRoleController(roleService *services.RoleService) *RoleController {
+	return &RoleController{
+		RoleService: roleService,
+	}
+}
+
+// GET /admin/roles
+func (c *RoleController) GetAll(ctx *gin.Context) {
+	roles, err := c.RoleService.GetAll()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get roles",
+		"roles":   roles,
+	})
+}
+
+// GET /admin/permissions
+func (c *RoleController) GetPermissions(ctx *gin.Context) {
+	permissions, err := c.RoleService.GetPermissions()
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":     "Success get permissions",
+		"permissions": permissions,
+	})
+}
+
+// POST /admin/roles
+func (c *RoleController) Create(ctx *gin.Context) {
+	var body struct {
+		Realm       string `json:"realm"`
+		Name        string `json:"name"`
+		Description string `json:"description"`
+	}
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	role, err := c.RoleService.Create(body.Realm, body.Name, body.Description)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Role created",
+		"role":    role,
+	})
+}
+
+// PUT /admin/roles/:id/permissions
+func (c *RoleController) SetPermissions(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid role id"})
+		return
+	}
+
+	var body struct {
+		Permissions []string `json:"permissions"`
+	}
+
+	if err := ctx.BindJSON(&body); err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid request"})
+		return
+	}
+
+	role, err := c.RoleService.SetPermissions(id, body.Permissions)
+	if errors.Is(err, services.ErrRoleNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Role permissions updated",
+		"role":    role,
+	})
+}
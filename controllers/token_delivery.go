@@ -0,0 +1,120 @@
+package controllers
+
+import (
+	"net/http"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+// Cara refresh token dikirim ke client
+const (
+	DeliveryCookie = "cookie" // HttpOnly cookie, untuk browser
+	DeliveryBody   = "body"   // JSON body, untuk aplikasi native
+)
+
+const (
+	RefreshTokenCookie = "refresh_token"
+	RefreshTokenHeader = "X-Refresh-Token"
+	ClientTypeHeader   = "X-Client-Type"
+)
+
+// TokenDelivery menentukan mode pengiriman refresh token per jenis client
+// (misalnya web → cookie, mobile → body).
+type TokenDelivery struct {
+	Modes         map[string]string
+	DefaultClient string
+
+	CookieSecure   bool
+	CookieSameSite http.SameSite
+	CookieDomain   string
+	CookieMaxAge   time.Duration
+
+	CSRF *middleware.CSRFProtection
+}
+
+// Mode untuk client type, client type kosong atau tidak dikenal memakai
+// DefaultClient
+func (d *TokenDelivery) Mode(clientType string) string {
+	if mode, ok := d.Modes[strings.ToLower(clientType)]; ok {
+		return mode
+	}
+	if mode, ok := d.Modes[d.DefaultClient]; ok {
+		return mode
+	}
+	return DeliveryBody
+}
+
+// deliverTokens mengirim hasil login/refresh. Pada mode cookie refresh token
+// hanya dikirim lewat cookie, tidak ikut di JSON, dan client mendapat CSRF
+// token session ini untuk header X-CSRF-Token.
+func (c *AuthController) deliverTokens(ctx *gin.Context, result map[string]interface{}, mode string) {
+	if mode == DeliveryCookie {
+		if token, ok := result["refresh_token"].(string); ok {
+			sessionID, _ := c.AuthService.JWTSecret.ParseRefreshToken(token)
+			csrfToken, err := c.Delivery.CSRF.NewToken(sessionID)
+			if err != nil {
+				ctx.JSON(http.StatusInternalServerError, gin.H{"error": "failed to create csrf token"})
+				return
+			}
+
+			maxAge := int(c.Delivery.CookieMaxAge.Seconds())
+			c.setRefreshCookie(ctx, token, maxAge)
+			c.setCSRFCookie(ctx, csrfToken, maxAge)
+			delete(result, "refresh_token")
+			result["csrf_token"] = csrfToken
+		}
+	}
+
+	ctx.JSON(http.StatusOK, result)
+}
+
+// readRefreshToken mengambil refresh token dari cookie, header
+// X-Refresh-Token, atau JSON body, beserta mode pengirimannya.
+func (c *AuthController) readRefreshToken(ctx *gin.Context) (string, string) {
+	if token, err := ctx.Cookie(RefreshTokenCookie); err == nil && token != "" {
+		return token, DeliveryCookie
+	}
+
+	if token := ctx.GetHeader(RefreshTokenHeader); token != "" {
+		return token, DeliveryBody
+	}
+
+	var body struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if err := ctx.ShouldBindJSON(&body); err == nil && body.RefreshToken != "" {
+		return body.RefreshToken, DeliveryBody
+	}
+
+	return "", ""
+}
+
+func (c *AuthController) clearRefreshCookie(ctx *gin.Context) {
+	c.setRefreshCookie(ctx, "", -1)
+	c.setCSRFCookie(ctx, "", -1)
+}
+
+// Cookie hanya dikirim browser ke path refresh milik realm ini
+func (c *AuthController) setRefreshCookie(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(c.Delivery.CookieSameSite)
+	ctx.SetCookie(RefreshTokenCookie, value, maxAge, c.RefreshPath, c.Delivery.CookieDomain, c.Delivery.CookieSecure, true)
+}
+
+// Cookie CSRF berlaku untuk semua endpoint auth realm ini (refresh dan
+// logout), tidak HttpOnly supaya bisa dibaca JavaScript di origin yang sama
+func (c *AuthController) setCSRFCookie(ctx *gin.Context, value string, maxAge int) {
+	ctx.SetSameSite(c.Delivery.CookieSameSite)
+	ctx.SetCookie(middleware.CSRFCookie, value, maxAge, path.Dir(c.RefreshPath), c.Delivery.CookieDomain, c.Delivery.CookieSecure, false)
+}
+
+// clientType dari field client_type atau header X-Client-Type
+func clientType(ctx *gin.Context, fromBody string) string {
+	if fromBody != "" {
+		return fromBody
+	}
+	return ctx.GetHeader(ClientTypeHeader)
+}
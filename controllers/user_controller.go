@@ -1,6 +1,7 @@
 package controllers
 
 import (
+	"errors"
 	"fmt"
 	"net/http"
 	"path/filepath"
@@ -9,6 +10,7 @@ import (
 	"time"
 
 	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
 	"github.com/muhammadfarrasfajri/login-google/repository"
 	"github.com/muhammadfarrasfajri/login-google/services"
 )
@@ -16,12 +18,14 @@ import (
 type UserController struct {
 	UserService *services.UserService
 	Repo        *repository.UserRepository
+	RBAC        *middleware.RBAC
 }
 
-func NewUserController(userService *services.UserService, repo *repository.UserRepository) *UserController{
+func NewUserController(userService *services.UserService, repo *repository.UserRepository, rbac *middleware.RBAC) *UserController{
 	return &UserController{
 		UserService: userService,
 		Repo: repo,
+		RBAC: rbac,
 	}
 }
 
@@ -81,8 +85,13 @@ func (c *UserController) Update(ctx *gin.Context) {
 	}
 
 	// Kirim ke service/repo
-	user, err := c.UserService.Update(id, name, email, role, publicPath)
+	canAssignRole := c.RBAC.HasPermission(ctx, "users:assign_role")
+	user, err := c.UserService.Update(id, name, email, role, publicPath, canAssignRole)
 
+	if errors.Is(err, services.ErrRoleChangeForbidden) {
+		ctx.JSON(http.StatusForbidden, gin.H{"error": err.Error()})
+		return
+	}
 	if err != nil {
 		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
 		return
@@ -90,7 +99,7 @@ func (c *UserController) Update(ctx *gin.Context) {
 
 	// Format URL fotoqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqqq
 	if user.ProfilePicture != "" && !strings.HasPrefix(user.ProfilePicture, "http") {
-		user.ProfilePicture = fmt.Sprintf(user.ProfilePicture)
+		user.ProfilePicture = fmt.Sprint(user.ProfilePicture)
 	}
 
 	ctx.JSON(http.StatusOK, gin.H{
@@ -99,6 +108,44 @@ func (c *UserController) Update(ctx *gin.Context) {
 	})
 }
 
+// GET /admin/users/:id/profile-history
+func (c *UserController) ProfileHistory(ctx *gin.Context) {
+	changes, err := c.UserService.ProfileHistory(ctx.Param("id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+		return
+	}
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Success get profile history",
+		"changes": changes,
+	})
+}
+
+// PolicyResource memuat user target (:id) untuk policy CEL (variabel resource)
+func (c *UserController) PolicyResource(ctx *gin.Context) (map[string]interface{}, error) {
+	user, err := c.UserService.GetByID(ctx.Param("id"))
+	if errors.Is(err, services.ErrUserNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"id":           user.ID,
+		"email":        user.Email,
+		"name":         user.Name,
+		"role":         user.Role,
+		"organization": user.Organization,
+		"created_at":   user.CreatedAt,
+	}, nil
+}
+
 // DELETE /users/:id
 func (c *UserController) Delete(ctx *gin.Context) {
 	id := ctx.Param("id")
@@ -114,6 +161,21 @@ func (c *UserController) Delete(ctx *gin.Context) {
 	})
 }
 
+// POST /admin/users/:id/logout
+func (c *UserController) ForceLogout(ctx *gin.Context) {
+	id := ctx.Param("id")
+
+	err := c.UserService.ForceLogout(id)
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message": "Force logout success",
+	})
+}
+
 func (uc *UserController) UploadPhoto(c *gin.Context) {
 	file, err := c.FormFile("photo")
 	if err != nil {
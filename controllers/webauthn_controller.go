@@ -0,0 +1,171 @@
+package controllers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/services"
+)
+
+// WebAuthnController melayani passkey admin dan step-up
+type WebAuthnController struct {
+	WebAuthnService *services.WebAuthnService
+}
+
+func NewWebAuthnController(webAuthnService *services.WebAuthnService) *WebAuthnController {
+	return &WebAuthnController{
+		WebAuthnService: webAuthnService,
+	}
+}
+
+// Body finish: session dari langkah begin dan hasil navigator.credentials
+type webAuthnFinishRequest struct {
+	Session    string          `json:"session"`
+	Name       string          `json:"name"`
+	Credential json.RawMessage `json:"credential"`
+}
+
+// RequireStepUpOnceEnrolled dipasang pada registrasi dan penghapusan passkey.
+// Admin yang belum punya passkey boleh mendaftarkan passkey pertamanya dengan
+// access token biasa; setelah itu perubahan passkey butuh elevated token,
+// supaya access token curian tidak bisa dipakai untuk menambah passkey milik
+// penyerang lalu melakukan step-up.
+func (c *WebAuthnController) RequireStepUpOnceEnrolled(stepUp gin.HandlerFunc) gin.HandlerFunc {
+	return func(ctx *gin.Context) {
+		enrolled, err := c.WebAuthnService.HasPasskey(ctx.GetInt("user_id"))
+		if err != nil {
+			ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+			ctx.Abort()
+			return
+		}
+		if !enrolled {
+			ctx.Next()
+			return
+		}
+		stepUp(ctx)
+	}
+}
+
+// POST /api/auth/admin/webauthn/register/begin
+func (c *WebAuthnController) BeginRegistration(ctx *gin.Context) {
+	options, session, err := c.WebAuthnService.BeginRegistration(ctx.GetInt("user_id"))
+	if err != nil {
+		respondWebAuthnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"options": options,
+		"session": session,
+	})
+}
+
+// POST /api/auth/admin/webauthn/register/finish
+func (c *WebAuthnController) FinishRegistration(ctx *gin.Context) {
+	var body webAuthnFinishRequest
+	if err := ctx.BindJSON(&body); err != nil || body.Session == "" || len(body.Credential) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "session and credential are required"})
+		return
+	}
+
+	credential, err := c.WebAuthnService.FinishRegistration(ctx.GetInt("user_id"), body.Session, body.Name, body.Credential)
+	if err != nil {
+		respondWebAuthnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusCreated, gin.H{
+		"message": "Passkey registered",
+		"passkey": credential,
+	})
+}
+
+// GET /api/auth/admin/webauthn/credentials
+func (c *WebAuthnController) ListCredentials(ctx *gin.Context) {
+	credentials, err := c.WebAuthnService.ListCredentials(ctx.GetInt("user_id"))
+	if err != nil {
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"message":  "Success get passkeys",
+		"passkeys": credentials,
+	})
+}
+
+// DELETE /api/auth/admin/webauthn/credentials/:id
+func (c *WebAuthnController) DeleteCredential(ctx *gin.Context) {
+	id, err := strconv.Atoi(ctx.Param("id"))
+	if err != nil {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "invalid passkey id"})
+		return
+	}
+
+	if err := c.WebAuthnService.DeleteCredential(ctx.GetInt("user_id"), id); err != nil {
+		respondWebAuthnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{"message": "Passkey deleted"})
+}
+
+// POST /api/auth/admin/step-up/begin
+func (c *WebAuthnController) BeginStepUp(ctx *gin.Context) {
+	options, session, err := c.WebAuthnService.BeginStepUp(ctx.GetInt("user_id"))
+	if err != nil {
+		respondWebAuthnError(ctx, err)
+		return
+	}
+
+	ctx.JSON(http.StatusOK, gin.H{
+		"options": options,
+		"session": session,
+	})
+}
+
+// POST /api/auth/admin/step-up/finish
+// Mengembalikan elevated token untuk route yang memakai RequireRecentStepUp
+func (c *WebAuthnController) FinishStepUp(ctx *gin.Context) {
+	var body webAuthnFinishRequest
+	if err := ctx.BindJSON(&body); err != nil || body.Session == "" || len(body.Credential) == 0 {
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": "session and credential are required"})
+		return
+	}
+
+	v, _ := ctx.Get("claims")
+	claims, ok := v.(*middleware.AccessClaims)
+	if !ok {
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
+		return
+	}
+
+	result, err := c.WebAuthnService.FinishStepUp(claims, body.Session, body.Credential)
+	if err != nil {
+		respondWebAuthnError(ctx, err)
+		return
+	}
+
+	ctx.Header("Cache-Control", "no-store")
+	ctx.JSON(http.StatusOK, result)
+}
+
+func respondWebAuthnError(ctx *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidCeremony), errors.Is(err, services.ErrWebAuthnFailed), errors.Is(err, services.ErrPasskeyCloneWarned),
+		errors.Is(err, services.ErrSessionNotFound):
+		ctx.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPasskeyName):
+		ctx.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrNoPasskey):
+		ctx.JSON(http.StatusConflict, gin.H{"error": err.Error()})
+	case errors.Is(err, services.ErrPasskeyNotFound), errors.Is(err, services.ErrUserNotFound):
+		ctx.JSON(http.StatusNotFound, gin.H{"error": err.Error()})
+	default:
+		ctx.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+	}
+}
@@ -0,0 +1,43 @@
+-- Per-device sessions: satu row per login, menggantikan refresh_tokens_user
+-- dan refresh_tokens_admin yang hanya menyimpan satu token per akun.
+
+CREATE TABLE IF NOT EXISTS sessions_user (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    user_id       INT          NOT NULL,
+    refresh_token TEXT         NOT NULL,
+    device_info   VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address    VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at    DATETIME     NOT NULL,
+    last_used_at  DATETIME     NOT NULL,
+    expires_at    DATETIME     NOT NULL,
+    INDEX idx_sessions_user_user_id (user_id),
+    CONSTRAINT fk_sessions_user_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS sessions_admin (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id      INT          NOT NULL,
+    refresh_token TEXT         NOT NULL,
+    device_info   VARCHAR(255) NOT NULL DEFAULT '',
+    ip_address    VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at    DATETIME     NOT NULL,
+    last_used_at  DATETIME     NOT NULL,
+    expires_at    DATETIME     NOT NULL,
+    INDEX idx_sessions_admin_admin_id (admin_id),
+    CONSTRAINT fk_sessions_admin_admin FOREIGN KEY (admin_id) REFERENCES admins (id) ON DELETE CASCADE
+);
+
+-- Refresh token yang masih berlaku dipindah menjadi session, supaya user
+-- yang sedang login tidak ter-logout saat deploy.
+INSERT INTO sessions_user (user_id, refresh_token, created_at, last_used_at, expires_at)
+SELECT user_id, refresh_token, NOW(), NOW(), expires_at
+FROM refresh_tokens_user
+WHERE expires_at > NOW();
+
+INSERT INTO sessions_admin (admin_id, refresh_token, created_at, last_used_at, expires_at)
+SELECT admin_id, refresh_token, NOW(), NOW(), expires_at
+FROM refresh_tokens_admin
+WHERE expires_at > NOW();
+
+DROP TABLE refresh_tokens_user;
+DROP TABLE refresh_tokens_admin;
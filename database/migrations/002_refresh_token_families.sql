@@ -0,0 +1,30 @@
+-- Refresh token families: setiap session menyimpan generation token terakhir.
+-- Token dari generation lama yang dipakai lagi membuat session di-revoke.
+
+ALTER TABLE sessions_user
+    ADD COLUMN generation INT      NOT NULL DEFAULT 0 AFTER refresh_token,
+    ADD COLUMN revoked_at DATETIME NULL AFTER generation;
+
+ALTER TABLE sessions_admin
+    ADD COLUMN generation INT      NOT NULL DEFAULT 0 AFTER refresh_token,
+    ADD COLUMN revoked_at DATETIME NULL AFTER generation;
+
+CREATE TABLE IF NOT EXISTS security_events_user (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    event_type  VARCHAR(64)  NOT NULL,
+    detail      TEXT         NOT NULL,
+    ip_address  VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_security_events_user_user_id (user_id)
+);
+
+CREATE TABLE IF NOT EXISTS security_events_admin (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id    INT          NOT NULL,
+    event_type  VARCHAR(64)  NOT NULL,
+    detail      TEXT         NOT NULL,
+    ip_address  VARCHAR(64)  NOT NULL DEFAULT '',
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_security_events_admin_admin_id (admin_id)
+);
@@ -0,0 +1,35 @@
+-- Refresh token opaque: database hanya menyimpan HMAC-SHA256 dari token.
+--
+-- Kolom refresh_token lama (JWT terenkripsi) tetap ada untuk session yang
+-- sudah berjalan. Saat token lama dipakai refresh, session langsung diganti
+-- ke token opaque dan kolom ini dikosongkan, jadi tidak ada user yang
+-- ter-logout. Sisa token lama hilang sendiri setelah expired (7 hari).
+
+ALTER TABLE sessions_user
+    MODIFY refresh_token TEXT NULL,
+    ADD COLUMN refresh_token_hash CHAR(64) NULL AFTER refresh_token;
+
+ALTER TABLE sessions_admin
+    MODIFY refresh_token TEXT NULL,
+    ADD COLUMN refresh_token_hash CHAR(64) NULL AFTER refresh_token;
+
+-- Hash token yang sudah dirotasi, dipakai untuk mendeteksi reuse
+CREATE TABLE IF NOT EXISTS refresh_token_history_user (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    session_id  INT      NOT NULL,
+    token_hash  CHAR(64) NOT NULL,
+    generation  INT      NOT NULL,
+    rotated_at  DATETIME NOT NULL,
+    INDEX idx_refresh_token_history_user_session (session_id),
+    CONSTRAINT fk_refresh_token_history_user_session FOREIGN KEY (session_id) REFERENCES sessions_user (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS refresh_token_history_admin (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    session_id  INT      NOT NULL,
+    token_hash  CHAR(64) NOT NULL,
+    generation  INT      NOT NULL,
+    rotated_at  DATETIME NOT NULL,
+    INDEX idx_refresh_token_history_admin_session (session_id),
+    CONSTRAINT fk_refresh_token_history_admin_session FOREIGN KEY (session_id) REFERENCES sessions_admin (id) ON DELETE CASCADE
+);
@@ -0,0 +1,17 @@
+-- Denylist jti access token. Entry hanya perlu disimpan sampai token-nya
+-- expired dan dibersihkan otomatis oleh RevocationRepository.
+CREATE TABLE IF NOT EXISTS revoked_tokens (
+    jti         VARCHAR(64) PRIMARY KEY,
+    expires_at  DATETIME    NOT NULL,
+    revoked_at  DATETIME    NOT NULL,
+    INDEX idx_revoked_tokens_expires_at (expires_at)
+);
+
+-- jti access token terakhir per session, dicabut saat logout/rotasi
+ALTER TABLE sessions_user
+    ADD COLUMN access_jti        VARCHAR(64) NULL AFTER revoked_at,
+    ADD COLUMN access_expires_at DATETIME    NULL AFTER access_jti;
+
+ALTER TABLE sessions_admin
+    ADD COLUMN access_jti        VARCHAR(64) NULL AFTER revoked_at,
+    ADD COLUMN access_expires_at DATETIME    NULL AFTER access_jti;
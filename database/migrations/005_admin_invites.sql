@@ -0,0 +1,17 @@
+-- Undangan registrasi admin. Kode undangan hanya disimpan sebagai SHA-256,
+-- terikat ke satu email atau satu Google hosted domain, dan hanya bisa
+-- dipakai sekali.
+CREATE TABLE IF NOT EXISTS admin_invites (
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    code_hash      CHAR(64)     NOT NULL,
+    email          VARCHAR(255) NULL,
+    hosted_domain  VARCHAR(255) NULL,
+    created_by     INT          NOT NULL,
+    created_at     DATETIME     NOT NULL,
+    expires_at     DATETIME     NOT NULL,
+    used_at        DATETIME     NULL,
+    used_by_uid    VARCHAR(128) NULL,
+    revoked_at     DATETIME     NULL,
+    UNIQUE KEY uq_admin_invites_code_hash (code_hash),
+    INDEX idx_admin_invites_created_by (created_by)
+);
@@ -0,0 +1,51 @@
+-- Role dan permission. Role dipisah per realm (admin/user), jadi kolom role
+-- di tabel admins/users hanya bermakna bila ada di tabel roles realm-nya.
+CREATE TABLE IF NOT EXISTS roles (
+    id           INT AUTO_INCREMENT PRIMARY KEY,
+    realm        VARCHAR(16)  NOT NULL,
+    name         VARCHAR(64)  NOT NULL,
+    description  VARCHAR(255) NOT NULL DEFAULT '',
+    created_at   DATETIME     NOT NULL,
+    UNIQUE KEY uq_roles_realm_name (realm, name)
+);
+
+CREATE TABLE IF NOT EXISTS permissions (
+    name         VARCHAR(64)  PRIMARY KEY,
+    description  VARCHAR(255) NOT NULL DEFAULT ''
+);
+
+CREATE TABLE IF NOT EXISTS role_permissions (
+    role_id      INT         NOT NULL,
+    permission   VARCHAR(64) NOT NULL,
+    PRIMARY KEY (role_id, permission),
+    FOREIGN KEY (role_id) REFERENCES roles(id) ON DELETE CASCADE,
+    FOREIGN KEY (permission) REFERENCES permissions(name) ON DELETE CASCADE
+);
+
+-- Permission bawaan
+INSERT IGNORE INTO permissions (name, description) VALUES
+    ('users:read',        'Lihat daftar dan detail user'),
+    ('users:update',      'Ubah nama, email dan foto user'),
+    ('users:assign_role', 'Ganti role user'),
+    ('users:delete',      'Hapus user'),
+    ('users:logout',      'Paksa logout semua session user'),
+    ('invites:manage',    'Buat, lihat dan cabut undangan admin'),
+    ('roles:manage',      'Atur role dan permission');
+
+-- Role bawaan: admin (semua permission), support, dan user biasa
+INSERT IGNORE INTO roles (realm, name, description, created_at) VALUES
+    ('admin', 'admin',   'Administrator dengan semua permission', NOW()),
+    ('admin', 'support', 'Tim support, hanya baca dan paksa logout', NOW()),
+    ('user',  'user',    'User biasa', NOW());
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, p.name FROM roles r CROSS JOIN permissions p
+    WHERE r.realm = 'admin' AND r.name = 'admin';
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, p.name FROM roles r JOIN permissions p ON p.name IN ('users:read', 'users:logout')
+    WHERE r.realm = 'admin' AND r.name = 'support';
+
+-- Role kosong/di luar daftar pada data lama dianggap role default realm
+UPDATE admins SET role = 'admin' WHERE role IS NULL OR role = '';
+UPDATE users  SET role = 'user'  WHERE role IS NULL OR role NOT IN (SELECT name FROM roles WHERE realm = 'user');
@@ -0,0 +1,8 @@
+-- Atribut untuk policy CEL: organisasi admin/user dan waktu akun dibuat
+ALTER TABLE admins
+    ADD COLUMN organization VARCHAR(128) NULL,
+    ADD COLUMN created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP;
+
+ALTER TABLE users
+    ADD COLUMN organization VARCHAR(128) NULL,
+    ADD COLUMN created_at   DATETIME     NOT NULL DEFAULT CURRENT_TIMESTAMP;
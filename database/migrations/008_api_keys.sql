@@ -0,0 +1,40 @@
+-- Service account untuk batch job dan tool internal. Service account punya
+-- realm dan role sendiri, tidak terhubung ke akun Google.
+CREATE TABLE IF NOT EXISTS service_accounts (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    realm         VARCHAR(16)  NOT NULL,
+    name          VARCHAR(64)  NOT NULL,
+    description   VARCHAR(255) NOT NULL DEFAULT '',
+    role          VARCHAR(64)  NOT NULL,
+    organization  VARCHAR(128) NULL,
+    created_by    INT          NOT NULL,
+    created_at    DATETIME     NOT NULL,
+    disabled_at   DATETIME     NULL,
+    UNIQUE KEY uq_service_accounts_realm_name (realm, name)
+);
+
+-- API key milik admin, user atau service account. Key hanya disimpan sebagai
+-- SHA-256; prefix dipakai untuk mencari row tanpa membuka key.
+CREATE TABLE IF NOT EXISTS api_keys (
+    id            INT AUTO_INCREMENT PRIMARY KEY,
+    prefix        CHAR(12)     NOT NULL,
+    key_hash      CHAR(64)     NOT NULL,
+    name          VARCHAR(64)  NOT NULL,
+    realm         VARCHAR(16)  NOT NULL,
+    owner_type    VARCHAR(16)  NOT NULL,
+    owner_id      INT          NOT NULL,
+    scopes        TEXT         NOT NULL,
+    expires_at    DATETIME     NOT NULL,
+    last_used_at  DATETIME     NULL,
+    created_at    DATETIME     NOT NULL,
+    revoked_at    DATETIME     NULL,
+    UNIQUE KEY uq_api_keys_prefix (prefix),
+    INDEX idx_api_keys_owner (realm, owner_type, owner_id)
+);
+
+INSERT IGNORE INTO permissions (name, description) VALUES
+    ('service_accounts:manage', 'Buat service account dan API key-nya');
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, 'service_accounts:manage' FROM roles r
+    WHERE r.realm = 'admin' AND r.name = 'admin';
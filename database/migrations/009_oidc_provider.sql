@@ -0,0 +1,38 @@
+-- Client OIDC (aplikasi internal yang login lewat service ini). Secret hanya
+-- disimpan sebagai hash; client public (SPA/native) tidak punya secret dan
+-- wajib memakai PKCE.
+CREATE TABLE IF NOT EXISTS oauth_clients (
+    client_id           VARCHAR(64)  PRIMARY KEY,
+    client_secret_hash  CHAR(64)     NULL,
+    name                VARCHAR(128) NOT NULL,
+    realm               VARCHAR(16)  NOT NULL,
+    redirect_uris       TEXT         NOT NULL,
+    scopes              TEXT         NOT NULL,
+    created_by          INT          NOT NULL,
+    created_at          DATETIME     NOT NULL,
+    disabled_at         DATETIME     NULL
+);
+
+-- Authorization code sekali pakai (hash), berlaku 1 menit
+CREATE TABLE IF NOT EXISTS oauth_authorization_codes (
+    code_hash              CHAR(64)     PRIMARY KEY,
+    client_id              VARCHAR(64)  NOT NULL,
+    realm                  VARCHAR(16)  NOT NULL,
+    user_id                INT          NOT NULL,
+    redirect_uri           TEXT         NOT NULL,
+    scope                  VARCHAR(255) NOT NULL,
+    nonce                  VARCHAR(255) NOT NULL DEFAULT '',
+    code_challenge         VARCHAR(128) NOT NULL,
+    auth_time              DATETIME     NOT NULL,
+    created_at             DATETIME     NOT NULL,
+    expires_at             DATETIME     NOT NULL,
+    used_at                DATETIME     NULL,
+    INDEX idx_oauth_codes_expires_at (expires_at)
+);
+
+INSERT IGNORE INTO permissions (name, description) VALUES
+    ('oauth_clients:manage', 'Daftarkan dan nonaktifkan client OIDC');
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, 'oauth_clients:manage' FROM roles r
+    WHERE r.realm = 'admin' AND r.name = 'admin';
@@ -0,0 +1,22 @@
+-- TOTP (RFC 6238) untuk admin. Secret disimpan terenkripsi (ENCRYPTION_KEY);
+-- enabled_at NULL berarti enrollment belum dikonfirmasi dengan kode pertama.
+CREATE TABLE IF NOT EXISTS admin_totp (
+    admin_id         INT          PRIMARY KEY,
+    secret           TEXT         NOT NULL,
+    enabled_at       DATETIME     NULL,
+    -- time step terakhir yang dipakai, kode yang sama tidak bisa dipakai ulang
+    last_used_step   BIGINT       NOT NULL DEFAULT 0,
+    failed_attempts  INT          NOT NULL DEFAULT 0,
+    locked_until     DATETIME     NULL,
+    created_at       DATETIME     NOT NULL
+);
+
+-- Recovery code sekali pakai, hanya disimpan sebagai SHA-256
+CREATE TABLE IF NOT EXISTS admin_recovery_codes (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id    INT          NOT NULL,
+    code_hash   CHAR(64)     NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    used_at     DATETIME     NULL,
+    UNIQUE KEY uq_admin_recovery_codes (admin_id, code_hash)
+);
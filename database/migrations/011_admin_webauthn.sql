@@ -0,0 +1,13 @@
+-- Passkey (WebAuthn) admin untuk step-up sebelum operasi sensitif.
+-- credential berisi JSON webauthn.Credential (public key, sign count, flags).
+CREATE TABLE IF NOT EXISTS admin_webauthn_credentials (
+    id             INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id       INT          NOT NULL,
+    credential_id  VARCHAR(512) NOT NULL,
+    name           VARCHAR(64)  NOT NULL,
+    credential     TEXT         NOT NULL,
+    created_at     DATETIME     NOT NULL,
+    last_used_at   DATETIME     NULL,
+    UNIQUE KEY uq_admin_webauthn_credential_id (credential_id),
+    INDEX idx_admin_webauthn_admin_id (admin_id)
+);
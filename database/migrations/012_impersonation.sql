@@ -0,0 +1,41 @@
+-- Impersonasi user oleh admin/support. Token-nya berumur pendek, tidak punya
+-- refresh token dan membawa klaim act berisi admin.
+CREATE TABLE IF NOT EXISTS impersonations (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id    INT          NOT NULL,
+    user_id     INT          NOT NULL,
+    reason      VARCHAR(255) NOT NULL,
+    token_id    VARCHAR(64)  NOT NULL,
+    ip_address  VARCHAR(64)  NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    expires_at  DATETIME     NOT NULL,
+    ended_at    DATETIME     NULL,
+    UNIQUE KEY uq_impersonations_token_id (token_id),
+    INDEX idx_impersonations_user (user_id),
+    INDEX idx_impersonations_admin (admin_id)
+);
+
+-- Audit setiap request yang memakai token impersonasi
+CREATE TABLE IF NOT EXISTS impersonation_requests (
+    id                INT AUTO_INCREMENT PRIMARY KEY,
+    impersonation_id  INT          NOT NULL,
+    method            VARCHAR(16)  NOT NULL,
+    path              VARCHAR(512) NOT NULL,
+    status            INT          NOT NULL,
+    ip_address        VARCHAR(64)  NOT NULL,
+    created_at        DATETIME     NOT NULL,
+    INDEX idx_impersonation_requests_impersonation (impersonation_id),
+    FOREIGN KEY (impersonation_id) REFERENCES impersonations(id) ON DELETE CASCADE
+);
+
+INSERT IGNORE INTO permissions (name, description) VALUES
+    ('users:impersonate',    'Login sebagai user untuk debugging (token singkat)'),
+    ('impersonations:audit', 'Lihat riwayat impersonasi dan request-nya');
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, p.name FROM roles r JOIN permissions p ON p.name IN ('users:impersonate', 'impersonations:audit')
+    WHERE r.realm = 'admin' AND r.name = 'admin';
+
+INSERT IGNORE INTO role_permissions (role_id, permission)
+    SELECT r.id, 'users:impersonate' FROM roles r
+    WHERE r.realm = 'admin' AND r.name = 'support';
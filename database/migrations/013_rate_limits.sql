@@ -0,0 +1,9 @@
+-- Token bucket rate limit yang dipakai bersama semua instance
+-- (RATE_LIMIT_STORE=mysql). Row yang lama tidak dipakai dibersihkan otomatis
+-- oleh RateLimitRepository.
+CREATE TABLE IF NOT EXISTS rate_limit_buckets (
+    bucket_key  VARCHAR(255) PRIMARY KEY,
+    tokens      DOUBLE       NOT NULL,
+    updated_at  DATETIME(6)  NOT NULL,
+    INDEX idx_rate_limit_buckets_updated_at (updated_at)
+);
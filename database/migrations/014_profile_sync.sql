@@ -0,0 +1,36 @@
+-- Sinkronisasi profil Google: google_name dan google_email menyimpan nilai
+-- terakhir dari ID token. Nama/email yang berbeda dengan nilai itu berarti
+-- sudah diubah sendiri (customised). Akun lama dibiarkan NULL, diisi saat
+-- login berikutnya.
+ALTER TABLE users
+    ADD COLUMN google_name  VARCHAR(255) NULL AFTER google_picture,
+    ADD COLUMN google_email VARCHAR(255) NULL AFTER google_name;
+
+ALTER TABLE admins
+    ADD COLUMN google_name  VARCHAR(255) NULL AFTER google_picture,
+    ADD COLUMN google_email VARCHAR(255) NULL AFTER google_name;
+
+-- Riwayat perubahan profil (sinkronisasi Google, edit sendiri, edit admin)
+CREATE TABLE IF NOT EXISTS profile_changes_user (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    user_id     INT          NOT NULL,
+    field       VARCHAR(32)  NOT NULL,
+    old_value   TEXT         NOT NULL,
+    new_value   TEXT         NOT NULL,
+    source      VARCHAR(16)  NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_profile_changes_user_user_id (user_id),
+    CONSTRAINT fk_profile_changes_user_user FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE
+);
+
+CREATE TABLE IF NOT EXISTS profile_changes_admin (
+    id          INT AUTO_INCREMENT PRIMARY KEY,
+    admin_id    INT          NOT NULL,
+    field       VARCHAR(32)  NOT NULL,
+    old_value   TEXT         NOT NULL,
+    new_value   TEXT         NOT NULL,
+    source      VARCHAR(16)  NOT NULL,
+    created_at  DATETIME     NOT NULL,
+    INDEX idx_profile_changes_admin_admin_id (admin_id),
+    CONSTRAINT fk_profile_changes_admin_admin FOREIGN KEY (admin_id) REFERENCES admins (id) ON DELETE CASCADE
+);
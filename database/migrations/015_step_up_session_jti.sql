@@ -0,0 +1,10 @@
+-- jti elevated token (step-up passkey) terakhir per session, supaya ikut
+-- dicabut saat logout. Elevated token hanya dibuat untuk admin, kolom di
+-- sessions_user disiapkan agar kedua tabel tetap sama.
+ALTER TABLE sessions_user
+    ADD COLUMN step_up_jti        VARCHAR(64) NULL AFTER access_expires_at,
+    ADD COLUMN step_up_expires_at DATETIME    NULL AFTER step_up_jti;
+
+ALTER TABLE sessions_admin
+    ADD COLUMN step_up_jti        VARCHAR(64) NULL AFTER access_expires_at,
+    ADD COLUMN step_up_expires_at DATETIME    NULL AFTER step_up_jti;
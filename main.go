@@ -1,6 +1,10 @@
 package main
 
 import (
+	"context"
+	"log"
+
+	"firebase.google.com/go/v4/auth"
 	"github.com/gin-gonic/gin"
 	"github.com/muhammadfarrasfajri/login-google/bootstrap"
 	"github.com/muhammadfarrasfajri/login-google/middleware"
@@ -18,26 +22,57 @@ func main() {
 	// Database
 	bootstrap.InitDatabase()
 
-	// Firebase
-	adminAuth, userAuth := bootstrap.InitFirebase()
+	// Firebase (hanya bila ID token diverifikasi lewat Firebase Admin SDK)
+	var adminAuth, userAuth *auth.Client
+	if bootstrap.FirebaseEnabled() {
+		adminAuth, userAuth = bootstrap.InitFirebase()
+	}
 
 	// Build container (repositories, services, controllers)
 	container := bootstrap.InitContainer(adminAuth, userAuth)
 
+	// Upgrade ciphertext lama ke ENCRYPTION_KEY versi aktif
+	go container.ReEncryptionJob.Run()
+
+	// Tutup session akun yang di-disable/dihapus/di-revoke di Firebase
+	if container.FirebaseReconciliationJob != nil {
+		go container.FirebaseReconciliationJob.Start(context.Background())
+	}
+
 	// GIN
 	r := gin.Default()
 	r.Static("/public", "./public")
 
+	// Proxy yang boleh mengisi X-Forwarded-For (IP client untuk rate limit)
+	if err := r.SetTrustedProxies(bootstrap.TrustedProxies()); err != nil {
+		log.Fatal("Failed to set trusted proxies: ", err)
+	}
+
 	// CORS Middleware
-	middleware.AttachCORS(r)
+	middleware.AttachCORS(r, bootstrap.AllowedOrigins())
 
 	// ROUTES
 	routes.SetupRoutes(
 		r,
 		container.AuthAdminController,
 		container.AuthUserController,
+		container.MeController,
+		container.MFAController,
 		container.UserController,
-		container.JWTManager,
+		container.JWKSController,
+		container.InviteController,
+		container.RoleController,
+		container.APIKeyController,
+		container.OIDCController,
+		container.WebAuthnController,
+		container.ImpersonationController,
+		container.AdminJWTManager,
+		container.UserJWTManager,
+		container.CSRF,
+		container.RateLimiter,
+		container.RBAC,
+		container.Policies,
+		container.StepUp,
 	)
 
 	r.Run(":8080")
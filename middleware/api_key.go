@@ -0,0 +1,119 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// API key dikirim lewat header terpisah, bukan Authorization: Bearer
+const APIKeyHeader = "X-API-Key"
+
+// Cara request diautentikasi (context "auth_method")
+const (
+	AuthMethodToken  = "token"
+	AuthMethodAPIKey = "api_key"
+)
+
+var ErrInvalidAPIKey = errors.New("invalid, expired or revoked api key")
+
+// APIKeyPrincipal adalah pemilik API key yang sudah divalidasi. Untuk service
+// account UserID bernilai 0 dan ServiceAccountID terisi.
+type APIKeyPrincipal struct {
+	KeyID            int
+	Realm            string
+	Subject          string
+	UserID           int
+	ServiceAccountID int
+	Email            string
+	Role             string
+	Organization     string
+	Scopes           []string
+}
+
+// APIKeyAuthenticator memvalidasi API key (diimplementasikan APIKeyService)
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(key string) (*APIKeyPrincipal, error)
+}
+
+// authenticateAPIKey mengisi context dengan nilai yang sama seperti access
+// token (user_id, email, role, realm, claims) supaya controller, RBAC dan
+// policy tidak perlu membedakan keduanya.
+func (j *JWTManager) authenticateAPIKey(c *gin.Context, key string) {
+	principal, ok := lookupAPIKey(c, j.APIKeys, key)
+	if !ok {
+		return
+	}
+
+	if principal.Realm != j.Realm {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "token realm mismatch"})
+		c.Abort()
+		return
+	}
+
+	setAPIKeyContext(c, principal)
+	c.Next()
+}
+
+// lookupAPIKey memvalidasi key; bila gagal response sudah dikirim
+func lookupAPIKey(c *gin.Context, authenticator APIKeyAuthenticator, key string) (*APIKeyPrincipal, bool) {
+	if authenticator == nil {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "api keys are not enabled"})
+		c.Abort()
+		return nil, false
+	}
+
+	principal, err := authenticator.AuthenticateAPIKey(key)
+	if errors.Is(err, ErrInvalidAPIKey) {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": err.Error()})
+		c.Abort()
+		return nil, false
+	}
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check api key"})
+		c.Abort()
+		return nil, false
+	}
+	return principal, true
+}
+
+func setAPIKeyContext(c *gin.Context, principal *APIKeyPrincipal) {
+	claims := &AccessClaims{
+		Realm:        principal.Realm,
+		UserID:       principal.UserID,
+		Email:        principal.Email,
+		Role:         principal.Role,
+		Organization: principal.Organization,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Subject: principal.Subject,
+		},
+	}
+
+	c.Set("auth_method", AuthMethodAPIKey)
+	c.Set("claims", claims)
+	c.Set("realm", principal.Realm)
+	c.Set("user_id", principal.UserID)
+	c.Set("email", principal.Email)
+	c.Set("role", principal.Role)
+	c.Set("api_key_id", principal.KeyID)
+	c.Set("api_key_scopes", principal.Scopes)
+	if principal.ServiceAccountID != 0 {
+		c.Set("service_account_id", principal.ServiceAccountID)
+	}
+}
+
+// RejectAPIKeys untuk route yang hanya boleh dipakai dengan login
+// interaktif (logout, kelola API key), supaya API key yang bocor tidak bisa
+// membuat key baru atau menutup semua session pemiliknya.
+func RejectAPIKeys() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if c.GetString("auth_method") == AuthMethodAPIKey {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: api keys cannot be used here"})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
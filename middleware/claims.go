@@ -0,0 +1,130 @@
+package middleware
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// Default issuer dan toleransi perbedaan jam antar server
+const (
+	DefaultIssuer      = "login-google"
+	DefaultClockLeeway = 30 * time.Second
+)
+
+var (
+	ErrInvalidClaims = errors.New("invalid token claims")
+	ErrSymmetricKey  = errors.New("active signing key is HS256, tokens for other services need RS256, ES256 or EdDSA")
+)
+
+// AccessClaims adalah isi access token. sub berisi "<realm>:<id>", aud berisi
+// audience realm, iss berisi issuer JWTManager.
+type AccessClaims struct {
+	Realm     string `json:"realm"`
+	UserID    int    `json:"user_id"`
+	SessionID int    `json:"sid,omitempty"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	// Organisasi akun, dipakai policy CEL (claims.org)
+	Organization string `json:"org,omitempty"`
+	// Hanya ada di elevated token hasil step-up: metode autentikasi
+	// (RFC 8176) dan waktu step-up
+	AMR      []string `json:"amr,omitempty"`
+	AuthTime int64    `json:"auth_time,omitempty"`
+	// Hanya ada di token impersonasi: admin yang bertindak sebagai user
+	Act *ActorClaim `json:"act,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// LegacyRefreshClaims adalah isi refresh token JWT format lama (HS256 dengan
+// REFRESH_SECRET). sid dan gen hanya ada di token yang dibuat setelah
+// session diperkenalkan.
+type LegacyRefreshClaims struct {
+	UserID     int  `json:"user_id"`
+	SessionID  *int `json:"sid,omitempty"`
+	Generation *int `json:"gen,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// ParseAccessToken memvalidasi signature (algoritma sesuai keyring), iss, aud,
+// exp, nbf dan iat, lalu memastikan token memang milik realm ini.
+func (j *JWTManager) ParseAccessToken(tokenString string) (*AccessClaims, error) {
+	claims := &AccessClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc,
+		jwt.WithValidMethods(j.AccessKeys.Algorithms()),
+		jwt.WithIssuer(j.Issuer),
+		jwt.WithAudience(j.Audience),
+		jwt.WithLeeway(j.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	// token dari realm lain (misalnya token user di route admin) ditolak
+	if claims.Realm != j.Realm || claims.Subject != j.Subject(claims.UserID) {
+		return nil, ErrRealmMismatch
+	}
+	if claims.ID == "" {
+		return nil, ErrInvalidClaims
+	}
+	return claims, nil
+}
+
+// ParseLegacyRefreshToken memvalidasi refresh token JWT format lama
+func (j *JWTManager) ParseLegacyRefreshToken(tokenString string) (*LegacyRefreshClaims, error) {
+	claims := &LegacyRefreshClaims{}
+	_, err := jwt.ParseWithClaims(tokenString, claims, func(t *jwt.Token) (interface{}, error) {
+		return j.RefreshSecret, nil
+	},
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithLeeway(j.Leeway),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID <= 0 {
+		return nil, ErrInvalidClaims
+	}
+	return claims, nil
+}
+
+// Sign menandatangani claims apa pun dengan key aktif keyring realm
+func (j *JWTManager) Sign(claims jwt.Claims) (string, error) {
+	key, err := j.AccessKeys.Active()
+	if err != nil {
+		return "", err
+	}
+	return signWithKey(key, claims)
+}
+
+// SignPublic seperti Sign, untuk token yang diverifikasi service lain lewat
+// JWKS (misalnya ID token OIDC). Key HS256 ditolak karena secret-nya tidak
+// boleh dibagikan.
+func (j *JWTManager) SignPublic(claims jwt.Claims) (string, error) {
+	key, err := j.AccessKeys.Active()
+	if err != nil {
+		return "", err
+	}
+	if !key.Asymmetric() {
+		return "", ErrSymmetricKey
+	}
+	return signWithKey(key, claims)
+}
+
+// ParseClaims memvalidasi token buatan Sign/SignPublic dengan issuer dan
+// audience yang ditentukan pemanggil
+func (j *JWTManager) ParseClaims(tokenString string, claims jwt.Claims, issuer, audience string) error {
+	_, err := jwt.ParseWithClaims(tokenString, claims, j.keyFunc,
+		jwt.WithValidMethods(j.AccessKeys.Algorithms()),
+		jwt.WithIssuer(issuer),
+		jwt.WithAudience(audience),
+		jwt.WithLeeway(j.Leeway),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	return err
+}
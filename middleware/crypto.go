@@ -6,33 +6,68 @@ import (
 	"crypto/rand"
 	"encoding/base64"
 	"errors"
+	"fmt"
 	"io"
 	"log"
 	"os"
+	"strconv"
+	"strings"
 )
 
-var encryptionKey []byte
+// Ciphertext diberi prefix versi key: "v<versi>:<base64(nonce+ciphertext)>".
+// Ciphertext lama tanpa prefix dicoba dengan semua key di keyring.
+var (
+	encryptionKeys    = map[int][]byte{}
+	currentKeyVersion int
+)
+
+var ErrUnknownKeyVersion = errors.New("unknown encryption key version")
 
+// InitEncryptionKey memuat keyring AES-256.
+//
+//   - ENCRYPTION_KEYS: daftar "versi:base64key" dipisah koma, misalnya
+//     "1:AAAA...,2:BBBB...", dengan ENCRYPTION_KEY_VERSION sebagai versi aktif
+//     (default versi terbesar)
+//   - tanpa ENCRYPTION_KEYS: ENCRYPTION_KEY dipakai sebagai versi 1
 func InitEncryptionKey() {
-	keyStr := os.Getenv("ENCRYPTION_KEY")
-	key, err := base64.StdEncoding.DecodeString(keyStr)
-	if err != nil {
-		log.Fatal("ENCRYPTION_KEY is not valid base64")
+	keys := os.Getenv("ENCRYPTION_KEYS")
+	if keys == "" {
+		keys = "1:" + os.Getenv("ENCRYPTION_KEY")
 	}
-	if len(key) != 32 {
-		log.Fatal("ENCRYPTION_KEY must be 32 bytes for AES-256")
+
+	for _, entry := range strings.Split(keys, ",") {
+		versionStr, keyStr, ok := strings.Cut(strings.TrimSpace(entry), ":")
+		version, err := strconv.Atoi(versionStr)
+		if !ok || err != nil || version <= 0 {
+			log.Fatalf("ENCRYPTION_KEYS entry %q must be <version>:<base64 key>", versionStr)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(keyStr)
+		if err != nil {
+			log.Fatalf("ENCRYPTION_KEY version %d is not valid base64", version)
+		}
+		if len(key) != 32 {
+			log.Fatalf("ENCRYPTION_KEY version %d must be 32 bytes for AES-256", version)
+		}
+
+		encryptionKeys[version] = key
+		if version > currentKeyVersion {
+			currentKeyVersion = version
+		}
 	}
-	encryptionKey = key
-}
 
-// Encrypt plaintext string → base64 cipher
-func Encrypt(text string) (string, error) {
-	block, err := aes.NewCipher(encryptionKey)
-	if err != nil {
-		return "", err
+	if v := os.Getenv("ENCRYPTION_KEY_VERSION"); v != "" {
+		version, err := strconv.Atoi(v)
+		if err != nil || encryptionKeys[version] == nil {
+			log.Fatalf("ENCRYPTION_KEY_VERSION %q is not in ENCRYPTION_KEYS", v)
+		}
+		currentKeyVersion = version
 	}
+}
 
-	gcm, err := cipher.NewGCM(block)
+// Encrypt plaintext string → "v<versi>:" + base64 cipher
+func Encrypt(text string) (string, error) {
+	gcm, err := newGCM(encryptionKeys[currentKeyVersion])
 	if err != nil {
 		return "", err
 	}
@@ -43,22 +78,72 @@ func Encrypt(text string) (string, error) {
 	}
 
 	ciphertext := gcm.Seal(nonce, nonce, []byte(text), nil)
-	return base64.StdEncoding.EncodeToString(ciphertext), nil
+	return fmt.Sprintf("v%d:%s", currentKeyVersion, base64.StdEncoding.EncodeToString(ciphertext)), nil
 }
 
-// Decrypt base64 cipher → plaintext string
+// Decrypt cipher (dengan atau tanpa prefix versi) → plaintext string
 func Decrypt(encrypted string) (string, error) {
-	data, err := base64.StdEncoding.DecodeString(encrypted)
+	version, payload, versioned := splitKeyVersion(encrypted)
+
+	data, err := base64.StdEncoding.DecodeString(payload)
 	if err != nil {
 		return "", err
 	}
 
-	block, err := aes.NewCipher(encryptionKey)
+	if versioned {
+		key, ok := encryptionKeys[version]
+		if !ok {
+			return "", ErrUnknownKeyVersion
+		}
+		return decryptWithKey(key, data)
+	}
+
+	// ciphertext lama: coba semua key, GCM menolak key yang salah
+	for _, key := range encryptionKeys {
+		if plaintext, err := decryptWithKey(key, data); err == nil {
+			return plaintext, nil
+		}
+	}
+	return "", errors.New("invalid encrypted data")
+}
+
+// NeedsReEncryption true bila ciphertext tidak dibuat dengan key aktif
+func NeedsReEncryption(encrypted string) bool {
+	version, _, versioned := splitKeyVersion(encrypted)
+	return !versioned || version != currentKeyVersion
+}
+
+// ReEncrypt membuka ciphertext lalu mengenkripsi ulang dengan key aktif
+func ReEncrypt(encrypted string) (string, error) {
+	plaintext, err := Decrypt(encrypted)
 	if err != nil {
 		return "", err
 	}
+	return Encrypt(plaintext)
+}
+
+func splitKeyVersion(encrypted string) (int, string, bool) {
+	prefix, payload, ok := strings.Cut(encrypted, ":")
+	if !ok || !strings.HasPrefix(prefix, "v") {
+		return 0, encrypted, false
+	}
+	version, err := strconv.Atoi(prefix[1:])
+	if err != nil {
+		return 0, encrypted, false
+	}
+	return version, payload, true
+}
+
+func newGCM(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
 
-	gcm, err := cipher.NewGCM(block)
+func decryptWithKey(key []byte, data []byte) (string, error) {
+	gcm, err := newGCM(key)
 	if err != nil {
 		return "", err
 	}
@@ -0,0 +1,141 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	CSRFCookie = "csrf_token"
+	CSRFHeader = "X-CSRF-Token"
+)
+
+// CSRFProtection memakai signed double-submit token: token diberikan saat
+// login (cookie csrf_token + JSON) dan harus dikirim ulang lewat header
+// X-CSRF-Token. Token terikat ke session id, jadi token milik session lain
+// (misalnya dari login penyerang sendiri, atau cookie yang ditanam dari
+// subdomain lain) ditolak. Pengecekan hanya berlaku untuk request yang
+// membawa cookie auth, client native yang memakai body/header tidak
+// terpengaruh.
+type CSRFProtection struct {
+	Secret         []byte
+	AllowedOrigins map[string]bool
+}
+
+func NewCSRFProtection(secret []byte, allowedOrigins []string) *CSRFProtection {
+	origins := map[string]bool{}
+	for _, o := range allowedOrigins {
+		origins[strings.TrimRight(o, "/")] = true
+	}
+	return &CSRFProtection{
+		Secret:         secret,
+		AllowedOrigins: origins,
+	}
+}
+
+// Token baru untuk session: "<session_id>.<random>.<hmac(session_id.random)>"
+func (p *CSRFProtection) NewToken(sessionID int) (string, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	payload := strconv.Itoa(sessionID) + "." + base64.RawURLEncoding.EncodeToString(b)
+	return payload + "." + p.sign(payload), nil
+}
+
+func (p *CSRFProtection) Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if !hasAuthCookie(c) {
+			c.Next()
+			return
+		}
+
+		if !p.originAllowed(c.Request) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "csrf: origin not allowed"})
+			c.Abort()
+			return
+		}
+
+		cookie, _ := c.Cookie(CSRFCookie)
+		header := c.GetHeader(CSRFHeader)
+		if cookie == "" || header == "" ||
+			subtle.ConstantTimeCompare([]byte(cookie), []byte(header)) != 1 ||
+			!p.valid(header, requestSessionID(c)) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "csrf: invalid token"})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+func (p *CSRFProtection) sign(payload string) string {
+	mac := hmac.New(sha256.New, p.Secret)
+	mac.Write([]byte("csrf:" + payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// Token valid bila tanda tangannya cocok dan dibuat untuk sessionID
+func (p *CSRFProtection) valid(token string, sessionID int) bool {
+	if sessionID <= 0 {
+		return false
+	}
+	i := strings.LastIndex(token, ".")
+	if i < 0 {
+		return false
+	}
+	payload, sig := token[:i], token[i+1:]
+	sid, _, ok := strings.Cut(payload, ".")
+	if !ok || sid != strconv.Itoa(sessionID) {
+		return false
+	}
+	return hmac.Equal([]byte(sig), []byte(p.sign(payload)))
+}
+
+// requestSessionID: session dari cookie refresh_token (endpoint refresh),
+// atau dari access token bila AuthMiddleware sudah berjalan (logout). 0 bila
+// request tidak membawa keduanya.
+func requestSessionID(c *gin.Context) int {
+	if token, err := c.Cookie("refresh_token"); err == nil && token != "" {
+		parts := strings.Split(token, ".")
+		if len(parts) < 2 {
+			return 0
+		}
+		sessionID, _ := strconv.Atoi(parts[len(parts)-2])
+		return sessionID
+	}
+	return c.GetInt("session_id")
+}
+
+// Origin harus ada di daftar; bila Origin tidak dikirim, pakai Referer.
+// Request tanpa keduanya ditolak.
+func (p *CSRFProtection) originAllowed(r *http.Request) bool {
+	origin := r.Header.Get("Origin")
+	if origin == "" || origin == "null" {
+		ref, err := url.Parse(r.Header.Get("Referer"))
+		if err != nil || ref.Scheme == "" || ref.Host == "" {
+			return false
+		}
+		origin = ref.Scheme + "://" + ref.Host
+	}
+	return p.AllowedOrigins[origin]
+}
+
+func hasAuthCookie(c *gin.Context) bool {
+	for _, name := range []string{"refresh_token", CSRFCookie} {
+		if v, err := c.Cookie(name); err == nil && v != "" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestCSRFMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	p := NewCSRFProtection([]byte("csrf-secret"), []string{"https://app.example.com/"})
+	other := NewCSRFProtection([]byte("other-secret"), nil)
+
+	token, err := p.NewToken(7)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSession, _ := p.NewToken(8)
+	otherSecret, _ := other.NewToken(7)
+
+	tests := []struct {
+		name      string
+		origin    string
+		referer   string
+		refresh   string
+		cookie    string
+		header    string
+		sessionID int
+		want      int
+	}{
+		{name: "no auth cookie", want: http.StatusOK},
+		{name: "valid token", origin: "https://app.example.com", refresh: "user.7.abc", cookie: token, header: token, want: http.StatusOK},
+		{name: "referer fallback", referer: "https://app.example.com/login", refresh: "user.7.abc", cookie: token, header: token, want: http.StatusOK},
+		{name: "session from access token", origin: "https://app.example.com", sessionID: 7, cookie: token, header: token, want: http.StatusOK},
+		{name: "origin not allowed", origin: "https://evil.example.com", refresh: "user.7.abc", cookie: token, header: token, want: http.StatusForbidden},
+		{name: "no origin or referer", refresh: "user.7.abc", cookie: token, header: token, want: http.StatusForbidden},
+		{name: "missing header", origin: "https://app.example.com", refresh: "user.7.abc", cookie: token, want: http.StatusForbidden},
+		{name: "header differs from cookie", origin: "https://app.example.com", refresh: "user.7.abc", cookie: token, header: otherSession, want: http.StatusForbidden},
+		{name: "token of another session", origin: "https://app.example.com", refresh: "user.7.abc", cookie: otherSession, header: otherSession, want: http.StatusForbidden},
+		{name: "token signed with another secret", origin: "https://app.example.com", refresh: "user.7.abc", cookie: otherSecret, header: otherSecret, want: http.StatusForbidden},
+		{name: "no session to bind", origin: "https://app.example.com", cookie: token, header: token, want: http.StatusForbidden},
+		{name: "unsigned token", origin: "https://app.example.com", refresh: "user.7.abc", cookie: "7.random", header: "7.random", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/refresh", func(c *gin.Context) {
+				if tt.sessionID != 0 {
+					c.Set("session_id", tt.sessionID)
+				}
+			}, p.Middleware(), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			req := httptest.NewRequest(http.MethodPost, "/refresh", nil)
+			if tt.origin != "" {
+				req.Header.Set("Origin", tt.origin)
+			}
+			if tt.referer != "" {
+				req.Header.Set("Referer", tt.referer)
+			}
+			if tt.refresh != "" {
+				req.AddCookie(&http.Cookie{Name: "refresh_token", Value: tt.refresh})
+			}
+			if tt.cookie != "" {
+				req.AddCookie(&http.Cookie{Name: CSRFCookie, Value: tt.cookie})
+			}
+			if tt.header != "" {
+				req.Header.Set(CSRFHeader, tt.header)
+			}
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+		})
+	}
+}
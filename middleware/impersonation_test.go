@@ -0,0 +1,65 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeImpersonationAuditor menandai jti yang impersonasinya masih berjalan
+type fakeImpersonationAuditor struct {
+	active   map[string]bool
+	recorded int
+}
+
+func (a *fakeImpersonationAuditor) ImpersonationActive(tokenID string) (bool, error) {
+	return a.active[tokenID], nil
+}
+
+func (a *fakeImpersonationAuditor) RecordImpersonatedRequest(tokenID, method, path string, status int, ip string) error {
+	a.recorded++
+	return nil
+}
+
+func TestAuthMiddlewareRejectsEndedImpersonation(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	keys := NewSingleKeyring(NewHMACKey("test", []byte("test-access-secret")))
+	manager := NewJWTManager(RealmUser, "login-google-user", keys, "test-refresh-secret", NewMemoryRevocationStore())
+	auditor := &fakeImpersonationAuditor{active: map[string]bool{}}
+	manager.Impersonation = auditor
+
+	token, jti, err := manager.GenerateImpersonationToken(1, "user@example.com", "user", "", ActorClaim{Subject: "admin:1"}, time.Minute)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	r := gin.New()
+	r.GET("/me", manager.AuthMiddleware(), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	serve := func() int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	auditor.active[jti] = true
+	if code := serve(); code != http.StatusOK {
+		t.Fatalf("active impersonation status = %d, want %d", code, http.StatusOK)
+	}
+
+	// impersonasi diakhiri tapi jti belum masuk denylist
+	auditor.active[jti] = false
+	if code := serve(); code != http.StatusUnauthorized {
+		t.Fatalf("ended impersonation status = %d, want %d", code, http.StatusUnauthorized)
+	}
+	if auditor.recorded != 1 {
+		t.Fatalf("recorded %d requests, want 1", auditor.recorded)
+	}
+}
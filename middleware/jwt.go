@@ -1,6 +1,8 @@
 package middleware
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"strings"
 	"time"
@@ -9,45 +11,120 @@ import (
 	"github.com/golang-jwt/jwt/v5"
 )
 
+// Masa berlaku access token
+const AccessTokenTTL = 10 * time.Minute
 
+// Realm token: akun admin (tabel admins) dan user (tabel users) punya id
+// sendiri-sendiri, jadi token selalu membawa realm dan subject "<realm>:<id>"
+const (
+	RealmAdmin = "admin"
+	RealmUser  = "user"
+)
+
+var ErrRealmMismatch = errors.New("token realm mismatch")
+
+// JWTManager membuat dan memvalidasi token untuk satu realm. Setiap realm
+// punya audience sendiri dan boleh memakai keyring sendiri.
 type JWTManager struct {
-	AccessSecret  []byte
+	Realm         string
+	Audience      string
+	Issuer        string
+	Leeway        time.Duration
+	AccessKeys    *Keyring
 	RefreshSecret []byte
+	Revocations   RevocationStore
+	// APIKeys opsional, bila diisi AuthMiddleware juga menerima header X-API-Key
+	APIKeys APIKeyAuthenticator
+	// Impersonation opsional, mencatat request yang memakai token impersonasi
+	Impersonation ImpersonationAuditor
 }
 
-func NewJWTManager(accessSecret, refreshSecret string) *JWTManager {
+func NewJWTManager(realm, audience string, accessKeys *Keyring, refreshSecret string, revocations RevocationStore) *JWTManager {
 	return &JWTManager{
-		AccessSecret:  []byte(accessSecret),
+		Realm:         realm,
+		Audience:      audience,
+		Issuer:        DefaultIssuer,
+		Leeway:        DefaultClockLeeway,
+		AccessKeys:    accessKeys,
 		RefreshSecret: []byte(refreshSecret),
+		Revocations:   revocations,
 	}
 }
 
-// Generate JWT Token
-func (j *JWTManager) GenerateAccessToken(userID int, email, role string) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"email":   email,
-		"role":    role,
-		"exp":     time.Now().Add(10 * time.Minute).Unix(),
+// Subject token, misalnya "admin:5"
+func (j *JWTManager) Subject(userID int) string {
+	return fmt.Sprintf("%s:%d", j.Realm, userID)
+}
+
+// Generate JWT Token, mengembalikan token dan jti-nya
+func (j *JWTManager) GenerateAccessToken(userID, sessionID int, email, role, org string) (string, string, error) {
+	key, err := j.AccessKeys.Active()
+	if err != nil {
+		return "", "", err
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.AccessSecret)
-}
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", "", err
+	}
 
-func (j *JWTManager) GenerateRefreshToken(userID int) (string, error) {
-	claims := jwt.MapClaims{
-		"user_id": userID,
-		"exp":     time.Now().Add(7 * 24 * time.Hour).Unix(),
+	now := time.Now()
+	claims := AccessClaims{
+		Realm:        j.Realm,
+		UserID:       userID,
+		SessionID:    sessionID,
+		Email:        email,
+		Role:         role,
+		Organization: org,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    j.Issuer,
+			Subject:   j.Subject(userID),
+			Audience:  jwt.ClaimStrings{j.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+		},
 	}
 
-	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
-	return token.SignedString(j.RefreshSecret)
+	signed, err := signWithKey(key, claims)
+	return signed, jti, err
+}
+
+func signWithKey(key *SigningKey, claims jwt.Claims) (string, error) {
+	token := jwt.NewWithClaims(key.Method, claims)
+	token.Header["kid"] = key.KID
+	return token.SignedString(key.signKey)
+}
+
+// JWKS berisi public key access token untuk /.well-known/jwks.json. Key HS256
+// tidak pernah dipublikasikan.
+func (j *JWTManager) JWKS() map[string]interface{} {
+	return map[string]interface{}{"keys": j.AccessKeys.JWKs()}
+}
+
+// keyFunc memilih key verifikasi dari header kid dan memastikan algoritma
+// token sama dengan algoritma key tersebut.
+func (j *JWTManager) keyFunc(t *jwt.Token) (interface{}, error) {
+	kid, _ := t.Header["kid"].(string)
+	key, err := j.AccessKeys.Lookup(kid)
+	if err != nil {
+		return nil, err
+	}
+	if t.Method.Alg() != key.Method.Alg() {
+		return nil, jwt.ErrTokenSignatureInvalid
+	}
+	return key.verifyKey, nil
 }
 
 // Middleware untuk validasi token
 func (j *JWTManager) AuthMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			j.authenticateAPIKey(c, key)
+			return
+		}
+
 		authHeader := c.GetHeader("Authorization")
 
 		if authHeader == "" || !strings.HasPrefix(authHeader, "Bearer ") {
@@ -58,20 +135,48 @@ func (j *JWTManager) AuthMiddleware() gin.HandlerFunc {
 
 		tokenString := strings.TrimPrefix(authHeader, "Bearer ")
 
-		token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-			return j.AccessSecret, nil
-		})
-
-		if err != nil || !token.Valid {
+		claims, err := j.ParseAccessToken(tokenString)
+		if errors.Is(err, ErrRealmMismatch) {
+			c.JSON(http.StatusUnauthorized, gin.H{"error": "token realm mismatch"})
+			c.Abort()
+			return
+		}
+		if err != nil {
 			c.JSON(http.StatusUnauthorized, gin.H{"error": "invalid token"})
 			c.Abort()
 			return
 		}
 
-		claims := token.Claims.(jwt.MapClaims)
-		c.Set("user_id", int(claims["user_id"].(float64)))
-		c.Set("email", claims["email"].(string))
-		c.Set("role", claims["role"])
+		// tolak token yang sudah dicabut (logout, force logout, ganti role)
+		if j.Revocations != nil {
+			revoked, err := j.Revocations.IsRevoked(claims.ID)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check token"})
+				c.Abort()
+				return
+			}
+			if revoked {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token revoked"})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Set("auth_method", AuthMethodToken)
+		c.Set("claims", claims)
+		c.Set("jti", claims.ID)
+		c.Set("realm", claims.Realm)
+		c.Set("user_id", claims.UserID)
+		c.Set("email", claims.Email)
+		c.Set("role", claims.Role)
+		if claims.SessionID != 0 {
+			c.Set("session_id", claims.SessionID)
+		}
+
+		if claims.Act != nil {
+			j.serveImpersonated(c, claims)
+			return
+		}
 
 		c.Next()
 	}
@@ -0,0 +1,173 @@
+package middleware
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+)
+
+// Status key di keyring:
+//   - pending: sudah dipublikasikan di JWKS dan bisa memverifikasi, tapi belum
+//     dipakai untuk tanda tangan (beri waktu service lain refresh cache JWKS)
+//   - active: dipakai menandatangani token baru, hanya boleh satu
+//   - retired: tidak dipakai tanda tangan, token lama tetap valid sampai RetireAt
+const (
+	KeyPending = "pending"
+	KeyActive  = "active"
+	KeyRetired = "retired"
+)
+
+var (
+	ErrKeyNotFound    = errors.New("signing key not found")
+	ErrNoActiveKey    = errors.New("keyring has no active signing key")
+	ErrDuplicateKeyID = errors.New("duplicate signing key id")
+)
+
+type KeyringKey struct {
+	Key      *SigningKey
+	Status   string
+	RetireAt time.Time
+}
+
+// Keyring menyimpan semua signing key access token. Setiap token membawa
+// header kid, jadi key bisa dirotasi tanpa membuat semua user ter-logout.
+type Keyring struct {
+	mu     sync.RWMutex
+	keys   map[string]*KeyringKey
+	active string
+}
+
+func NewKeyring() *Keyring {
+	return &Keyring{
+		keys: make(map[string]*KeyringKey),
+	}
+}
+
+// Keyring berisi satu key aktif, dipakai bila tidak ada file keyring
+func NewSingleKeyring(key *SigningKey) *Keyring {
+	ring := NewKeyring()
+	ring.keys[key.KID] = &KeyringKey{Key: key, Status: KeyActive}
+	ring.active = key.KID
+	return ring
+}
+
+// Ganti seluruh isi keyring sekaligus (dipakai saat file keyring di-reload)
+func (r *Keyring) Replace(keys []KeyringKey) error {
+	next := make(map[string]*KeyringKey, len(keys))
+	active := ""
+
+	for i := range keys {
+		k := keys[i]
+		if _, ok := next[k.Key.KID]; ok {
+			return fmt.Errorf("%w: %s", ErrDuplicateKeyID, k.Key.KID)
+		}
+		switch k.Status {
+		case KeyActive:
+			if active != "" {
+				return fmt.Errorf("keyring has more than one active key: %s, %s", active, k.Key.KID)
+			}
+			active = k.Key.KID
+		case KeyPending, KeyRetired:
+		default:
+			return fmt.Errorf("signing key %s: unknown status %q", k.Key.KID, k.Status)
+		}
+		next[k.Key.KID] = &k
+	}
+
+	if active == "" {
+		return ErrNoActiveKey
+	}
+
+	r.mu.Lock()
+	r.keys = next
+	r.active = active
+	r.mu.Unlock()
+	return nil
+}
+
+// Key untuk menandatangani token baru
+func (r *Keyring) Active() (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	entry, ok := r.keys[r.active]
+	if !ok {
+		return nil, ErrNoActiveKey
+	}
+	return entry.Key, nil
+}
+
+// Key untuk memverifikasi token dengan kid tertentu. Token tanpa kid (dibuat
+// sebelum ada keyring) diverifikasi dengan key aktif.
+func (r *Keyring) Lookup(kid string) (*SigningKey, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	if kid == "" {
+		kid = r.active
+	}
+
+	entry, ok := r.keys[kid]
+	if !ok || !entry.usable(time.Now()) {
+		return nil, ErrKeyNotFound
+	}
+	return entry.Key, nil
+}
+
+// Algoritma semua key yang masih bisa memverifikasi. Algoritma yang hanya
+// dipakai key retired yang sudah lewat RetireAt tidak diterima lagi.
+func (r *Keyring) Algorithms() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	seen := map[string]bool{}
+	algs := []string{}
+	for _, entry := range r.keys {
+		if !entry.usable(now) {
+			continue
+		}
+		alg := entry.Key.Method.Alg()
+		if !seen[alg] {
+			seen[alg] = true
+			algs = append(algs, alg)
+		}
+	}
+	sort.Strings(algs)
+	return algs
+}
+
+// Public key yang dipublikasikan di JWKS: pending, active dan retired yang
+// belum lewat RetireAt
+func (r *Keyring) JWKs() []map[string]string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	now := time.Now()
+	kids := make([]string, 0, len(r.keys))
+	for kid := range r.keys {
+		kids = append(kids, kid)
+	}
+	sort.Strings(kids)
+
+	jwks := []map[string]string{}
+	for _, kid := range kids {
+		entry := r.keys[kid]
+		if !entry.usable(now) {
+			continue
+		}
+		if jwk, ok := entry.Key.JWK(); ok {
+			jwks = append(jwks, jwk)
+		}
+	}
+	return jwks
+}
+
+func (k *KeyringKey) usable(now time.Time) bool {
+	if k.Status == KeyRetired {
+		return now.Before(k.RetireAt)
+	}
+	return true
+}
@@ -0,0 +1,37 @@
+package middleware
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+func TestKeyringAlgorithmsDropExpiredRetiredKeys(t *testing.T) {
+	active := NewHMACKey("hs-2", []byte("secret"))
+	retired := &SigningKey{KID: "rs-1", Method: jwt.SigningMethodRS256}
+
+	ring := NewKeyring()
+	err := ring.Replace([]KeyringKey{
+		{Key: active, Status: KeyActive},
+		{Key: retired, Status: KeyRetired, RetireAt: time.Now().Add(time.Hour)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ring.Algorithms(), []string{"HS256", "RS256"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Algorithms() = %v, want %v", got, want)
+	}
+
+	err = ring.Replace([]KeyringKey{
+		{Key: active, Status: KeyActive},
+		{Key: retired, Status: KeyRetired, RetireAt: time.Now().Add(-time.Minute)},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got, want := ring.Algorithms(), []string{"HS256"}; !reflect.DeepEqual(got, want) {
+		t.Fatalf("Algorithms() after retirement = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,139 @@
+package middleware
+
+import (
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// PermissionStore mengambil permission role dari database
+type PermissionStore interface {
+	PermissionsForRole(realm, role string) ([]string, error)
+}
+
+// Lama permission role disimpan di memori sebelum dibaca ulang
+const PermissionCacheTTL = 30 * time.Second
+
+type cachedPermissions struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+// RBAC mengecek permission role dari token (realm + role). Permission dibaca
+// dari PermissionStore dan di-cache sebentar, jadi perubahan mapping
+// role→permission berlaku tanpa login ulang.
+type RBAC struct {
+	Store PermissionStore
+
+	mu    sync.Mutex
+	cache map[string]cachedPermissions
+}
+
+func NewRBAC(store PermissionStore) *RBAC {
+	return &RBAC{
+		Store: store,
+		cache: map[string]cachedPermissions{},
+	}
+}
+
+// RequirePermission dipasang setelah AuthMiddleware, contoh:
+//
+//	admin.DELETE("/users/:id", rbac.RequirePermission("users:delete"), ...)
+func (r *RBAC) RequirePermission(permission string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		permissions, err := r.permissions(c.GetString("realm"), c.GetString("role"))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to check permission"})
+			c.Abort()
+			return
+		}
+
+		if !permissions[permission] {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: missing permission " + permission})
+			c.Abort()
+			return
+		}
+
+		// API key hanya boleh memakai permission yang ada di scope-nya
+		if !scopeAllows(c, permission) {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: api key scope does not include " + permission})
+			c.Abort()
+			return
+		}
+
+		c.Set("permissions", scopedPermissions(c, permissions))
+		c.Next()
+	}
+}
+
+// HasPermission untuk pengecekan tambahan di dalam handler (misalnya ganti
+// role saat update user)
+func (r *RBAC) HasPermission(c *gin.Context, permission string) bool {
+	permissions, err := r.permissions(c.GetString("realm"), c.GetString("role"))
+	return err == nil && permissions[permission] && scopeAllows(c, permission)
+}
+
+// scopedPermissions adalah permission role yang juga ada di scope API key
+func scopedPermissions(c *gin.Context, permissions map[string]bool) map[string]bool {
+	if _, ok := c.Get("api_key_scopes"); !ok {
+		return permissions
+	}
+	scoped := map[string]bool{}
+	for p := range permissions {
+		if scopeAllows(c, p) {
+			scoped[p] = true
+		}
+	}
+	return scoped
+}
+
+func scopeAllows(c *gin.Context, permission string) bool {
+	v, ok := c.Get("api_key_scopes")
+	if !ok {
+		return true
+	}
+	scopes, _ := v.([]string)
+	for _, s := range scopes {
+		if s == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Invalidate menghapus cache, dipanggil setelah mapping role berubah
+func (r *RBAC) Invalidate() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.cache = map[string]cachedPermissions{}
+}
+
+func (r *RBAC) permissions(realm, role string) (map[string]bool, error) {
+	if realm == "" || role == "" {
+		return map[string]bool{}, nil
+	}
+	key := realm + ":" + role
+
+	r.mu.Lock()
+	cached, ok := r.cache[key]
+	r.mu.Unlock()
+	if ok && time.Since(cached.loadedAt) < PermissionCacheTTL {
+		return cached.permissions, nil
+	}
+
+	list, err := r.Store.PermissionsForRole(realm, role)
+	if err != nil {
+		return nil, err
+	}
+	permissions := map[string]bool{}
+	for _, p := range list {
+		permissions[p] = true
+	}
+
+	r.mu.Lock()
+	r.cache[key] = cachedPermissions{permissions: permissions, loadedAt: time.Now()}
+	r.mu.Unlock()
+	return permissions, nil
+}
@@ -0,0 +1,167 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakePermissionStore memetakan "realm:role" ke permission
+type fakePermissionStore struct {
+	roles map[string][]string
+	err   error
+	loads int
+}
+
+func (s *fakePermissionStore) PermissionsForRole(realm, role string) ([]string, error) {
+	s.loads++
+	if s.err != nil {
+		return nil, s.err
+	}
+	return s.roles[realm+":"+role], nil
+}
+
+// serveWithPermission menjalankan RequirePermission dengan context yang
+// biasanya diisi AuthMiddleware
+func serveWithPermission(rbac *RBAC, permission string, values map[string]interface{}) int {
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		for k, v := range values {
+			c.Set(k, v)
+		}
+	}, rbac.RequirePermission(permission), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/", nil))
+	return w.Code
+}
+
+func TestRequirePermission(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakePermissionStore{roles: map[string][]string{
+		"admin:admin":   {"users:read", "users:delete"},
+		"admin:support": {"users:read"},
+		"user:admin":    {"users:delete"},
+	}}
+
+	tests := []struct {
+		name       string
+		realm      string
+		role       string
+		permission string
+		want       int
+	}{
+		{name: "role has permission", realm: "admin", role: "admin", permission: "users:delete", want: http.StatusOK},
+		{name: "role lacks permission", realm: "admin", role: "support", permission: "users:delete", want: http.StatusForbidden},
+		{name: "unknown role", realm: "admin", role: "guest", permission: "users:read", want: http.StatusForbidden},
+		{name: "no role", realm: "admin", permission: "users:read", want: http.StatusForbidden},
+		// role bernama sama di realm lain tidak ikut berlaku
+		{name: "same role in another realm", realm: "user", role: "admin", permission: "users:read", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			code := serveWithPermission(NewRBAC(store), tt.permission, map[string]interface{}{"realm": tt.realm, "role": tt.role})
+			if code != tt.want {
+				t.Fatalf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRequirePermissionStoreError(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rbac := NewRBAC(&fakePermissionStore{err: errors.New("db down")})
+	if code := serveWithPermission(rbac, "users:read", map[string]interface{}{"realm": "admin", "role": "admin"}); code != http.StatusInternalServerError {
+		t.Fatalf("status = %d, want %d", code, http.StatusInternalServerError)
+	}
+}
+
+func TestRBACCachesUntilInvalidated(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakePermissionStore{roles: map[string][]string{"admin:support": {"users:read"}}}
+	rbac := NewRBAC(store)
+	values := map[string]interface{}{"realm": "admin", "role": "support"}
+
+	serveWithPermission(rbac, "users:read", values)
+	serveWithPermission(rbac, "users:read", values)
+	if store.loads != 1 {
+		t.Fatalf("store loaded %d times, want 1", store.loads)
+	}
+
+	store.roles["admin:support"] = []string{"users:read", "users:delete"}
+	rbac.Invalidate()
+	if code := serveWithPermission(rbac, "users:delete", values); code != http.StatusOK {
+		t.Fatalf("status after Invalidate = %d, want %d", code, http.StatusOK)
+	}
+}
+
+func TestRequirePermissionAPIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	store := &fakePermissionStore{roles: map[string][]string{
+		"admin:admin": {"users:read", "users:delete"},
+	}}
+
+	tests := []struct {
+		name       string
+		scopes     interface{} // nil: access token, tanpa api_key_scopes
+		permission string
+		want       int
+	}{
+		{name: "access token uses every role permission", permission: "users:delete", want: http.StatusOK},
+		{name: "scope includes permission", scopes: []string{"users:read", "users:delete"}, permission: "users:delete", want: http.StatusOK},
+		{name: "scope excludes permission", scopes: []string{"users:read"}, permission: "users:delete", want: http.StatusForbidden},
+		{name: "empty scope", scopes: []string{}, permission: "users:read", want: http.StatusForbidden},
+		// scope tidak bisa menambah permission di luar role
+		{name: "scope outside role", scopes: []string{"roles:write"}, permission: "roles:write", want: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			values := map[string]interface{}{"realm": "admin", "role": "admin"}
+			if tt.scopes != nil {
+				values["api_key_scopes"] = tt.scopes
+			}
+			if code := serveWithPermission(NewRBAC(store), tt.permission, values); code != tt.want {
+				t.Fatalf("status = %d, want %d", code, tt.want)
+			}
+		})
+	}
+}
+
+func TestRBACHasPermissionRespectsAPIKeyScopes(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	rbac := NewRBAC(&fakePermissionStore{roles: map[string][]string{
+		"admin:admin": {"users:read", "users:update", "roles:assign"},
+	}})
+
+	var scoped map[string]bool
+	var canAssign bool
+	r := gin.New()
+	r.GET("/", func(c *gin.Context) {
+		c.Set("realm", "admin")
+		c.Set("role", "admin")
+		c.Set("api_key_scopes", []string{"users:read", "users:update"})
+	}, rbac.RequirePermission("users:update"), func(c *gin.Context) {
+		scoped, _ = c.MustGet("permissions").(map[string]bool)
+		canAssign = rbac.HasPermission(c, "roles:assign")
+	})
+	r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+
+	if len(scoped) != 2 || !scoped["users:read"] || !scoped["users:update"] {
+		t.Fatalf("permissions = %v, want only the scoped ones", scoped)
+	}
+	if canAssign {
+		t.Fatal("HasPermission allowed a permission outside the api key scope")
+	}
+}
@@ -0,0 +1,317 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"sort"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/cel-go/cel"
+)
+
+// Policy berisi aturan CEL untuk satu aksi, misalnya "users.delete". Aturan
+// deny yang bernilai true langsung menolak request; selain itu request
+// diizinkan bila minimal satu aturan allow bernilai true.
+//
+// Variabel yang tersedia di ekspresi:
+//
+//   - claims:   sub, realm, user_id, session_id, email, role, org, permissions
+//   - request:  method, path, route, ip, params
+//   - resource: resource target dari ResourceLoader (misalnya user yang dihapus)
+//   - now:      waktu evaluasi (timestamp)
+//
+// Contoh file (POLICY_FILE):
+//
+//	{"policies": [
+//	  {"name": "users.read", "rules": [
+//	    {"id": "admin-any", "effect": "allow", "expr": "claims.role == 'admin'"},
+//	    {"id": "support-own-org", "effect": "allow",
+//	     "expr": "claims.role == 'support' && claims.org != '' && resource.organization == claims.org"}
+//	  ]},
+//	  {"name": "users.delete", "rules": [
+//	    {"id": "recent-users-only", "effect": "allow", "expr": "now - resource.created_at < duration('720h')"}
+//	  ]}
+//	]}
+type Policy struct {
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	Rules       []PolicyRule `json:"rules"`
+}
+
+type PolicyRule struct {
+	ID     string `json:"id"`
+	Effect string `json:"effect"` // "allow" atau "deny"
+	Expr   string `json:"expr"`
+}
+
+const (
+	EffectAllow = "allow"
+	EffectDeny  = "deny"
+)
+
+// ResourceLoader memuat resource target untuk route. (nil, nil) berarti
+// resource tidak ada; policy dilewati dan handler yang menjawab 404.
+type ResourceLoader func(c *gin.Context) (map[string]interface{}, error)
+
+// Decision adalah satu entry decision log
+type Decision struct {
+	Time       time.Time    `json:"time"`
+	Policy     string       `json:"policy"`
+	Allowed    bool         `json:"allowed"`
+	Reason     string       `json:"reason"`
+	Subject    string       `json:"subject"`
+	Method     string       `json:"method"`
+	Path       string       `json:"path"`
+	IP         string       `json:"ip"`
+	ResourceID interface{}  `json:"resource_id,omitempty"`
+	Rules      []RuleResult `json:"rules"`
+}
+
+type RuleResult struct {
+	ID      string `json:"id"`
+	Effect  string `json:"effect"`
+	Matched bool   `json:"matched"`
+	Error   string `json:"error,omitempty"`
+}
+
+// DecisionLogger menerima setiap keputusan policy yang menolak request (dan
+// keputusan allow bila diaktifkan)
+type DecisionLogger interface {
+	LogDecision(d Decision)
+}
+
+// JSONDecisionLogger menulis decision log sebagai satu baris JSON per
+// keputusan
+type JSONDecisionLogger struct {
+	Out       *os.File
+	LogAllows bool
+}
+
+func (l *JSONDecisionLogger) LogDecision(d Decision) {
+	if d.Allowed && !l.LogAllows {
+		return
+	}
+	data, err := json.Marshal(d)
+	if err != nil {
+		return
+	}
+	fmt.Fprintln(l.Out, string(data))
+}
+
+type compiledRule struct {
+	PolicyRule
+	program cel.Program
+}
+
+type PolicyEngine struct {
+	Logger DecisionLogger
+
+	policies map[string][]compiledRule
+}
+
+// NewPolicyEngine tanpa policy: semua route yang diikat ke policy diizinkan
+// (permission RBAC tetap berlaku)
+func NewPolicyEngine(logger DecisionLogger) *PolicyEngine {
+	return &PolicyEngine{
+		Logger:   logger,
+		policies: map[string][]compiledRule{},
+	}
+}
+
+func policyEnv() (*cel.Env, error) {
+	return cel.NewEnv(
+		cel.Variable("claims", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("request", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("resource", cel.MapType(cel.StringType, cel.DynType)),
+		cel.Variable("now", cel.TimestampType),
+	)
+}
+
+// LoadPolicyFile membaca dan meng-compile semua ekspresi. Ekspresi yang
+// tidak valid atau tidak menghasilkan bool membuat seluruh file ditolak.
+func (e *PolicyEngine) LoadPolicyFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file struct {
+		Policies []Policy `json:"policies"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	env, err := policyEnv()
+	if err != nil {
+		return err
+	}
+
+	policies := map[string][]compiledRule{}
+	for _, p := range file.Policies {
+		if p.Name == "" || len(p.Rules) == 0 {
+			return fmt.Errorf("policy %q must have a name and at least one rule", p.Name)
+		}
+		for i, r := range p.Rules {
+			if r.ID == "" {
+				r.ID = fmt.Sprintf("rule-%d", i+1)
+			}
+			if r.Effect != EffectAllow && r.Effect != EffectDeny {
+				return fmt.Errorf("policy %s rule %s: effect must be allow or deny", p.Name, r.ID)
+			}
+
+			ast, iss := env.Compile(r.Expr)
+			if iss.Err() != nil {
+				return fmt.Errorf("policy %s rule %s: %w", p.Name, r.ID, iss.Err())
+			}
+			if ast.OutputType() != cel.BoolType && ast.OutputType() != cel.DynType {
+				return fmt.Errorf("policy %s rule %s: expression must return bool", p.Name, r.ID)
+			}
+			program, err := env.Program(ast)
+			if err != nil {
+				return fmt.Errorf("policy %s rule %s: %w", p.Name, r.ID, err)
+			}
+			policies[p.Name] = append(policies[p.Name], compiledRule{PolicyRule: r, program: program})
+		}
+	}
+
+	e.policies = policies
+	return nil
+}
+
+// Enforce mengikat route ke policy, dipasang setelah AuthMiddleware dan
+// RequirePermission. Route yang policy-nya tidak ada di file tetap diizinkan.
+func (e *PolicyEngine) Enforce(policy string, loader ResourceLoader) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		rules, ok := e.policies[policy]
+		if !ok {
+			c.Next()
+			return
+		}
+
+		resource := map[string]interface{}{}
+		if loader != nil {
+			loaded, err := loader(c)
+			if err != nil {
+				c.JSON(http.StatusInternalServerError, gin.H{"error": "failed to load resource"})
+				c.Abort()
+				return
+			}
+			if loaded == nil {
+				c.Next()
+				return
+			}
+			resource = loaded
+		}
+
+		decision := e.evaluate(policy, rules, policyInput(c, resource))
+		decision.ResourceID = resource["id"]
+		if e.Logger != nil {
+			e.Logger.LogDecision(decision)
+		}
+
+		if !decision.Allowed {
+			c.JSON(http.StatusForbidden, gin.H{"error": "forbidden: " + decision.Reason})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// evaluate menjalankan semua aturan supaya decision log berisi hasil setiap
+// aturan. Error evaluasi selalu berpihak pada penolakan: aturan allow yang
+// error dianggap tidak cocok, aturan deny yang error dianggap cocok.
+func (e *PolicyEngine) evaluate(policy string, rules []compiledRule, input map[string]interface{}) Decision {
+	request, _ := input["request"].(map[string]interface{})
+	claims, _ := input["claims"].(map[string]interface{})
+
+	d := Decision{
+		Time:    time.Now(),
+		Policy:  policy,
+		Subject: fmt.Sprint(claims["sub"]),
+		Method:  fmt.Sprint(request["method"]),
+		Path:    fmt.Sprint(request["path"]),
+		IP:      fmt.Sprint(request["ip"]),
+	}
+
+	var denyBy, allowBy string
+	for _, r := range rules {
+		result := RuleResult{ID: r.ID, Effect: r.Effect}
+		out, _, err := r.program.Eval(input)
+		if err != nil {
+			result.Error = err.Error()
+		} else if matched, ok := out.Value().(bool); ok {
+			result.Matched = matched
+		} else {
+			result.Error = "expression did not return bool"
+		}
+		d.Rules = append(d.Rules, result)
+
+		// aturan deny yang gagal dievaluasi juga menolak request
+		if (result.Matched || result.Error != "") && r.Effect == EffectDeny && denyBy == "" {
+			denyBy = r.ID
+		}
+		if result.Matched && r.Effect == EffectAllow && allowBy == "" {
+			allowBy = r.ID
+		}
+	}
+
+	switch {
+	case denyBy != "":
+		d.Reason = fmt.Sprintf("policy %s: deny rule %s matched", policy, denyBy)
+	case allowBy != "":
+		d.Allowed = true
+		d.Reason = fmt.Sprintf("policy %s: allow rule %s matched", policy, allowBy)
+	default:
+		d.Reason = fmt.Sprintf("policy %s: no allow rule matched", policy)
+	}
+	return d
+}
+
+func policyInput(c *gin.Context, resource map[string]interface{}) map[string]interface{} {
+	claims := map[string]interface{}{}
+	if v, ok := c.Get("claims"); ok {
+		if ac, ok := v.(*AccessClaims); ok {
+			claims["sub"] = ac.Subject
+			claims["realm"] = ac.Realm
+			claims["user_id"] = ac.UserID
+			claims["session_id"] = ac.SessionID
+			claims["email"] = ac.Email
+			claims["role"] = ac.Role
+			claims["org"] = ac.Organization
+		}
+	}
+
+	permissions := []string{}
+	if v, ok := c.Get("permissions"); ok {
+		if set, ok := v.(map[string]bool); ok {
+			for p := range set {
+				permissions = append(permissions, p)
+			}
+		}
+	}
+	sort.Strings(permissions)
+	claims["permissions"] = permissions
+
+	params := map[string]string{}
+	for _, p := range c.Params {
+		params[p.Key] = p.Value
+	}
+
+	return map[string]interface{}{
+		"claims": claims,
+		"request": map[string]interface{}{
+			"method": c.Request.Method,
+			"path":   c.Request.URL.Path,
+			"route":  c.FullPath(),
+			"ip":     c.ClientIP(),
+			"params": params,
+		},
+		"resource": resource,
+		"now":      time.Now(),
+	}
+}
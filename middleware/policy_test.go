@@ -0,0 +1,140 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// recordingDecisionLogger menyimpan decision log untuk diperiksa test
+type recordingDecisionLogger struct {
+	decisions []Decision
+}
+
+func (l *recordingDecisionLogger) LogDecision(d Decision) {
+	l.decisions = append(l.decisions, d)
+}
+
+func writePolicyFile(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "policies.json")
+	if err := os.WriteFile(path, []byte(content), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+const testPolicies = `{"policies": [
+  {"name": "users.read", "rules": [
+    {"id": "admin-any", "effect": "allow", "expr": "claims.role == 'admin'"},
+    {"id": "support-own-org", "effect": "allow", "expr": "claims.role == 'support' && resource.organization == claims.org"},
+    {"id": "no-suspended", "effect": "deny", "expr": "resource.suspended == true"}
+  ]},
+  {"name": "users.delete", "rules": [
+    {"id": "broken-deny", "effect": "deny", "expr": "resource.missing_field == 'x'"},
+    {"id": "admin-any", "effect": "allow", "expr": "claims.role == 'admin'"}
+  ]},
+  {"name": "users.update", "rules": [
+    {"id": "broken-allow", "effect": "allow", "expr": "resource.missing_field == 'x'"}
+  ]}
+]}`
+
+func TestPolicyEnforce(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	logger := &recordingDecisionLogger{}
+	engine := NewPolicyEngine(logger)
+	if err := engine.LoadPolicyFile(writePolicyFile(t, testPolicies)); err != nil {
+		t.Fatalf("LoadPolicyFile: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		policy   string
+		role     string
+		org      string
+		resource map[string]interface{}
+		want     int
+		wantRule string
+	}{
+		{name: "allow rule matches", policy: "users.read", role: "admin", resource: map[string]interface{}{"organization": "acme", "suspended": false}, want: http.StatusOK, wantRule: "admin-any"},
+		{name: "attribute allow", policy: "users.read", role: "support", org: "acme", resource: map[string]interface{}{"organization": "acme", "suspended": false}, want: http.StatusOK, wantRule: "support-own-org"},
+		{name: "no allow rule matches", policy: "users.read", role: "support", org: "acme", resource: map[string]interface{}{"organization": "other", "suspended": false}, want: http.StatusForbidden},
+		{name: "deny overrides allow", policy: "users.read", role: "admin", resource: map[string]interface{}{"organization": "acme", "suspended": true}, want: http.StatusForbidden, wantRule: "no-suspended"},
+		{name: "deny rule error denies", policy: "users.delete", role: "admin", resource: map[string]interface{}{"id": 1}, want: http.StatusForbidden, wantRule: "broken-deny"},
+		{name: "allow rule error does not allow", policy: "users.update", role: "admin", resource: map[string]interface{}{"id": 1}, want: http.StatusForbidden},
+		{name: "missing resource is left to the handler", policy: "users.read", role: "support", want: http.StatusNotFound},
+		{name: "policy not configured", policy: "users.logout", role: "support", resource: map[string]interface{}{}, want: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			logger.decisions = nil
+
+			r := gin.New()
+			r.GET("/users/:id", func(c *gin.Context) {
+				c.Set("claims", &AccessClaims{Role: tt.role, Organization: tt.org})
+			}, engine.Enforce(tt.policy, func(c *gin.Context) (map[string]interface{}, error) {
+				return tt.resource, nil
+			}), func(c *gin.Context) {
+				if tt.resource == nil {
+					c.Status(http.StatusNotFound)
+					return
+				}
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/1", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d (%s)", w.Code, tt.want, w.Body.String())
+			}
+
+			if tt.wantRule == "" {
+				return
+			}
+			if len(logger.decisions) != 1 {
+				t.Fatalf("%d decisions logged, want 1", len(logger.decisions))
+			}
+			d := logger.decisions[0]
+			if d.Allowed != (tt.want == http.StatusOK) || !containsRule(d, tt.wantRule) {
+				t.Fatalf("decision = %+v, want rule %s", d, tt.wantRule)
+			}
+		})
+	}
+}
+
+func containsRule(d Decision, id string) bool {
+	for _, r := range d.Rules {
+		if r.ID == id && (r.Matched || r.Error != "") {
+			return true
+		}
+	}
+	return false
+}
+
+func TestLoadPolicyFileRejectsInvalidPolicies(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "syntax error", content: `{"policies": [{"name": "p", "rules": [{"effect": "allow", "expr": "claims.role =="}]}]}`},
+		{name: "non-bool expression", content: `{"policies": [{"name": "p", "rules": [{"effect": "allow", "expr": "claims.user_id + 1"}]}]}`},
+		{name: "unknown effect", content: `{"policies": [{"name": "p", "rules": [{"effect": "maybe", "expr": "true"}]}]}`},
+		{name: "policy without rules", content: `{"policies": [{"name": "p", "rules": []}]}`},
+		{name: "unknown variable", content: `{"policies": [{"name": "p", "rules": [{"effect": "allow", "expr": "user.role == 'admin'"}]}]}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			engine := NewPolicyEngine(nil)
+			if err := engine.LoadPolicyFile(writePolicyFile(t, tt.content)); err == nil {
+				t.Fatal("LoadPolicyFile accepted an invalid policy")
+			}
+		})
+	}
+}
@@ -0,0 +1,219 @@
+package middleware
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"math"
+	"net/http"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// Jenis key rate limit. IP dicek di middleware sebelum ID token diverifikasi,
+// Google UID dan refresh token family baru dicek setelah token terbukti valid
+// supaya orang lain tidak bisa menghabiskan bucket milik akun tertentu.
+const (
+	RateKeyIP            = "ip"
+	RateKeyGoogleUID     = "google_uid"
+	RateKeyRefreshFamily = "refresh_family"
+)
+
+// RateLimit adalah token bucket dengan kapasitas Burst yang terisi penuh
+// kembali dalam Per. Burst 0 berarti tidak dibatasi.
+type RateLimit struct {
+	Burst int
+	Per   time.Duration
+}
+
+// Take mengambil satu token dari bucket yang berisi tokens dan terakhir
+// diisi elapsed yang lalu. Mengembalikan sisa token, dan bila bucket kosong,
+// waktu tunggu sampai token berikutnya tersedia.
+func (l RateLimit) Take(tokens float64, elapsed time.Duration) (float64, bool, time.Duration) {
+	rate := float64(l.Burst) / l.Per.Seconds()
+	tokens = math.Min(float64(l.Burst), tokens+elapsed.Seconds()*rate)
+	if tokens < 1 {
+		wait := time.Duration((1 - tokens) / rate * float64(time.Second))
+		return tokens, false, wait
+	}
+	return tokens - 1, true, 0
+}
+
+// RateLimitStore menyimpan isi bucket. Store bersama (misalnya MySQL)
+// dipakai bila server berjalan di beberapa instance.
+type RateLimitStore interface {
+	Take(key string, limit RateLimit) (bool, time.Duration, error)
+}
+
+// RateLimitedError dikembalikan bila bucket habis
+type RateLimitedError struct {
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitedError) Error() string {
+	return "too many requests, try again later"
+}
+
+// RateLimiter memegang limit per route ("admin.login", "user.refresh", ...)
+// dan per jenis key
+type RateLimiter struct {
+	Store RateLimitStore
+	Rules map[string]map[string]RateLimit
+	// Fallback dipakai bila Store error (misalnya MySQL mati), supaya limit
+	// tetap berlaku per instance
+	Fallback RateLimitStore
+}
+
+func NewRateLimiter(store RateLimitStore, rules map[string]map[string]RateLimit) *RateLimiter {
+	return &RateLimiter{
+		Store:    store,
+		Rules:    rules,
+		Fallback: NewMemoryRateLimitStore(),
+	}
+}
+
+// Check mengambil satu token dari bucket route/jenis key/value. Route atau
+// jenis key tanpa rule selalu lolos. Bila store error, bucket diambil dari
+// Fallback di memory; bila itu juga gagal, request ditolak.
+func (l *RateLimiter) Check(route, kind, value string) error {
+	limit, ok := l.Rules[route][kind]
+	if !ok || limit.Burst <= 0 || value == "" {
+		return nil
+	}
+
+	key := route + ":" + kind + ":" + value
+	allowed, retryAfter, err := l.Store.Take(key, limit)
+	if err != nil {
+		log.Println("rate limit store failed, using in-memory fallback:", err)
+		if l.Fallback == nil {
+			return &RateLimitedError{RetryAfter: time.Second}
+		}
+		allowed, retryAfter, err = l.Fallback.Take(key, limit)
+		if err != nil {
+			return &RateLimitedError{RetryAfter: time.Second}
+		}
+	}
+	if !allowed {
+		return &RateLimitedError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// Middleware membatasi route per IP client (c.ClientIP, jadi pastikan
+// trusted proxy sudah diatur)
+func (l *RateLimiter) Middleware(route string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if err := l.Check(route, RateKeyIP, c.ClientIP()); err != nil {
+			RespondRateLimited(c, err.(*RateLimitedError))
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+// RespondRateLimited mengirim 429 dengan header Retry-After (detik)
+func RespondRateLimited(c *gin.Context, err *RateLimitedError) {
+	seconds := int(math.Ceil(err.RetryAfter.Seconds()))
+	if seconds < 1 {
+		seconds = 1
+	}
+	c.Header("Retry-After", strconv.Itoa(seconds))
+	c.JSON(http.StatusTooManyRequests, gin.H{
+		"error":       err.Error(),
+		"retry_after": seconds,
+	})
+}
+
+// LoadRateLimitFile menimpa rule bawaan dengan isi file JSON, misalnya:
+//
+//	{"admin.login": {"ip": {"burst": 10, "per": "1m"}, "google_uid": {"burst": 0}}}
+func (l *RateLimiter) LoadRateLimitFile(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return err
+	}
+
+	var file map[string]map[string]struct {
+		Burst int    `json:"burst"`
+		Per   string `json:"per"`
+	}
+	if err := json.Unmarshal(data, &file); err != nil {
+		return err
+	}
+
+	for route, kinds := range file {
+		for kind, r := range kinds {
+			if kind != RateKeyIP && kind != RateKeyGoogleUID && kind != RateKeyRefreshFamily {
+				return fmt.Errorf("rate limit %s: unknown key %q", route, kind)
+			}
+			limit := RateLimit{Burst: r.Burst}
+			if r.Burst > 0 {
+				limit.Per, err = time.ParseDuration(r.Per)
+				if err != nil || limit.Per <= 0 {
+					return fmt.Errorf("rate limit %s.%s: per must be a positive duration", route, kind)
+				}
+			}
+			if l.Rules[route] == nil {
+				l.Rules[route] = map[string]RateLimit{}
+			}
+			l.Rules[route][kind] = limit
+		}
+	}
+	return nil
+}
+
+// --------------------------- MEMORY STORE ---------------------------
+
+type tokenBucket struct {
+	tokens  float64
+	updated time.Time
+	full    time.Time
+}
+
+// MemoryRateLimitStore hanya cocok untuk satu instance server.
+type MemoryRateLimitStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+func NewMemoryRateLimitStore() *MemoryRateLimitStore {
+	return &MemoryRateLimitStore{
+		buckets:   make(map[string]*tokenBucket),
+		lastSweep: time.Now(),
+	}
+}
+
+func (m *MemoryRateLimitStore) Take(key string, limit RateLimit) (bool, time.Duration, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+
+	// bucket yang sudah penuh lagi sama dengan bucket baru, jadi dibuang
+	if now.Sub(m.lastSweep) > time.Minute {
+		for k, b := range m.buckets {
+			if !b.full.After(now) {
+				delete(m.buckets, k)
+			}
+		}
+		m.lastSweep = now
+	}
+
+	b, ok := m.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: float64(limit.Burst), updated: now}
+		m.buckets[key] = b
+	}
+
+	tokens, allowed, retryAfter := limit.Take(b.tokens, now.Sub(b.updated))
+	b.tokens = tokens
+	b.updated = now
+	b.full = now.Add(time.Duration((float64(limit.Burst) - tokens) / float64(limit.Burst) * float64(limit.Per)))
+	return allowed, retryAfter, nil
+}
@@ -0,0 +1,127 @@
+package middleware
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRateLimitTake(t *testing.T) {
+	limit := RateLimit{Burst: 10, Per: time.Minute} // 1 token per 6 detik
+
+	tests := []struct {
+		name          string
+		tokens        float64
+		elapsed       time.Duration
+		wantAllowed   bool
+		wantTokens    float64
+		wantRetryFrom time.Duration
+		wantRetryTo   time.Duration
+	}{
+		{name: "full bucket", tokens: 10, wantAllowed: true, wantTokens: 9},
+		{name: "refill is capped at burst", tokens: 10, elapsed: time.Hour, wantAllowed: true, wantTokens: 9},
+		{name: "last token", tokens: 1, wantAllowed: true, wantTokens: 0},
+		{name: "empty bucket", tokens: 0, wantTokens: 0, wantRetryFrom: 6 * time.Second, wantRetryTo: 6 * time.Second},
+		{name: "partially refilled", tokens: 0, elapsed: 3 * time.Second, wantTokens: 0.5, wantRetryFrom: 3 * time.Second, wantRetryTo: 3 * time.Second},
+		{name: "refilled one token", tokens: 0, elapsed: 6 * time.Second, wantAllowed: true, wantTokens: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tokens, allowed, retryAfter := limit.Take(tt.tokens, tt.elapsed)
+			if allowed != tt.wantAllowed {
+				t.Fatalf("allowed = %v, want %v", allowed, tt.wantAllowed)
+			}
+			if diff := tokens - tt.wantTokens; diff > 1e-9 || diff < -1e-9 {
+				t.Fatalf("tokens = %v, want %v", tokens, tt.wantTokens)
+			}
+			if retryAfter < tt.wantRetryFrom-time.Millisecond || retryAfter > tt.wantRetryTo+time.Millisecond {
+				t.Fatalf("retryAfter = %s, want %s", retryAfter, tt.wantRetryFrom)
+			}
+		})
+	}
+}
+
+// failingRateLimitStore mensimulasikan store bersama yang mati
+type failingRateLimitStore struct{}
+
+func (failingRateLimitStore) Take(key string, limit RateLimit) (bool, time.Duration, error) {
+	return false, 0, errors.New("store unavailable")
+}
+
+func TestRateLimiterCheck(t *testing.T) {
+	rules := map[string]map[string]RateLimit{
+		"user.login": {RateKeyIP: {Burst: 2, Per: time.Minute}},
+	}
+
+	tests := []struct {
+		name     string
+		store    RateLimitStore
+		fallback RateLimitStore
+		route    string
+		value    string
+		allowed  int // request yang lolos dari 3 request
+	}{
+		{name: "memory store", store: NewMemoryRateLimitStore(), fallback: NewMemoryRateLimitStore(), route: "user.login", value: "10.0.0.1", allowed: 2},
+		{name: "store error uses fallback", store: failingRateLimitStore{}, fallback: NewMemoryRateLimitStore(), route: "user.login", value: "10.0.0.1", allowed: 2},
+		{name: "store and fallback fail closed", store: failingRateLimitStore{}, fallback: failingRateLimitStore{}, route: "user.login", value: "10.0.0.1", allowed: 0},
+		{name: "store error without fallback fails closed", store: failingRateLimitStore{}, route: "user.login", value: "10.0.0.1", allowed: 0},
+		{name: "route without rule", store: failingRateLimitStore{}, route: "user.me", value: "10.0.0.1", allowed: 3},
+		{name: "empty key", store: failingRateLimitStore{}, route: "user.login", allowed: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l := &RateLimiter{Store: tt.store, Rules: rules, Fallback: tt.fallback}
+
+			allowed := 0
+			for i := 0; i < 3; i++ {
+				err := l.Check(tt.route, RateKeyIP, tt.value)
+				if err == nil {
+					allowed++
+					continue
+				}
+				var limited *RateLimitedError
+				if !errors.As(err, &limited) || limited.RetryAfter <= 0 {
+					t.Fatalf("Check error = %v, want RateLimitedError with RetryAfter", err)
+				}
+			}
+			if allowed != tt.allowed {
+				t.Fatalf("%d requests allowed, want %d", allowed, tt.allowed)
+			}
+		})
+	}
+}
+
+func TestRateLimiterMiddlewareSetsRetryAfter(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	l := NewRateLimiter(NewMemoryRateLimitStore(), map[string]map[string]RateLimit{
+		"user.login": {RateKeyIP: {Burst: 1, Per: 30 * time.Second}},
+	})
+	r := gin.New()
+	r.POST("/login", l.Middleware("user.login"), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	serve := func() *httptest.ResponseRecorder {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/login", nil))
+		return w
+	}
+
+	if w := serve(); w.Code != http.StatusOK {
+		t.Fatalf("first request status = %d, want %d", w.Code, http.StatusOK)
+	}
+	w := serve()
+	if w.Code != http.StatusTooManyRequests {
+		t.Fatalf("second request status = %d, want %d", w.Code, http.StatusTooManyRequests)
+	}
+	if got := w.Header().Get("Retry-After"); got != "30" {
+		t.Fatalf("Retry-After = %q, want %q", got, "30")
+	}
+}
@@ -0,0 +1,51 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AnyRealm untuk route yang sama di semua realm (misalnya /api/auth/me).
+// Realm dibaca dari token (claim realm) atau pemilik API key, lalu request
+// divalidasi penuh oleh AuthMiddleware realm tersebut.
+func AnyRealm(managers ...*JWTManager) gin.HandlerFunc {
+	byRealm := map[string]*JWTManager{}
+	for _, m := range managers {
+		byRealm[m.Realm] = m
+	}
+
+	return func(c *gin.Context) {
+		if key := c.GetHeader(APIKeyHeader); key != "" {
+			// semua realm memakai APIKeyAuthenticator yang sama
+			principal, ok := lookupAPIKey(c, managers[0].APIKeys, key)
+			if !ok {
+				return
+			}
+			if _, ok := byRealm[principal.Realm]; !ok {
+				c.JSON(http.StatusUnauthorized, gin.H{"error": "token realm mismatch"})
+				c.Abort()
+				return
+			}
+			setAPIKeyContext(c, principal)
+			c.Next()
+			return
+		}
+
+		// claim realm hanya dipakai untuk memilih JWTManager; tanda tangan,
+		// audience dan exp tetap diperiksa AuthMiddleware realm itu
+		tokenString := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		claims := &AccessClaims{}
+		if _, _, err := jwt.NewParser().ParseUnverified(tokenString, claims); err == nil {
+			if m, ok := byRealm[claims.Realm]; ok {
+				m.AuthMiddleware()(c)
+				return
+			}
+		}
+
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "missing or invalid token"})
+		c.Abort()
+	}
+}
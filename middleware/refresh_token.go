@@ -0,0 +1,62 @@
+package middleware
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// Refresh token berupa nilai opaque "<realm>.<session_id>.<random>". Database hanya
+// menyimpan HMAC-SHA256 dari token (kunci REFRESH_SECRET), jadi isi tabel
+// session tidak bisa dipakai untuk replay.
+
+const refreshTokenBytes = 32
+
+// Generate refresh token opaque untuk session
+func (j *JWTManager) GenerateRefreshToken(sessionID int) (string, error) {
+	b := make([]byte, refreshTokenBytes)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%s.%d.%s", j.Realm, sessionID, base64.RawURLEncoding.EncodeToString(b)), nil
+}
+
+// Hash refresh token dengan HMAC-SHA256, hasil dalam hex
+func (j *JWTManager) HashRefreshToken(token string) string {
+	mac := hmac.New(sha256.New, j.RefreshSecret)
+	mac.Write([]byte(token))
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+// Ambil session id dari refresh token opaque. Token dari realm lain ditolak;
+// token "<session_id>.<random>" (sebelum ada realm) tetap diterima.
+func (j *JWTManager) ParseRefreshToken(token string) (int, bool) {
+	parts := strings.Split(token, ".")
+	switch {
+	case len(parts) == 3 && parts[0] == j.Realm:
+		parts = parts[1:]
+	case len(parts) != 2:
+		return 0, false
+	}
+
+	sid, secret := parts[0], parts[1]
+	if secret == "" {
+		return 0, false
+	}
+	sessionID, err := strconv.Atoi(sid)
+	if err != nil || sessionID <= 0 {
+		return 0, false
+	}
+	return sessionID, true
+}
+
+// Bandingkan dua hash dalam constant time
+func EqualHash(a, b string) bool {
+	return subtle.ConstantTimeCompare([]byte(a), []byte(b)) == 1
+}
@@ -0,0 +1,71 @@
+package middleware
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// RevocationStore menyimpan jti access token yang sudah dicabut (denylist).
+// Entry cukup disimpan sampai waktu expired token, setelah itu token sudah
+// ditolak oleh validasi exp.
+type RevocationStore interface {
+	Revoke(jti string, expiresAt time.Time) error
+	IsRevoked(jti string) (bool, error)
+}
+
+// Generate id unik untuk claim jti
+func NewTokenID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// --------------------------- MEMORY STORE ---------------------------
+
+// MemoryRevocationStore hanya cocok untuk satu instance server.
+type MemoryRevocationStore struct {
+	mu      sync.Mutex
+	entries map[string]time.Time
+}
+
+func NewMemoryRevocationStore() *MemoryRevocationStore {
+	return &MemoryRevocationStore{
+		entries: make(map[string]time.Time),
+	}
+}
+
+func (m *MemoryRevocationStore) Revoke(jti string, expiresAt time.Time) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	for id, exp := range m.entries {
+		if !exp.After(now) {
+			delete(m.entries, id)
+		}
+	}
+
+	if expiresAt.After(now) {
+		m.entries[jti] = expiresAt
+	}
+	return nil
+}
+
+func (m *MemoryRevocationStore) IsRevoked(jti string) (bool, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	exp, ok := m.entries[jti]
+	if !ok {
+		return false, nil
+	}
+	if !exp.After(time.Now()) {
+		delete(m.entries, jti)
+		return false, nil
+	}
+	return true, nil
+}
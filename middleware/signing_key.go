@@ -0,0 +1,160 @@
+package middleware
+
+import (
+	"crypto/ecdsa"
+	"crypto/ed25519"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"os"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// SigningKey adalah key untuk menandatangani access token. HS256 memakai
+// shared secret, sedangkan RS256/ES256/EdDSA memakai private key dari file
+// PEM sehingga service lain cukup memverifikasi dengan public key dari JWKS.
+type SigningKey struct {
+	KID    string
+	Method jwt.SigningMethod
+
+	signKey   interface{}
+	verifyKey interface{}
+}
+
+func NewHMACKey(kid string, secret []byte) *SigningKey {
+	return &SigningKey{
+		KID:       kid,
+		Method:    jwt.SigningMethodHS256,
+		signKey:   secret,
+		verifyKey: secret,
+	}
+}
+
+// Load private key PEM untuk algoritma RS256, ES256 atau EdDSA
+func LoadSigningKeyPEM(path, alg string) (*SigningKey, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	key := &SigningKey{}
+	switch alg {
+	case "RS256":
+		priv, err := jwt.ParseRSAPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodRS256, priv, &priv.PublicKey
+
+	case "ES256":
+		priv, err := jwt.ParseECPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		if priv.Curve != elliptic.P256() {
+			return nil, errors.New("ES256 requires a P-256 key")
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodES256, priv, &priv.PublicKey
+
+	case "EdDSA":
+		priv, err := jwt.ParseEdPrivateKeyFromPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		edPriv, ok := priv.(ed25519.PrivateKey)
+		if !ok {
+			return nil, errors.New("EdDSA requires an Ed25519 key")
+		}
+		key.Method, key.signKey, key.verifyKey = jwt.SigningMethodEdDSA, edPriv, edPriv.Public()
+
+	default:
+		return nil, fmt.Errorf("unsupported signing algorithm %q", alg)
+	}
+
+	kid, err := thumbprint(key.verifyKey)
+	if err != nil {
+		return nil, err
+	}
+	key.KID = kid
+	return key, nil
+}
+
+// Asymmetric true bila key punya public key yang boleh dipublikasikan
+func (k *SigningKey) Asymmetric() bool {
+	_, isSecret := k.verifyKey.([]byte)
+	return !isSecret
+}
+
+// JWK public key dalam format RFC 7517, hanya untuk key asymmetric
+func (k *SigningKey) JWK() (map[string]string, bool) {
+	jwk, ok := publicJWK(k.verifyKey)
+	if !ok {
+		return nil, false
+	}
+	jwk["kid"] = k.KID
+	jwk["alg"] = k.Method.Alg()
+	jwk["use"] = "sig"
+	return jwk, true
+}
+
+func publicJWK(pub interface{}) (map[string]string, bool) {
+	enc := base64.RawURLEncoding.EncodeToString
+
+	switch p := pub.(type) {
+	case *rsa.PublicKey:
+		return map[string]string{
+			"kty": "RSA",
+			"n":   enc(p.N.Bytes()),
+			"e":   enc(big.NewInt(int64(p.E)).Bytes()),
+		}, true
+
+	case *ecdsa.PublicKey:
+		size := (p.Curve.Params().BitSize + 7) / 8
+		return map[string]string{
+			"kty": "EC",
+			"crv": p.Curve.Params().Name,
+			"x":   enc(p.X.FillBytes(make([]byte, size))),
+			"y":   enc(p.Y.FillBytes(make([]byte, size))),
+		}, true
+
+	case ed25519.PublicKey:
+		return map[string]string{
+			"kty": "OKP",
+			"crv": "Ed25519",
+			"x":   enc(p),
+		}, true
+	}
+	return nil, false
+}
+
+// kid diambil dari JWK thumbprint (RFC 7638), jadi stabil untuk key yang sama
+func thumbprint(pub interface{}) (string, error) {
+	jwk, ok := publicJWK(pub)
+	if !ok {
+		return "", errors.New("unsupported public key")
+	}
+
+	// json.Marshal mengurutkan key map, sesuai urutan leksikografis RFC 7638
+	required := map[string]string{"kty": jwk["kty"]}
+	switch jwk["kty"] {
+	case "RSA":
+		required["n"], required["e"] = jwk["n"], jwk["e"]
+	case "EC":
+		required["crv"], required["x"], required["y"] = jwk["crv"], jwk["x"], jwk["y"]
+	case "OKP":
+		required["crv"], required["x"] = jwk["crv"], jwk["x"]
+	}
+
+	data, err := json.Marshal(required)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
@@ -0,0 +1,84 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AMRHardwareKey adalah nilai amr (RFC 8176) untuk assertion WebAuthn:
+// proof-of-possession key yang disimpan authenticator
+const AMRHardwareKey = "hwk"
+
+// DefaultStepUpMaxAge adalah batas umur step-up dan masa berlaku elevated token
+const DefaultStepUpMaxAge = 5 * time.Minute
+
+// GenerateStepUpToken membuat elevated token: salinan access token dengan jti
+// baru, amr dan auth_time sekarang. Masa berlakunya ttl, dan token ini tidak
+// bisa di-refresh. jti dikembalikan supaya bisa dicatat di session dan
+// dicabut saat logout.
+func (j *JWTManager) GenerateStepUpToken(base *AccessClaims, amr []string, ttl time.Duration) (string, string, error) {
+	jti, err := NewTokenID()
+	if err != nil {
+		return "", "", err
+	}
+
+	now := time.Now()
+	claims := AccessClaims{
+		Realm:        j.Realm,
+		UserID:       base.UserID,
+		SessionID:    base.SessionID,
+		Email:        base.Email,
+		Role:         base.Role,
+		Organization: base.Organization,
+		AMR:          amr,
+		AuthTime:     now.Unix(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    j.Issuer,
+			Subject:   j.Subject(base.UserID),
+			Audience:  jwt.ClaimStrings{j.Audience},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(ttl)),
+		},
+	}
+	token, err := j.Sign(claims)
+	return token, jti, err
+}
+
+// RequireRecentStepUp dipasang setelah AuthMiddleware pada route sensitif.
+// Request harus memakai elevated token dengan amr "hwk" dan auth_time tidak
+// lebih tua dari maxAge. Response 401 mengikuti RFC 9470 supaya client tahu
+// harus melakukan step-up dulu.
+func RequireRecentStepUp(maxAge time.Duration) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		v, _ := c.Get("claims")
+		claims, _ := v.(*AccessClaims)
+
+		if c.GetString("auth_method") != AuthMethodToken || claims == nil || !hasAMR(claims, AMRHardwareKey) ||
+			time.Since(time.Unix(claims.AuthTime, 0)) > maxAge {
+			c.Header("WWW-Authenticate", fmt.Sprintf(`Bearer error="insufficient_user_authentication", error_description="passkey step-up required", max_age=%d`, int(maxAge.Seconds())))
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"error":            "step-up required: verify with a passkey first",
+				"step_up_required": true,
+				"max_age":          int(maxAge.Seconds()),
+			})
+			c.Abort()
+			return
+		}
+		c.Next()
+	}
+}
+
+func hasAMR(claims *AccessClaims, method string) bool {
+	for _, m := range claims.AMR {
+		if m == method {
+			return true
+		}
+	}
+	return false
+}
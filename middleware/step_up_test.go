@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestRequireRecentStepUp(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		authMethod string
+		amr        []string
+		authTime   time.Time
+		want       int
+	}{
+		{name: "fresh passkey step-up", authMethod: AuthMethodToken, amr: []string{AMRHardwareKey}, authTime: time.Now(), want: http.StatusOK},
+		{name: "step-up near max age", authMethod: AuthMethodToken, amr: []string{AMRHardwareKey}, authTime: time.Now().Add(-4 * time.Minute), want: http.StatusOK},
+		{name: "step-up older than max age", authMethod: AuthMethodToken, amr: []string{AMRHardwareKey}, authTime: time.Now().Add(-6 * time.Minute), want: http.StatusUnauthorized},
+		{name: "plain access token", authMethod: AuthMethodToken, authTime: time.Now(), want: http.StatusUnauthorized},
+		{name: "other amr", authMethod: AuthMethodToken, amr: []string{"otp"}, authTime: time.Now(), want: http.StatusUnauthorized},
+		{name: "api key", authMethod: AuthMethodAPIKey, amr: []string{AMRHardwareKey}, authTime: time.Now(), want: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.DELETE("/users/1", func(c *gin.Context) {
+				c.Set("auth_method", tt.authMethod)
+				c.Set("claims", &AccessClaims{AMR: tt.amr, AuthTime: tt.authTime.Unix()})
+			}, RequireRecentStepUp(DefaultStepUpMaxAge), func(c *gin.Context) {
+				c.Status(http.StatusOK)
+			})
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodDelete, "/users/1", nil))
+			if w.Code != tt.want {
+				t.Fatalf("status = %d, want %d", w.Code, tt.want)
+			}
+			if tt.want == http.StatusUnauthorized && w.Header().Get("WWW-Authenticate") == "" {
+				t.Fatal("missing WWW-Authenticate challenge")
+			}
+		})
+	}
+}
+
+func TestGenerateStepUpTokenCarriesFreshAuthTime(t *testing.T) {
+	keys := NewSingleKeyring(NewHMACKey("test", []byte("test-access-secret")))
+	manager := NewJWTManager(RealmAdmin, "login-google-admin", keys, "test-refresh-secret", nil)
+
+	base := &AccessClaims{UserID: 1, SessionID: 3, Email: "admin@example.com", Role: "admin"}
+	token, jti, err := manager.GenerateStepUpToken(base, []string{AMRHardwareKey}, DefaultStepUpMaxAge)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	claims, err := manager.ParseAccessToken(token)
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if claims.ID != jti || claims.SessionID != 3 || !hasAMR(claims, AMRHardwareKey) {
+		t.Fatalf("claims = %+v, want jti %s, session 3 and amr hwk", claims, jti)
+	}
+	if age := time.Since(time.Unix(claims.AuthTime, 0)); age > time.Minute {
+		t.Fatalf("auth_time is %s old", age)
+	}
+	if ttl := time.Until(claims.ExpiresAt.Time); ttl > DefaultStepUpMaxAge {
+		t.Fatalf("elevated token lives %s, want at most %s", ttl, DefaultStepUpMaxAge)
+	}
+}
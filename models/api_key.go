@@ -0,0 +1,38 @@
+package models
+
+import "time"
+
+// Pemilik API key
+const (
+	OwnerAccount        = "account" // admin atau user (sesuai realm)
+	OwnerServiceAccount = "service_account"
+)
+
+// APIKey untuk client tanpa login Google. Scopes membatasi permission role
+// pemiliknya.
+type APIKey struct {
+	ID         int
+	Prefix     string
+	KeyHash    string `json:"-"`
+	Name       string
+	Realm      string
+	OwnerType  string
+	OwnerID    int
+	Scopes     []string
+	ExpiresAt  time.Time
+	LastUsedAt *time.Time
+	CreatedAt  time.Time
+	RevokedAt  *time.Time
+}
+
+type ServiceAccount struct {
+	ID           int
+	Realm        string
+	Name         string
+	Description  string
+	Role         string
+	Organization string
+	CreatedBy    int
+	CreatedAt    time.Time
+	DisabledAt   *time.Time
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// Impersonation adalah satu token "act as user" yang dibuat admin
+type Impersonation struct {
+	ID        int
+	AdminID   int
+	UserID    int
+	Reason    string
+	TokenID   string `json:"-"`
+	IPAddress string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	EndedAt   *time.Time
+}
+
+// ImpersonationRequest adalah satu request yang memakai token impersonasi
+type ImpersonationRequest struct {
+	ID              int
+	ImpersonationID int
+	Method          string
+	Path            string
+	Status          int
+	IPAddress       string
+	CreatedAt       time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// AdminInvite adalah undangan sekali pakai untuk registrasi admin. Undangan
+// terikat ke Email atau ke HostedDomain (claim "hd" Google Workspace).
+type AdminInvite struct {
+	ID           int
+	CodeHash     string `json:"-"`
+	Email        string
+	HostedDomain string
+	CreatedBy    int
+	CreatedAt    time.Time
+	ExpiresAt    time.Time
+	UsedAt       *time.Time
+	UsedByUID    string
+	RevokedAt    *time.Time
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// TOTPFactor adalah authenticator TOTP milik admin. Secret masih terenkripsi.
+type TOTPFactor struct {
+	AdminID        int
+	Secret         string `json:"-"`
+	EnabledAt      *time.Time
+	LastUsedStep   int64 `json:"-"`
+	FailedAttempts int
+	LockedUntil    *time.Time
+	CreatedAt      time.Time
+}
+
+// Enabled true setelah enrollment dikonfirmasi
+func (f *TOTPFactor) Enabled() bool {
+	return f != nil && f.EnabledAt != nil
+}
@@ -0,0 +1,37 @@
+package models
+
+import "time"
+
+// OAuthClient adalah aplikasi yang memakai service ini sebagai OIDC issuer.
+// Client tanpa secret (public) wajib memakai PKCE.
+type OAuthClient struct {
+	ClientID         string
+	ClientSecretHash string `json:"-"`
+	Name             string
+	Realm            string
+	RedirectURIs     []string
+	Scopes           []string
+	CreatedBy        int
+	CreatedAt        time.Time
+	DisabledAt       *time.Time
+}
+
+// Confidential true bila client punya secret
+func (c *OAuthClient) Confidential() bool {
+	return c.ClientSecretHash != ""
+}
+
+type AuthorizationCode struct {
+	CodeHash      string
+	ClientID      string
+	Realm         string
+	UserID        int
+	RedirectURI   string
+	Scope         string
+	Nonce         string
+	CodeChallenge string
+	AuthTime      time.Time
+	CreatedAt     time.Time
+	ExpiresAt     time.Time
+	UsedAt        *time.Time
+}
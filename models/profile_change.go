@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// ProfileChange adalah satu perubahan field profil. Source menunjukkan asal
+// perubahan: "google" (sinkronisasi saat login), "self" atau "admin".
+type ProfileChange struct {
+	ID            int
+	AdminOrUserID int
+	Field         string
+	OldValue      string
+	NewValue      string
+	Source        string
+	CreatedAt     time.Time
+}
@@ -0,0 +1,18 @@
+package models
+
+import "time"
+
+// Role berlaku untuk satu realm ("admin" atau "user")
+type Role struct {
+	ID          int
+	Realm       string
+	Name        string
+	Description string
+	Permissions []string
+	CreatedAt   time.Time
+}
+
+type Permission struct {
+	Name        string
+	Description string
+}
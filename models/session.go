@@ -0,0 +1,31 @@
+package models
+
+import "time"
+
+// Session mewakili satu login pada satu device. Setiap login membuat session
+// baru, jadi login di HP tidak lagi menimpa refresh token di laptop.
+//
+// Session juga menjadi "family" refresh token: setiap rotasi menaikkan
+// Generation, dan token dari generation lama yang dipakai lagi membuat
+// seluruh family di-revoke.
+type Session struct {
+	ID            int
+	AdminOrUserID int
+	// HMAC refresh token yang berlaku sekarang
+	RefreshTokenHash string
+	// Refresh token format lama (JWT terenkripsi), kosong setelah dirotasi
+	LegacyRefreshToken string
+	Generation         int
+	RevokedAt          *time.Time
+	// jti access token terakhir yang dibuat untuk session ini
+	AccessJTI       string
+	AccessExpiresAt *time.Time
+	// jti elevated token (step-up) terakhir untuk session ini
+	StepUpJTI       string
+	StepUpExpiresAt *time.Time
+	DeviceInfo      string
+	IPAddress       string
+	CreatedAt       time.Time
+	LastUsedAt      time.Time
+	ExpiresAt       time.Time
+}
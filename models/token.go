@@ -1,10 +0,0 @@
-package models
-
-import "time"
-
-type RefreshToken struct {
-	ID           int
-	AdminOrUserID      int
-	RefreshToken string
-	ExpiresAt    time.Time
-}
\ No newline at end of file
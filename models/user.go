@@ -1,5 +1,7 @@
 package models
 
+import "time"
+
 type BaseUser struct {
 	ID             int
 	GoogleUID      string
@@ -9,4 +11,9 @@ type BaseUser struct {
 	ProfilePicture string 
 	Role           string
 	IsLoggedIn     int
+	Organization   string
+	CreatedAt      time.Time
+	// Nama dan email terakhir dari ID token Google (sinkronisasi profil)
+	GoogleName  string `json:"-"`
+	GoogleEmail string `json:"-"`
 }
@@ -0,0 +1,15 @@
+package models
+
+import "time"
+
+// WebAuthnCredential adalah passkey admin. Credential berisi JSON
+// webauthn.Credential dan tidak ikut dikirim ke client.
+type WebAuthnCredential struct {
+	ID           int
+	AdminID      int
+	CredentialID string
+	Name         string
+	Credential   string `json:"-"`
+	CreatedAt    time.Time
+	LastUsedAt   *time.Time
+}
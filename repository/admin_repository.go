@@ -10,7 +10,7 @@ import (
 // --------------------------- GET ALL ADMINS -----------------------------------
 
 func (r *AdminRepository) GetAll() ([]models.BaseUser, error) {
-	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, profile_picture FROM admins`
+	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, profile_picture, COALESCE(organization, ''), created_at FROM admins`
 	rows, err := r.DB.Query(sqlQuery)
 	if err != nil {
 		return nil, err
@@ -21,7 +21,7 @@ func (r *AdminRepository) GetAll() ([]models.BaseUser, error) {
 
 	for rows.Next() {
 		u := models.BaseUser{}
-		err := rows.Scan(&u.ID, &u.GoogleUID, &u.Name, &u.Email, &u.GooglePicture, &u.Role, &u.ProfilePicture)
+		err := rows.Scan(&u.ID, &u.GoogleUID, &u.Name, &u.Email, &u.GooglePicture, &u.Role, &u.ProfilePicture, &u.Organization, &u.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -37,10 +37,10 @@ func (r *AdminRepository) GetAll() ([]models.BaseUser, error) {
 
 // Get User Use Id
 func (r *AdminRepository) FindByID(id string) (*models.BaseUser, error) {
-	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role FROM admins WHERE id = ?`
+	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, COALESCE(organization, ''), created_at FROM admins WHERE id = ?`
 	row := r.DB.QueryRow(sqlQuery, id)
 	admin := models.BaseUser{}
-	err := row.Scan(&admin.ID, &admin.GoogleUID, &admin.Name, &admin.Email, &admin.GooglePicture, &admin.Role)
+	err := row.Scan(&admin.ID, &admin.GoogleUID, &admin.Name, &admin.Email, &admin.GooglePicture, &admin.Role, &admin.Organization, &admin.CreatedAt)
 	if err == sql.ErrNoRows {
 		return nil, errors.New("user not found")
 	}
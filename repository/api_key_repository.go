@@ -0,0 +1,182 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type APIKeyRepository struct {
+	DB *sql.DB
+}
+
+func NewAPIKeyRepository(db *sql.DB) *APIKeyRepository {
+	return &APIKeyRepository{
+		DB: db,
+	}
+}
+
+// ------------------------------ API KEY ----------------------------------
+
+const apiKeyColumns = `id, prefix, key_hash, name, realm, owner_type, owner_id, scopes, expires_at, last_used_at, created_at, revoked_at`
+
+func (r *APIKeyRepository) CreateAPIKey(key models.APIKey) (int, error) {
+	sqlQuery := `INSERT INTO api_keys (prefix, key_hash, name, realm, owner_type, owner_id, scopes, expires_at, created_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, NOW())`
+	res, err := r.DB.Exec(sqlQuery, key.Prefix, key.KeyHash, key.Name, key.Realm, key.OwnerType, key.OwnerID, strings.Join(key.Scopes, " "), key.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *APIKeyRepository) FindAPIKeyByPrefix(prefix string) (*models.APIKey, error) {
+	sqlQuery := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE prefix = ?`
+	key, err := scanAPIKey(r.DB.QueryRow(sqlQuery, prefix))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("api key not found")
+	}
+	return key, err
+}
+
+func (r *APIKeyRepository) FindAPIKeyByID(id int) (*models.APIKey, error) {
+	sqlQuery := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE id = ?`
+	key, err := scanAPIKey(r.DB.QueryRow(sqlQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("api key not found")
+	}
+	return key, err
+}
+
+func (r *APIKeyRepository) FindAPIKeysByOwner(realm, ownerType string, ownerID int) ([]models.APIKey, error) {
+	sqlQuery := `SELECT ` + apiKeyColumns + ` FROM api_keys WHERE realm = ? AND owner_type = ? AND owner_id = ? ORDER BY created_at DESC`
+	rows, err := r.DB.Query(sqlQuery, realm, ownerType, ownerID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	keys := []models.APIKey{}
+	for rows.Next() {
+		key, err := scanAPIKey(rows)
+		if err != nil {
+			return nil, err
+		}
+		keys = append(keys, *key)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return keys, nil
+}
+
+// TouchAPIKey mengisi last_used_at, paling sering sekali per menit supaya
+// setiap request tidak menulis ke database
+func (r *APIKeyRepository) TouchAPIKey(id int) error {
+	sqlQuery := `UPDATE api_keys SET last_used_at = NOW() WHERE id = ? AND (last_used_at IS NULL OR last_used_at < NOW() - INTERVAL 1 MINUTE)`
+	_, err := r.DB.Exec(sqlQuery, id)
+	return err
+}
+
+func (r *APIKeyRepository) RevokeAPIKey(id int) (bool, error) {
+	sqlQuery := `UPDATE api_keys SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL`
+	res, err := r.DB.Exec(sqlQuery, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func (r *APIKeyRepository) RevokeAPIKeysByOwner(realm, ownerType string, ownerID int) error {
+	sqlQuery := `UPDATE api_keys SET revoked_at = NOW() WHERE realm = ? AND owner_type = ? AND owner_id = ? AND revoked_at IS NULL`
+	_, err := r.DB.Exec(sqlQuery, realm, ownerType, ownerID)
+	return err
+}
+
+func scanAPIKey(row rowScanner) (*models.APIKey, error) {
+	key := models.APIKey{}
+	var scopes string
+	err := row.Scan(&key.ID, &key.Prefix, &key.KeyHash, &key.Name, &key.Realm, &key.OwnerType, &key.OwnerID, &scopes, &key.ExpiresAt, &key.LastUsedAt, &key.CreatedAt, &key.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	key.Scopes = strings.Fields(scopes)
+	return &key, nil
+}
+
+// --------------------------- SERVICE ACCOUNT -----------------------------
+
+const serviceAccountColumns = `id, realm, name, description, role, COALESCE(organization, ''), created_by, created_at, disabled_at`
+
+func (r *APIKeyRepository) CreateServiceAccount(sa models.ServiceAccount) (int, error) {
+	sqlQuery := `INSERT INTO service_accounts (realm, name, description, role, organization, created_by, created_at) VALUES (?, ?, ?, ?, NULLIF(?, ''), ?, NOW())`
+	res, err := r.DB.Exec(sqlQuery, sa.Realm, sa.Name, sa.Description, sa.Role, sa.Organization, sa.CreatedBy)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *APIKeyRepository) FindServiceAccountByID(id int) (*models.ServiceAccount, error) {
+	sqlQuery := `SELECT ` + serviceAccountColumns + ` FROM service_accounts WHERE id = ?`
+	sa := models.ServiceAccount{}
+	err := r.DB.QueryRow(sqlQuery, id).Scan(&sa.ID, &sa.Realm, &sa.Name, &sa.Description, &sa.Role, &sa.Organization, &sa.CreatedBy, &sa.CreatedAt, &sa.DisabledAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("service account not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &sa, nil
+}
+
+func (r *APIKeyRepository) GetAllServiceAccounts() ([]models.ServiceAccount, error) {
+	sqlQuery := `SELECT ` + serviceAccountColumns + ` FROM service_accounts ORDER BY realm, name`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.ServiceAccount{}
+	for rows.Next() {
+		sa := models.ServiceAccount{}
+		err := rows.Scan(&sa.ID, &sa.Realm, &sa.Name, &sa.Description, &sa.Role, &sa.Organization, &sa.CreatedBy, &sa.CreatedAt, &sa.DisabledAt)
+		if err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, sa)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// DisableServiceAccount menonaktifkan service account dan mencabut semua key-nya
+func (r *APIKeyRepository) DisableServiceAccount(id int) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec(`UPDATE service_accounts SET disabled_at = NOW() WHERE id = ? AND disabled_at IS NULL`, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n != 1 {
+		return false, err
+	}
+
+	_, err = tx.Exec(`UPDATE api_keys SET revoked_at = NOW() WHERE owner_type = ? AND owner_id = ? AND revoked_at IS NULL`, models.OwnerServiceAccount, id)
+	if err != nil {
+		return false, err
+	}
+	return true, tx.Commit()
+}
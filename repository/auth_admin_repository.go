@@ -19,8 +19,8 @@ func NewAdminRepository (db *sql.DB) *AdminRepository {
 
 // Create User Register
 func (r *AdminRepository) Create(admin models.BaseUser) error {
-	sqlQuery := `INSERT INTO admins (google_uid, name, email, google_picture) VALUES (?, ?, ?, ?)`
-	_, err := r.DB.Exec(sqlQuery, admin.GoogleUID, admin.Name, admin.Email, admin.GooglePicture)
+	sqlQuery := `INSERT INTO admins (google_uid, name, email, google_picture, google_name, google_email) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.DB.Exec(sqlQuery, admin.GoogleUID, admin.Name, admin.Email, admin.GooglePicture, admin.GoogleName, admin.GoogleEmail)
 	return err
 }
 // Update Status Login User
@@ -37,10 +37,10 @@ func (r *AdminRepository) SaveLoginHistory(adminID int, deviceInfo, ip string) e
 }
 // Get User Use google_uid
 func (r *AdminRepository) FindByGoogleUID(uid string) (*models.BaseUser, error) {
-	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, is_logged_in FROM admins WHERE google_uid = ? LIMIT 1`
+	sqlQuery := `SELECT id, google_uid, name, email, google_picture, COALESCE(profile_picture, ''), role, is_logged_in, COALESCE(organization, ''), COALESCE(google_name, ''), COALESCE(google_email, '') FROM admins WHERE google_uid = ? LIMIT 1`
 	row := r.DB.QueryRow(sqlQuery, uid)
 	admin := models.BaseUser{}
-	err := row.Scan(&admin.ID, &admin.GoogleUID, &admin.Name, &admin.Email, &admin.GooglePicture, &admin.Role, &admin.IsLoggedIn)
+	err := row.Scan(&admin.ID, &admin.GoogleUID, &admin.Name, &admin.Email, &admin.GooglePicture, &admin.ProfilePicture, &admin.Role, &admin.IsLoggedIn, &admin.Organization, &admin.GoogleName, &admin.GoogleEmail)
 	if err != nil {
 		if err == sql.ErrNoRows {
 		return nil, err
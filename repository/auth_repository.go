@@ -20,9 +20,28 @@ type AuthRepository interface {
 	Delete(id string) error
 	UpdatePhotoURL(userID int, url string) error
 
-	// Refresh Token
-	RefreshToken(userID int, refreshToken string, exp time.Time) error
-	FindRefreshToken(userID int) (*models.RefreshToken, error)
-	UpdateRefreshToken(userID int, newRefreshToken string, exp time.Time) error
-	DeleteRefreshToken(UserID int) error
+	// Session (satu row per login/device)
+	CreateSession(session models.Session) (int, error)
+	FindSessionByID(sessionID int) (*models.Session, error)
+	FindSessionsByUserID(userID int) ([]models.Session, error)
+	UpdateSessionRefreshToken(sessionID int, tokenHash string, exp time.Time) error
+	RotateSessionRefreshToken(sessionID, generation int, oldTokenHash, newTokenHash string, exp time.Time) (bool, error)
+	FindRotatedTokenHashes(sessionID int) ([]string, error)
+	SetSessionAccessToken(sessionID int, jti string, exp time.Time) error
+	SetSessionStepUpToken(sessionID int, jti string, exp time.Time) error
+	FindLegacyRefreshTokens() ([]models.Session, error)
+	UpdateLegacyRefreshToken(sessionID int, oldToken, newToken string) error
+	RevokeSession(sessionID int) error
+	DeleteSession(userID, sessionID int) error
+	DeleteSessionsByUserID(userID int) error
+	DeleteExpiredSessions(userID int) error
+	FindAccountsWithActiveSessions() ([]models.BaseUser, error)
+
+	// Security Event
+	SaveSecurityEvent(userID int, eventType, detail, ip string) error
+
+	// Sinkronisasi profil Google dan riwayat perubahan profil
+	UpdateGoogleProfile(user models.BaseUser, changes []models.ProfileChange) error
+	SaveProfileChange(change models.ProfileChange) error
+	FindProfileChanges(userID int) ([]models.ProfileChange, error)
 }
@@ -17,10 +17,10 @@ func NewUserRepository(db *sql.DB) *UserRepository {
 }
 
 func (r *UserRepository) FindByGoogleUID(uid string) (*models.BaseUser, error) {
-	sqlQuery := `SELECT id, google_uid, name, email, google_picture, is_logged_in FROM users WHERE google_uid = ? LIMIT 1`
+	sqlQuery := `SELECT id, google_uid, name, email, google_picture, COALESCE(profile_picture, ''), is_logged_in, COALESCE(organization, ''), COALESCE(google_name, ''), COALESCE(google_email, '') FROM users WHERE google_uid = ? LIMIT 1`
 	row := r.DB.QueryRow(sqlQuery, uid)
 	user := models.BaseUser{}
-	err := row.Scan(&user.ID, &user.GoogleUID, &user.Name, &user.Email, &user.GooglePicture, &user.IsLoggedIn)
+	err := row.Scan(&user.ID, &user.GoogleUID, &user.Name, &user.Email, &user.GooglePicture, &user.ProfilePicture, &user.IsLoggedIn, &user.Organization, &user.GoogleName, &user.GoogleEmail)
 	if err != nil {
 		if err == sql.ErrNoRows {
 		return nil, err
@@ -31,8 +31,8 @@ func (r *UserRepository) FindByGoogleUID(uid string) (*models.BaseUser, error) {
 }
 
 func (r *UserRepository) Create(user models.BaseUser) error {
-	sqlQuery := `INSERT INTO users (google_uid, name, email, google_picture) VALUES (?, ?, ?, ?)`
-	_, err := r.DB.Exec(sqlQuery, user.GoogleUID, user.Name, user.Email, user.GooglePicture)
+	sqlQuery := `INSERT INTO users (google_uid, name, email, google_picture, google_name, google_email) VALUES (?, ?, ?, ?, ?, ?)`
+	_, err := r.DB.Exec(sqlQuery, user.GoogleUID, user.Name, user.Email, user.GooglePicture, user.GoogleName, user.GoogleEmail)
 	return err
 }
 
@@ -0,0 +1,121 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type ImpersonationRepository struct {
+	DB *sql.DB
+}
+
+func NewImpersonationRepository(db *sql.DB) *ImpersonationRepository {
+	return &ImpersonationRepository{
+		DB: db,
+	}
+}
+
+const impersonationColumns = `id, admin_id, user_id, reason, token_id, ip_address, created_at, expires_at, ended_at`
+
+func (r *ImpersonationRepository) CreateImpersonation(imp models.Impersonation) (int, error) {
+	sqlQuery := `INSERT INTO impersonations (admin_id, user_id, reason, token_id, ip_address, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?)`
+	res, err := r.DB.Exec(sqlQuery, imp.AdminID, imp.UserID, imp.Reason, imp.TokenID, imp.IPAddress, imp.CreatedAt, imp.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *ImpersonationRepository) FindImpersonationByID(id int) (*models.Impersonation, error) {
+	sqlQuery := `SELECT ` + impersonationColumns + ` FROM impersonations WHERE id = ?`
+	imp, err := scanImpersonation(r.DB.QueryRow(sqlQuery, id))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("impersonation not found")
+	}
+	return imp, err
+}
+
+// FindImpersonationByTokenID mengembalikan nil bila jti bukan token impersonasi
+func (r *ImpersonationRepository) FindImpersonationByTokenID(tokenID string) (*models.Impersonation, error) {
+	sqlQuery := `SELECT ` + impersonationColumns + ` FROM impersonations WHERE token_id = ?`
+	imp, err := scanImpersonation(r.DB.QueryRow(sqlQuery, tokenID))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	return imp, err
+}
+
+func (r *ImpersonationRepository) GetAllImpersonations() ([]models.Impersonation, error) {
+	sqlQuery := `SELECT ` + impersonationColumns + ` FROM impersonations ORDER BY created_at DESC`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	imps := []models.Impersonation{}
+	for rows.Next() {
+		imp, err := scanImpersonation(rows)
+		if err != nil {
+			return nil, err
+		}
+		imps = append(imps, *imp)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return imps, nil
+}
+
+// EndImpersonation hanya mengubah impersonasi yang belum diakhiri
+func (r *ImpersonationRepository) EndImpersonation(id int) (bool, error) {
+	sqlQuery := `UPDATE impersonations SET ended_at = NOW() WHERE id = ? AND ended_at IS NULL`
+	res, err := r.DB.Exec(sqlQuery, id)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// SaveImpersonationRequest mencari impersonasi lewat jti token-nya
+func (r *ImpersonationRepository) SaveImpersonationRequest(tokenID, method, path string, status int, ip string) error {
+	sqlQuery := `INSERT INTO impersonation_requests (impersonation_id, method, path, status, ip_address, created_at)
+		SELECT id, ?, ?, ?, ?, NOW() FROM impersonations WHERE token_id = ?`
+	_, err := r.DB.Exec(sqlQuery, method, path, status, ip, tokenID)
+	return err
+}
+
+func (r *ImpersonationRepository) FindImpersonationRequests(impersonationID int) ([]models.ImpersonationRequest, error) {
+	sqlQuery := `SELECT id, impersonation_id, method, path, status, ip_address, created_at FROM impersonation_requests WHERE impersonation_id = ? ORDER BY id`
+	rows, err := r.DB.Query(sqlQuery, impersonationID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reqs := []models.ImpersonationRequest{}
+	for rows.Next() {
+		req := models.ImpersonationRequest{}
+		if err := rows.Scan(&req.ID, &req.ImpersonationID, &req.Method, &req.Path, &req.Status, &req.IPAddress, &req.CreatedAt); err != nil {
+			return nil, err
+		}
+		reqs = append(reqs, req)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return reqs, nil
+}
+
+func scanImpersonation(row rowScanner) (*models.Impersonation, error) {
+	imp := models.Impersonation{}
+	err := row.Scan(&imp.ID, &imp.AdminID, &imp.UserID, &imp.Reason, &imp.TokenID, &imp.IPAddress, &imp.CreatedAt, &imp.ExpiresAt, &imp.EndedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &imp, nil
+}
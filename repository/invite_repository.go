@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type InviteRepository struct {
+	DB *sql.DB
+}
+
+func NewInviteRepository(db *sql.DB) *InviteRepository {
+	return &InviteRepository{
+		DB: db,
+	}
+}
+
+const inviteColumns = `id, code_hash, COALESCE(email, ''), COALESCE(hosted_domain, ''), created_by, created_at, expires_at, used_at, COALESCE(used_by_uid, ''), revoked_at`
+
+func (r *InviteRepository) CreateInvite(invite models.AdminInvite) (int, error) {
+	sqlQuery := `INSERT INTO admin_invites (code_hash, email, hosted_domain, created_by, created_at, expires_at) VALUES (?, NULLIF(?, ''), NULLIF(?, ''), ?, NOW(), ?)`
+	res, err := r.DB.Exec(sqlQuery, invite.CodeHash, invite.Email, invite.HostedDomain, invite.CreatedBy, invite.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *InviteRepository) FindInviteByCodeHash(codeHash string) (*models.AdminInvite, error) {
+	sqlQuery := `SELECT ` + inviteColumns + ` FROM admin_invites WHERE code_hash = ?`
+	invite, err := scanInvite(r.DB.QueryRow(sqlQuery, codeHash))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("invite not found")
+	}
+	return invite, err
+}
+
+func (r *InviteRepository) GetAllInvites() ([]models.AdminInvite, error) {
+	sqlQuery := `SELECT ` + inviteColumns + ` FROM admin_invites ORDER BY created_at DESC`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	invites := []models.AdminInvite{}
+	for rows.Next() {
+		invite, err := scanInvite(rows)
+		if err != nil {
+			return nil, err
+		}
+		invites = append(invites, *invite)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return invites, nil
+}
+
+// ConsumeInvite menandai undangan terpakai. Hanya berhasil satu kali untuk
+// undangan yang belum dipakai, belum dicabut dan belum expired, jadi dua
+// registrasi bersamaan tidak bisa memakai kode yang sama.
+func (r *InviteRepository) ConsumeInvite(inviteID int, googleUID string) (bool, error) {
+	sqlQuery := `UPDATE admin_invites SET used_at = NOW(), used_by_uid = ? WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL AND expires_at > NOW()`
+	res, err := r.DB.Exec(sqlQuery, googleUID, inviteID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// ReleaseInvite mengembalikan undangan bila registrasi gagal setelah
+// undangan dipakai
+func (r *InviteRepository) ReleaseInvite(inviteID int, googleUID string) error {
+	sqlQuery := `UPDATE admin_invites SET used_at = NULL, used_by_uid = NULL WHERE id = ? AND used_by_uid = ?`
+	_, err := r.DB.Exec(sqlQuery, inviteID, googleUID)
+	return err
+}
+
+func (r *InviteRepository) RevokeInvite(inviteID int) (bool, error) {
+	sqlQuery := `UPDATE admin_invites SET revoked_at = NOW() WHERE id = ? AND used_at IS NULL AND revoked_at IS NULL`
+	res, err := r.DB.Exec(sqlQuery, inviteID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+type rowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+func scanInvite(row rowScanner) (*models.AdminInvite, error) {
+	invite := models.AdminInvite{}
+	err := row.Scan(&invite.ID, &invite.CodeHash, &invite.Email, &invite.HostedDomain, &invite.CreatedBy, &invite.CreatedAt, &invite.ExpiresAt, &invite.UsedAt, &invite.UsedByUID, &invite.RevokedAt)
+	if err != nil {
+		return nil, err
+	}
+	return &invite, nil
+}
@@ -0,0 +1,150 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+// MFARepository menyimpan TOTP dan recovery code admin
+type MFARepository struct {
+	DB *sql.DB
+}
+
+func NewMFARepository(db *sql.DB) *MFARepository {
+	return &MFARepository{
+		DB: db,
+	}
+}
+
+// FindTOTP mengembalikan (nil, nil) bila admin belum pernah enroll
+func (r *MFARepository) FindTOTP(adminID int) (*models.TOTPFactor, error) {
+	sqlQuery := `SELECT admin_id, secret, enabled_at, last_used_step, failed_attempts, locked_until, created_at FROM admin_totp WHERE admin_id = ?`
+	f := models.TOTPFactor{}
+	err := r.DB.QueryRow(sqlQuery, adminID).Scan(&f.AdminID, &f.Secret, &f.EnabledAt, &f.LastUsedStep, &f.FailedAttempts, &f.LockedUntil, &f.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
+}
+
+// SavePendingTOTP menyimpan secret baru yang belum dikonfirmasi. TOTP yang
+// sudah aktif tidak ditimpa.
+func (r *MFARepository) SavePendingTOTP(adminID int, secret string) (bool, error) {
+	sqlQuery := `INSERT INTO admin_totp (admin_id, secret, created_at) VALUES (?, ?, NOW())
+        ON DUPLICATE KEY UPDATE secret = IF(enabled_at IS NULL, VALUES(secret), secret), last_used_step = IF(enabled_at IS NULL, 0, last_used_step), created_at = IF(enabled_at IS NULL, NOW(), created_at)`
+	if _, err := r.DB.Exec(sqlQuery, adminID, secret); err != nil {
+		return false, err
+	}
+	f, err := r.FindTOTP(adminID)
+	if err != nil || f == nil {
+		return false, err
+	}
+	return !f.Enabled(), nil
+}
+
+// EnableTOTP mengaktifkan TOTP dan mengganti recovery code dalam satu
+// transaksi
+func (r *MFARepository) EnableTOTP(adminID int, recoveryCodeHashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`UPDATE admin_totp SET enabled_at = NOW() WHERE admin_id = ? AND enabled_at IS NULL`, adminID); err != nil {
+		return err
+	}
+	if err := replaceRecoveryCodes(tx, adminID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *MFARepository) ReplaceRecoveryCodes(adminID int, recoveryCodeHashes []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if err := replaceRecoveryCodes(tx, adminID, recoveryCodeHashes); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func replaceRecoveryCodes(tx *sql.Tx, adminID int, hashes []string) error {
+	if _, err := tx.Exec(`DELETE FROM admin_recovery_codes WHERE admin_id = ?`, adminID); err != nil {
+		return err
+	}
+	for _, h := range hashes {
+		if _, err := tx.Exec(`INSERT INTO admin_recovery_codes (admin_id, code_hash, created_at) VALUES (?, ?, NOW())`, adminID, h); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// DeleteTOTP menghapus TOTP beserta recovery code admin
+func (r *MFARepository) DeleteTOTP(adminID int) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM admin_recovery_codes WHERE admin_id = ?`, adminID); err != nil {
+		return err
+	}
+	if _, err := tx.Exec(`DELETE FROM admin_totp WHERE admin_id = ?`, adminID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// UseTOTPStep mencatat time step yang dipakai dan mereset hitungan gagal.
+// false bila step itu (atau yang lebih baru) sudah pernah dipakai.
+func (r *MFARepository) UseTOTPStep(adminID int, step int64) (bool, error) {
+	sqlQuery := `UPDATE admin_totp SET last_used_step = ?, failed_attempts = 0, locked_until = NULL WHERE admin_id = ? AND last_used_step < ?`
+	res, err := r.DB.Exec(sqlQuery, step, adminID, step)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+// UseRecoveryCode menandai recovery code terpakai, hanya berhasil sekali
+func (r *MFARepository) UseRecoveryCode(adminID int, codeHash string) (bool, error) {
+	sqlQuery := `UPDATE admin_recovery_codes SET used_at = NOW() WHERE admin_id = ? AND code_hash = ? AND used_at IS NULL`
+	res, err := r.DB.Exec(sqlQuery, adminID, codeHash)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n != 1 {
+		return false, err
+	}
+	_, err = r.DB.Exec(`UPDATE admin_totp SET failed_attempts = 0, locked_until = NULL WHERE admin_id = ?`, adminID)
+	return true, err
+}
+
+func (r *MFARepository) CountRecoveryCodes(adminID int) (int, error) {
+	var n int
+	err := r.DB.QueryRow(`SELECT COUNT(*) FROM admin_recovery_codes WHERE admin_id = ? AND used_at IS NULL`, adminID).Scan(&n)
+	return n, err
+}
+
+// RecordFailedAttempt menaikkan hitungan kode salah dan mengunci TOTP
+// sampai lockUntil bila sudah mencapai maxAttempts
+func (r *MFARepository) RecordFailedAttempt(adminID, maxAttempts int, lockUntil time.Time) error {
+	sqlQuery := `UPDATE admin_totp SET failed_attempts = failed_attempts + 1,
+        locked_until = IF(failed_attempts >= ?, ?, locked_until) WHERE admin_id = ?`
+	_, err := r.DB.Exec(sqlQuery, maxAttempts, lockUntil, adminID)
+	return err
+}
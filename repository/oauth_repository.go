@@ -0,0 +1,123 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"strings"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type OAuthRepository struct {
+	DB *sql.DB
+}
+
+func NewOAuthRepository(db *sql.DB) *OAuthRepository {
+	return &OAuthRepository{
+		DB: db,
+	}
+}
+
+// -------------------------------- CLIENT ---------------------------------
+
+const oauthClientColumns = `client_id, COALESCE(client_secret_hash, ''), name, realm, redirect_uris, scopes, created_by, created_at, disabled_at`
+
+func (r *OAuthRepository) CreateClient(client models.OAuthClient) error {
+	sqlQuery := `INSERT INTO oauth_clients (client_id, client_secret_hash, name, realm, redirect_uris, scopes, created_by, created_at) VALUES (?, NULLIF(?, ''), ?, ?, ?, ?, ?, NOW())`
+	_, err := r.DB.Exec(sqlQuery, client.ClientID, client.ClientSecretHash, client.Name, client.Realm, strings.Join(client.RedirectURIs, " "), strings.Join(client.Scopes, " "), client.CreatedBy)
+	return err
+}
+
+func (r *OAuthRepository) FindClientByID(clientID string) (*models.OAuthClient, error) {
+	sqlQuery := `SELECT ` + oauthClientColumns + ` FROM oauth_clients WHERE client_id = ?`
+	client, err := scanOAuthClient(r.DB.QueryRow(sqlQuery, clientID))
+	if err == sql.ErrNoRows {
+		return nil, errors.New("client not found")
+	}
+	return client, err
+}
+
+func (r *OAuthRepository) GetAllClients() ([]models.OAuthClient, error) {
+	sqlQuery := `SELECT ` + oauthClientColumns + ` FROM oauth_clients ORDER BY created_at DESC`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	clients := []models.OAuthClient{}
+	for rows.Next() {
+		client, err := scanOAuthClient(rows)
+		if err != nil {
+			return nil, err
+		}
+		clients = append(clients, *client)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return clients, nil
+}
+
+func (r *OAuthRepository) DisableClient(clientID string) (bool, error) {
+	sqlQuery := `UPDATE oauth_clients SET disabled_at = NOW() WHERE client_id = ? AND disabled_at IS NULL`
+	res, err := r.DB.Exec(sqlQuery, clientID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
+
+func scanOAuthClient(row rowScanner) (*models.OAuthClient, error) {
+	client := models.OAuthClient{}
+	var redirectURIs, scopes string
+	err := row.Scan(&client.ClientID, &client.ClientSecretHash, &client.Name, &client.Realm, &redirectURIs, &scopes, &client.CreatedBy, &client.CreatedAt, &client.DisabledAt)
+	if err != nil {
+		return nil, err
+	}
+	client.RedirectURIs = strings.Fields(redirectURIs)
+	client.Scopes = strings.Fields(scopes)
+	return &client, nil
+}
+
+// --------------------------- AUTHORIZATION CODE --------------------------
+
+func (r *OAuthRepository) CreateAuthorizationCode(code models.AuthorizationCode) error {
+	// bersihkan code yang sudah expired
+	if _, err := r.DB.Exec(`DELETE FROM oauth_authorization_codes WHERE expires_at <= NOW() - INTERVAL 1 HOUR`); err != nil {
+		return err
+	}
+
+	sqlQuery := `INSERT INTO oauth_authorization_codes (code_hash, client_id, realm, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, created_at, expires_at) VALUES (?, ?, ?, ?, ?, ?, ?, ?, ?, NOW(), ?)`
+	_, err := r.DB.Exec(sqlQuery, code.CodeHash, code.ClientID, code.Realm, code.UserID, code.RedirectURI, code.Scope, code.Nonce, code.CodeChallenge, code.AuthTime, code.ExpiresAt)
+	return err
+}
+
+// ConsumeAuthorizationCode mengambil code dan menandainya terpakai dalam satu
+// transaksi. Code yang sudah pernah dipakai tetap dikembalikan (UsedAt terisi)
+// supaya pemanggil bisa menolak replay.
+func (r *OAuthRepository) ConsumeAuthorizationCode(codeHash string) (*models.AuthorizationCode, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return nil, err
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `SELECT code_hash, client_id, realm, user_id, redirect_uri, scope, nonce, code_challenge, auth_time, created_at, expires_at, used_at FROM oauth_authorization_codes WHERE code_hash = ? FOR UPDATE`
+	code := models.AuthorizationCode{}
+	err = tx.QueryRow(sqlQuery, codeHash).Scan(&code.CodeHash, &code.ClientID, &code.Realm, &code.UserID, &code.RedirectURI, &code.Scope, &code.Nonce, &code.CodeChallenge, &code.AuthTime, &code.CreatedAt, &code.ExpiresAt, &code.UsedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("authorization code not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if code.UsedAt == nil {
+		if _, err := tx.Exec(`UPDATE oauth_authorization_codes SET used_at = NOW() WHERE code_hash = ?`, codeHash); err != nil {
+			return nil, err
+		}
+	}
+	return &code, tx.Commit()
+}
@@ -0,0 +1,57 @@
+package repository
+
+import "github.com/muhammadfarrasfajri/login-google/models"
+
+// Simpan hasil sinkronisasi profil dari ID token Google beserta riwayat
+// perubahannya dalam satu transaksi
+func (r *AdminRepository) UpdateGoogleProfile(admin models.BaseUser, changes []models.ProfileChange) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `UPDATE admins SET name = ?, email = ?, google_picture = ?, google_name = ?, google_email = ? WHERE id = ?`
+	if _, err := tx.Exec(sqlQuery, admin.Name, admin.Email, admin.GooglePicture, admin.GoogleName, admin.GoogleEmail, admin.ID); err != nil {
+		return err
+	}
+
+	sqlQuery = `INSERT INTO profile_changes_admin (admin_id, field, old_value, new_value, source, created_at) VALUES (?, ?, ?, ?, ?, NOW())`
+	for _, change := range changes {
+		if _, err := tx.Exec(sqlQuery, change.AdminOrUserID, change.Field, change.OldValue, change.NewValue, change.Source); err != nil {
+			return err
+		}
+	}
+
+	return tx.Commit()
+}
+
+func (r *AdminRepository) SaveProfileChange(change models.ProfileChange) error {
+	sqlQuery := `INSERT INTO profile_changes_admin (admin_id, field, old_value, new_value, source, created_at) VALUES (?, ?, ?, ?, ?, NOW())`
+	_, err := r.DB.Exec(sqlQuery, change.AdminOrUserID, change.Field, change.OldValue, change.NewValue, change.Source)
+	return err
+}
+
+// Riwayat perubahan profil, terbaru di atas
+func (r *AdminRepository) FindProfileChanges(adminID int) ([]models.ProfileChange, error) {
+	sqlQuery := `SELECT id, admin_id, field, old_value, new_value, source, created_at FROM profile_changes_admin WHERE admin_id = ? ORDER BY created_at DESC, id DESC`
+	rows, err := r.DB.Query(sqlQuery, adminID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	changes := []models.ProfileChange{}
+	for rows.Next() {
+		c := models.ProfileChange{}
+		err := rows.Scan(&c.ID, &c.AdminOrUserID, &c.Field, &c.OldValue, &c.NewValue, &c.Source, &c.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		changes = append(changes, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return changes, nil
+}
@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"database/sql"
+	"sync"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+// RateLimitRepository menyimpan token bucket di MySQL, dipakai bersama oleh
+// semua instance server.
+type RateLimitRepository struct {
+	DB        *sql.DB
+	mu        sync.Mutex
+	lastSweep time.Time
+}
+
+func NewRateLimitRepository(db *sql.DB) *RateLimitRepository {
+	return &RateLimitRepository{
+		DB: db,
+	}
+}
+
+func (r *RateLimitRepository) Take(key string, limit middleware.RateLimit) (bool, time.Duration, error) {
+	now := time.Now()
+
+	// bersihkan bucket yang sudah lama tidak dipakai (pasti sudah penuh lagi)
+	r.mu.Lock()
+	sweep := now.Sub(r.lastSweep) > 10*time.Minute
+	if sweep {
+		r.lastSweep = now
+	}
+	r.mu.Unlock()
+	if sweep {
+		if _, err := r.DB.Exec(`DELETE FROM rate_limit_buckets WHERE updated_at < ?`, now.Add(-24*time.Hour)); err != nil {
+			return false, 0, err
+		}
+	}
+
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, 0, err
+	}
+	defer tx.Rollback()
+
+	// buat row bucket dulu (penuh) lalu kunci row itu. SELECT ... FOR UPDATE
+	// pada row yang belum ada mengambil gap lock, dan request pertama yang
+	// bersamaan untuk key yang sama bisa saling deadlock.
+	sqlQuery := `INSERT INTO rate_limit_buckets (bucket_key, tokens, updated_at) VALUES (?, ?, ?) ON DUPLICATE KEY UPDATE bucket_key = bucket_key`
+	if _, err := tx.Exec(sqlQuery, key, limit.Burst, now); err != nil {
+		return false, 0, err
+	}
+
+	var tokens float64
+	var updated time.Time
+	err = tx.QueryRow(`SELECT tokens, updated_at FROM rate_limit_buckets WHERE bucket_key = ? FOR UPDATE`, key).Scan(&tokens, &updated)
+	if err != nil {
+		return false, 0, err
+	}
+
+	tokens, allowed, retryAfter := limit.Take(tokens, now.Sub(updated))
+
+	sqlQuery = `UPDATE rate_limit_buckets SET tokens = ?, updated_at = ? WHERE bucket_key = ?`
+	if _, err := tx.Exec(sqlQuery, tokens, now, key); err != nil {
+		return false, 0, err
+	}
+	if err := tx.Commit(); err != nil {
+		return false, 0, err
+	}
+	return allowed, retryAfter, nil
+}
@@ -0,0 +1,42 @@
+package repository
+
+import (
+	"database/sql"
+	"time"
+)
+
+// RevocationRepository menyimpan denylist jti access token di MySQL, dipakai
+// bersama oleh semua instance server.
+type RevocationRepository struct {
+	DB *sql.DB
+}
+
+func NewRevocationRepository(db *sql.DB) *RevocationRepository {
+	return &RevocationRepository{
+		DB: db,
+	}
+}
+
+func (r *RevocationRepository) Revoke(jti string, expiresAt time.Time) error {
+	// bersihkan entry yang token-nya sudah expired
+	if _, err := r.DB.Exec(`DELETE FROM revoked_tokens WHERE expires_at <= NOW()`); err != nil {
+		return err
+	}
+
+	sqlQuery := `INSERT INTO revoked_tokens (jti, expires_at, revoked_at) VALUES (?, ?, NOW()) ON DUPLICATE KEY UPDATE expires_at = VALUES(expires_at)`
+	_, err := r.DB.Exec(sqlQuery, jti, expiresAt)
+	return err
+}
+
+func (r *RevocationRepository) IsRevoked(jti string) (bool, error) {
+	sqlQuery := `SELECT 1 FROM revoked_tokens WHERE jti = ? AND expires_at > NOW()`
+	var found int
+	err := r.DB.QueryRow(sqlQuery, jti).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
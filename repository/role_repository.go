@@ -0,0 +1,140 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type RoleRepository struct {
+	DB *sql.DB
+}
+
+func NewRoleRepository(db *sql.DB) *RoleRepository {
+	return &RoleRepository{
+		DB: db,
+	}
+}
+
+// Permission milik role di realm tertentu, kosong bila role tidak ada
+func (r *RoleRepository) PermissionsForRole(realm, role string) ([]string, error) {
+	sqlQuery := `SELECT rp.permission FROM role_permissions rp JOIN roles r ON r.id = rp.role_id WHERE r.realm = ? AND r.name = ?`
+	rows, err := r.DB.Query(sqlQuery, realm, role)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []string{}
+	for rows.Next() {
+		var p string
+		if err := rows.Scan(&p); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
+
+func (r *RoleRepository) GetAllRoles() ([]models.Role, error) {
+	sqlQuery := `SELECT id, realm, name, description, created_at FROM roles ORDER BY realm, name`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	roles := []models.Role{}
+	for rows.Next() {
+		role := models.Role{}
+		if err := rows.Scan(&role.ID, &role.Realm, &role.Name, &role.Description, &role.CreatedAt); err != nil {
+			return nil, err
+		}
+		roles = append(roles, role)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	for i := range roles {
+		roles[i].Permissions, err = r.PermissionsForRole(roles[i].Realm, roles[i].Name)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return roles, nil
+}
+
+func (r *RoleRepository) FindRoleByID(id int) (*models.Role, error) {
+	sqlQuery := `SELECT id, realm, name, description, created_at FROM roles WHERE id = ?`
+	role := models.Role{}
+	err := r.DB.QueryRow(sqlQuery, id).Scan(&role.ID, &role.Realm, &role.Name, &role.Description, &role.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("role not found")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	role.Permissions, err = r.PermissionsForRole(role.Realm, role.Name)
+	return &role, err
+}
+
+func (r *RoleRepository) RoleExists(realm, name string) (bool, error) {
+	sqlQuery := `SELECT 1 FROM roles WHERE realm = ? AND name = ?`
+	var found int
+	err := r.DB.QueryRow(sqlQuery, realm, name).Scan(&found)
+	if err == sql.ErrNoRows {
+		return false, nil
+	}
+	return err == nil, err
+}
+
+func (r *RoleRepository) CreateRole(role models.Role) (int, error) {
+	sqlQuery := `INSERT INTO roles (realm, name, description, created_at) VALUES (?, ?, ?, NOW())`
+	res, err := r.DB.Exec(sqlQuery, role.Realm, role.Name, role.Description)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *RoleRepository) GetAllPermissions() ([]models.Permission, error) {
+	sqlQuery := `SELECT name, description FROM permissions ORDER BY name`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	permissions := []models.Permission{}
+	for rows.Next() {
+		p := models.Permission{}
+		if err := rows.Scan(&p.Name, &p.Description); err != nil {
+			return nil, err
+		}
+		permissions = append(permissions, p)
+	}
+	return permissions, rows.Err()
+}
+
+// SetRolePermissions mengganti seluruh permission role dalam satu transaksi
+func (r *RoleRepository) SetRolePermissions(roleID int, permissions []string) error {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if _, err := tx.Exec(`DELETE FROM role_permissions WHERE role_id = ?`, roleID); err != nil {
+		return err
+	}
+	for _, p := range permissions {
+		if _, err := tx.Exec(`INSERT INTO role_permissions (role_id, permission) VALUES (?, ?)`, roleID, p); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,8 @@
+package repository
+
+// Simpan kejadian keamanan (misalnya reuse refresh token)
+func (r *AdminRepository) SaveSecurityEvent(adminID int, eventType, detail, ip string) error {
+	sqlQuery := `INSERT INTO security_events_admin (admin_id, event_type, detail, ip_address, created_at) VALUES (?, ?, ?, ?, NOW())`
+	_, err := r.DB.Exec(sqlQuery, adminID, eventType, detail, ip)
+	return err
+}
@@ -0,0 +1,8 @@
+package repository
+
+// Simpan kejadian keamanan (misalnya reuse refresh token)
+func (r *UserRepository) SaveSecurityEvent(userID int, eventType, detail, ip string) error {
+	sqlQuery := `INSERT INTO security_events_user (user_id, event_type, detail, ip_address, created_at) VALUES (?, ?, ?, ?, NOW())`
+	_, err := r.DB.Exec(sqlQuery, userID, eventType, detail, ip)
+	return err
+}
@@ -0,0 +1,203 @@
+package repository
+
+import (
+	"database/sql"
+	"errors"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+// Create session baru, refresh token diisi setelah id session diketahui
+func (r *UserRepository) CreateSession(session models.Session) (int, error) {
+	sqlQuery := `INSERT INTO sessions_user (user_id, device_info, ip_address, created_at, last_used_at, expires_at) VALUES (?, ?, ?, NOW(), NOW(), ?)`
+	res, err := r.DB.Exec(sqlQuery, session.AdminOrUserID, session.DeviceInfo, session.IPAddress, session.ExpiresAt)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *UserRepository) FindSessionByID(sessionID int) (*models.Session, error) {
+	sqlQuery := `SELECT id, user_id, COALESCE(refresh_token_hash, ''), COALESCE(refresh_token, ''), generation, revoked_at, COALESCE(access_jti, ''), access_expires_at, COALESCE(step_up_jti, ''), step_up_expires_at, device_info, ip_address, created_at, last_used_at, expires_at FROM sessions_user WHERE id = ?`
+	row := r.DB.QueryRow(sqlQuery, sessionID)
+	session := models.Session{}
+	err := row.Scan(&session.ID, &session.AdminOrUserID, &session.RefreshTokenHash, &session.LegacyRefreshToken, &session.Generation, &session.RevokedAt, &session.AccessJTI, &session.AccessExpiresAt, &session.StepUpJTI, &session.StepUpExpiresAt, &session.DeviceInfo, &session.IPAddress, &session.CreatedAt, &session.LastUsedAt, &session.ExpiresAt)
+	if err == sql.ErrNoRows {
+		return nil, errors.New("session not found")
+	}
+	return &session, err
+}
+
+func (r *UserRepository) FindSessionsByUserID(userID int) ([]models.Session, error) {
+	sqlQuery := `SELECT id, user_id, COALESCE(refresh_token_hash, ''), COALESCE(refresh_token, ''), generation, revoked_at, COALESCE(access_jti, ''), access_expires_at, COALESCE(step_up_jti, ''), step_up_expires_at, device_info, ip_address, created_at, last_used_at, expires_at FROM sessions_user WHERE user_id = ? AND revoked_at IS NULL AND expires_at > NOW() ORDER BY last_used_at DESC`
+	rows, err := r.DB.Query(sqlQuery, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s := models.Session{}
+		err := rows.Scan(&s.ID, &s.AdminOrUserID, &s.RefreshTokenHash, &s.LegacyRefreshToken, &s.Generation, &s.RevokedAt, &s.AccessJTI, &s.AccessExpiresAt, &s.StepUpJTI, &s.StepUpExpiresAt, &s.DeviceInfo, &s.IPAddress, &s.CreatedAt, &s.LastUsedAt, &s.ExpiresAt)
+		if err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+func (r *UserRepository) UpdateSessionRefreshToken(sessionID int, tokenHash string, exp time.Time) error {
+	sqlQuery := `UPDATE sessions_user SET refresh_token_hash = ?, refresh_token = NULL, expires_at = ?, last_used_at = NOW() WHERE id = ?`
+	_, err := r.DB.Exec(sqlQuery, tokenHash, exp, sessionID)
+	return err
+}
+
+// Rotasi refresh token hanya berhasil bila generation belum berubah, jadi dua
+// refresh paralel dengan token yang sama tidak bisa sama-sama lolos. Hash
+// token lama disimpan di history supaya reuse-nya bisa dikenali.
+func (r *UserRepository) RotateSessionRefreshToken(sessionID, generation int, oldTokenHash, newTokenHash string, exp time.Time) (bool, error) {
+	tx, err := r.DB.Begin()
+	if err != nil {
+		return false, err
+	}
+	defer tx.Rollback()
+
+	sqlQuery := `UPDATE sessions_user SET refresh_token_hash = ?, refresh_token = NULL, generation = generation + 1, expires_at = ?, last_used_at = NOW() WHERE id = ? AND generation = ? AND revoked_at IS NULL`
+	res, err := tx.Exec(sqlQuery, newTokenHash, exp, sessionID, generation)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	if err != nil || n != 1 {
+		return false, err
+	}
+
+	sqlQuery = `INSERT INTO refresh_token_history_user (session_id, token_hash, generation, rotated_at) VALUES (?, ?, ?, NOW())`
+	if _, err := tx.Exec(sqlQuery, sessionID, oldTokenHash, generation); err != nil {
+		return false, err
+	}
+
+	return true, tx.Commit()
+}
+
+// Hash refresh token yang sudah dirotasi dalam satu session
+func (r *UserRepository) FindRotatedTokenHashes(sessionID int) ([]string, error) {
+	sqlQuery := `SELECT token_hash FROM refresh_token_history_user WHERE session_id = ?`
+	rows, err := r.DB.Query(sqlQuery, sessionID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := []string{}
+	for rows.Next() {
+		var hash string
+		if err := rows.Scan(&hash); err != nil {
+			return nil, err
+		}
+		hashes = append(hashes, hash)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return hashes, nil
+}
+
+// Simpan jti access token terakhir, supaya bisa dicabut saat logout
+func (r *UserRepository) SetSessionAccessToken(sessionID int, jti string, exp time.Time) error {
+	sqlQuery := `UPDATE sessions_user SET access_jti = ?, access_expires_at = ? WHERE id = ?`
+	_, err := r.DB.Exec(sqlQuery, jti, exp, sessionID)
+	return err
+}
+
+// Simpan jti elevated token (step-up) terakhir, supaya ikut dicabut saat logout
+func (r *UserRepository) SetSessionStepUpToken(sessionID int, jti string, exp time.Time) error {
+	sqlQuery := `UPDATE sessions_user SET step_up_jti = ?, step_up_expires_at = ? WHERE id = ?`
+	_, err := r.DB.Exec(sqlQuery, jti, exp, sessionID)
+	return err
+}
+
+// Revoke seluruh family, row tetap disimpan supaya reuse berikutnya tetap terdeteksi
+func (r *UserRepository) RevokeSession(sessionID int) error {
+	sqlQuery := `UPDATE sessions_user SET revoked_at = NOW() WHERE id = ? AND revoked_at IS NULL`
+	_, err := r.DB.Exec(sqlQuery, sessionID)
+	return err
+}
+
+func (r *UserRepository) DeleteSession(userID, sessionID int) error {
+	sqlQuery := `DELETE FROM sessions_user WHERE id = ? AND user_id = ?`
+	_, err := r.DB.Exec(sqlQuery, sessionID, userID)
+	return err
+}
+
+func (r *UserRepository) DeleteSessionsByUserID(userID int) error {
+	sqlQuery := `DELETE FROM sessions_user WHERE user_id = ?`
+	_, err := r.DB.Exec(sqlQuery, userID)
+	return err
+}
+
+func (r *UserRepository) DeleteExpiredSessions(userID int) error {
+	sqlQuery := `DELETE FROM sessions_user WHERE user_id = ? AND expires_at <= NOW()`
+	_, err := r.DB.Exec(sqlQuery, userID)
+	return err
+}
+
+// Akun yang masih punya session aktif (id, google_uid dan email saja)
+func (r *UserRepository) FindAccountsWithActiveSessions() ([]models.BaseUser, error) {
+	sqlQuery := `SELECT DISTINCT u.id, u.google_uid, u.email FROM users u JOIN sessions_user s ON s.user_id = u.id WHERE s.revoked_at IS NULL AND s.expires_at > NOW()`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	accounts := []models.BaseUser{}
+	for rows.Next() {
+		u := models.BaseUser{}
+		if err := rows.Scan(&u.ID, &u.GoogleUID, &u.Email); err != nil {
+			return nil, err
+		}
+		accounts = append(accounts, u)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return accounts, nil
+}
+
+// Session yang masih menyimpan refresh token format lama (terenkripsi)
+func (r *UserRepository) FindLegacyRefreshTokens() ([]models.Session, error) {
+	sqlQuery := `SELECT id, user_id, refresh_token FROM sessions_user WHERE refresh_token IS NOT NULL AND expires_at > NOW()`
+	rows, err := r.DB.Query(sqlQuery)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	sessions := []models.Session{}
+	for rows.Next() {
+		s := models.Session{}
+		if err := rows.Scan(&s.ID, &s.AdminOrUserID, &s.LegacyRefreshToken); err != nil {
+			return nil, err
+		}
+		sessions = append(sessions, s)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return sessions, nil
+}
+
+// Ganti ciphertext refresh token lama, hanya bila belum dirotasi
+func (r *UserRepository) UpdateLegacyRefreshToken(sessionID int, oldToken, newToken string) error {
+	sqlQuery := `UPDATE sessions_user SET refresh_token = ? WHERE id = ? AND refresh_token = ?`
+	_, err := r.DB.Exec(sqlQuery, newToken, sessionID, oldToken)
+	return err
+}
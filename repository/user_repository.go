@@ -10,7 +10,7 @@ import (
 // --------------------------- GET ALL USERS -----------------------------------
 
 func (r *UserRepository) GetAll() ([]models.BaseUser, error) {
-	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, profile_picture FROM users`
+	sqlQuery := `SELECT id, google_uid, name, email, google_picture, role, profile_picture, COALESCE(organization, ''), created_at FROM users`
 	rows, err := r.DB.Query(sqlQuery)
 	if err != nil {
 		return nil, err
@@ -21,7 +21,7 @@ func (r *UserRepository) GetAll() ([]models.BaseUser, error) {
 
 	for rows.Next() {
 		u := models.BaseUser{}
-		err := rows.Scan(&u.ID, &u.GoogleUID, &u.Name, &u.Email, &u.GooglePicture, &u.Role, &u.ProfilePicture)
+		err := rows.Scan(&u.ID, &u.GoogleUID, &u.Name, &u.Email, &u.GooglePicture, &u.Role, &u.ProfilePicture, &u.Organization, &u.CreatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -37,12 +37,12 @@ func (r *UserRepository) GetAll() ([]models.BaseUser, error) {
 
 func (r *UserRepository) FindByID(id string) (*models.BaseUser, error) {
 	row := r.DB.QueryRow(`
-        SELECT id, google_uid, name, email, google_picture, role, profile_picture
+        SELECT id, google_uid, name, email, google_picture, role, profile_picture, COALESCE(organization, ''), created_at
         FROM users WHERE id = ?
     `, id)
 
 	user := models.BaseUser{}
-	err := row.Scan(&user.ID, &user.GoogleUID, &user.Name, &user.Email, &user.GooglePicture, &user.Role, &user.ProfilePicture)
+	err := row.Scan(&user.ID, &user.GoogleUID, &user.Name, &user.Email, &user.GooglePicture, &user.Role, &user.ProfilePicture, &user.Organization, &user.CreatedAt)
 
 	if err == sql.ErrNoRows {
 		fmt.Println("No user found with ID:", err)
@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"database/sql"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+type WebAuthnRepository struct {
+	DB *sql.DB
+}
+
+func NewWebAuthnRepository(db *sql.DB) *WebAuthnRepository {
+	return &WebAuthnRepository{
+		DB: db,
+	}
+}
+
+func (r *WebAuthnRepository) CreateCredential(cred models.WebAuthnCredential) (int, error) {
+	sqlQuery := `INSERT INTO admin_webauthn_credentials (admin_id, credential_id, name, credential, created_at) VALUES (?, ?, ?, ?, NOW())`
+	res, err := r.DB.Exec(sqlQuery, cred.AdminID, cred.CredentialID, cred.Name, cred.Credential)
+	if err != nil {
+		return 0, err
+	}
+	id, err := res.LastInsertId()
+	return int(id), err
+}
+
+func (r *WebAuthnRepository) FindCredentialsByAdmin(adminID int) ([]models.WebAuthnCredential, error) {
+	sqlQuery := `SELECT id, admin_id, credential_id, name, credential, created_at, last_used_at FROM admin_webauthn_credentials WHERE admin_id = ? ORDER BY created_at`
+	rows, err := r.DB.Query(sqlQuery, adminID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	creds := []models.WebAuthnCredential{}
+	for rows.Next() {
+		c := models.WebAuthnCredential{}
+		if err := rows.Scan(&c.ID, &c.AdminID, &c.CredentialID, &c.Name, &c.Credential, &c.CreatedAt, &c.LastUsedAt); err != nil {
+			return nil, err
+		}
+		creds = append(creds, c)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+	return creds, nil
+}
+
+// UpdateCredentialUsage menyimpan sign count terbaru setelah assertion
+func (r *WebAuthnRepository) UpdateCredentialUsage(credentialID, credential string) error {
+	sqlQuery := `UPDATE admin_webauthn_credentials SET credential = ?, last_used_at = NOW() WHERE credential_id = ?`
+	_, err := r.DB.Exec(sqlQuery, credential, credentialID)
+	return err
+}
+
+func (r *WebAuthnRepository) DeleteCredential(adminID, id int) (bool, error) {
+	res, err := r.DB.Exec(`DELETE FROM admin_webauthn_credentials WHERE id = ? AND admin_id = ?`, id, adminID)
+	if err != nil {
+		return false, err
+	}
+	n, err := res.RowsAffected()
+	return n == 1, err
+}
@@ -6,7 +6,29 @@ import (
 	"github.com/muhammadfarrasfajri/login-google/middleware"
 )
 
-func SetupRoutes(r *gin.Engine, authAdminController *controllers.AuthController,authUserController *controllers.AuthController, userController *controllers.UserController, jwtManager *middleware.JWTManager) {
+func SetupRoutes(r *gin.Engine, authAdminController *controllers.AuthController,authUserController *controllers.AuthController, meController *controllers.MeController, mfaController *controllers.MFAController, userController *controllers.UserController, jwksController *controllers.JWKSController, inviteController *controllers.InviteController, roleController *controllers.RoleController, apiKeyController *controllers.APIKeyController, oidcController *controllers.OIDCController, webAuthnController *controllers.WebAuthnController, impersonationController *controllers.ImpersonationController, adminJWT *middleware.JWTManager, userJWT *middleware.JWTManager, csrf *middleware.CSRFProtection, rateLimit *middleware.RateLimiter, rbac *middleware.RBAC, policies *middleware.PolicyEngine, stepUp gin.HandlerFunc) {
+
+	// ===========================
+	// PUBLIC KEYS
+	// ===========================
+	r.GET("/.well-known/jwks.json", jwksController.JWKS)
+
+	// ===========================
+	// OIDC PROVIDER (bila OIDC_ISSUER diisi)
+	// ===========================
+	if oidcController != nil {
+		r.GET("/.well-known/openid-configuration", oidcController.Discovery)
+		oauth := r.Group("/oauth")
+		{
+			oauth.GET("/authorize", oidcController.Authorize)
+			oauth.POST("/authorize/complete", rateLimit.Middleware("oauth.authorize"), oidcController.CompleteAuthorization)
+			oauth.POST("/token", rateLimit.Middleware("oauth.token"), oidcController.Token)
+			oauth.GET("/userinfo", oidcController.UserInfo)
+			oauth.POST("/userinfo", oidcController.UserInfo)
+			oauth.POST("/introspect", oidcController.Introspect)
+			oauth.POST("/revoke", oidcController.Revoke)
+		}
+	}
 
 	// ===========================
 	// AUTH ROUTES
@@ -14,35 +36,109 @@ func SetupRoutes(r *gin.Engine, authAdminController *controllers.AuthController,
 	auth := r.Group("/api/auth")
 	{
 		//auth admin
-		auth.POST("/admin/register", authAdminController.RegisterAdmin)
-		auth.POST("/admin/login", authAdminController.LoginAdmin)
-		auth.POST("/admin/refresh", authAdminController.RefreshTokenAdmin)
-		auth.POST("/admin/logout", jwtManager.AuthMiddleware(), authAdminController.LogoutAdmin)
+		auth.POST("/admin/register", rateLimit.Middleware("admin.register"), authAdminController.RegisterAdmin)
+		auth.POST("/admin/login", rateLimit.Middleware("admin.login"), authAdminController.LoginAdmin)
+		auth.POST("/admin/refresh", rateLimit.Middleware("admin.refresh"), csrf.Middleware(), authAdminController.RefreshTokenAdmin)
+		auth.POST("/admin/logout", adminJWT.AuthMiddleware(), csrf.Middleware(), middleware.RejectAPIKeys(), authAdminController.LogoutAdmin)
+
+		//TOTP admin: langkah kedua login, lalu kelola TOTP sendiri
+		auth.POST("/admin/mfa/verify", rateLimit.Middleware("admin.mfa"), authAdminController.VerifyMFAAdmin)
+		auth.POST("/admin/mfa/enroll", rateLimit.Middleware("admin.mfa"), authAdminController.EnrollMFAAdmin)
+		mfa := auth.Group("/admin/mfa", adminJWT.AuthMiddleware(), middleware.RejectAPIKeys())
+		mfa.GET("", mfaController.Status)
+		mfa.POST("/totp", mfaController.BeginEnrollment)
+		mfa.POST("/totp/confirm", mfaController.ConfirmEnrollment)
+		mfa.DELETE("/totp", mfaController.Disable)
+		mfa.POST("/recovery-codes", mfaController.RegenerateRecoveryCodes)
+
+		//passkey admin: registrasi lalu step-up sebelum route sensitif. Passkey
+		//kedua dan seterusnya (dan hapus passkey) juga butuh step-up
+		if webAuthnController != nil {
+			webauthn := auth.Group("/admin/webauthn", adminJWT.AuthMiddleware(), middleware.RejectAPIKeys())
+			enrolledStepUp := webAuthnController.RequireStepUpOnceEnrolled(stepUp)
+			webauthn.POST("/register/begin", enrolledStepUp, webAuthnController.BeginRegistration)
+			webauthn.POST("/register/finish", enrolledStepUp, webAuthnController.FinishRegistration)
+			webauthn.GET("/credentials", webAuthnController.ListCredentials)
+			webauthn.DELETE("/credentials/:id", enrolledStepUp, webAuthnController.DeleteCredential)
+			auth.POST("/admin/step-up/begin", adminJWT.AuthMiddleware(), middleware.RejectAPIKeys(), webAuthnController.BeginStepUp)
+			auth.POST("/admin/step-up/finish", adminJWT.AuthMiddleware(), middleware.RejectAPIKeys(), webAuthnController.FinishStepUp)
+		}
 	
 		//auth user
-		auth.POST("/user/register", authUserController.RegisterUser)
-		auth.POST("/user/login", authUserController.LoginUser)
-		auth.POST("/user/refresh", authUserController.RefreshTokenAdmin)
-		auth.POST("/user/logout", jwtManager.AuthMiddleware(), authUserController.LogoutUser)
+		auth.POST("/user/register", rateLimit.Middleware("user.register"), authUserController.RegisterUser)
+		auth.POST("/user/login", rateLimit.Middleware("user.login"), authUserController.LoginUser)
+		auth.POST("/user/refresh", rateLimit.Middleware("user.refresh"), csrf.Middleware(), authUserController.RefreshTokenUser)
+		auth.POST("/user/logout", userJWT.AuthMiddleware(), csrf.Middleware(), middleware.RejectAPIKeys(), middleware.RejectImpersonation(), authUserController.LogoutUser)
+
+		//akun yang sedang login (admin atau user)
+		auth.GET("/me", middleware.AnyRealm(adminJWT, userJWT), meController.Me)
+		auth.PATCH("/me", middleware.AnyRealm(adminJWT, userJWT), middleware.RejectAPIKeys(), middleware.RejectImpersonation(), meController.UpdateMe)
+		auth.GET("/me/profile-history", middleware.AnyRealm(adminJWT, userJWT), meController.ProfileHistory)
 	}
 
 	// ===========================
 	// USER ROUTES
 	// ===========================
-	user := r.Group("/users", jwtManager.AuthMiddleware())
+	user := r.Group("/users", userJWT.AuthMiddleware())
 	{
 		user.GET("/:id", userController.GetByID)
+
+		// API key pribadi
+		user.POST("/api-keys", middleware.RejectAPIKeys(), middleware.RejectImpersonation(), apiKeyController.CreatePersonal)
+		user.GET("/api-keys", middleware.RejectAPIKeys(), apiKeyController.ListPersonal)
+		user.DELETE("/api-keys/:key_id", middleware.RejectAPIKeys(), middleware.RejectImpersonation(), apiKeyController.RevokePersonal)
 	}
 	
 	// ===========================
 	// ADMIN ROUTES
 	// ===========================
 
-	admin := r.Group("/admin", jwtManager.AuthMiddleware(), middleware.AdminOnly())
+	admin := r.Group("/admin", adminJWT.AuthMiddleware())
 	{
-		admin.GET("/:id", userController.GetByID)
-		admin.GET("/users", userController.GetAll)
-		admin.PATCH("/users/:id", userController.Update)
-		admin.DELETE("/users/:id", userController.Delete)
+		admin.GET("/:id", rbac.RequirePermission("users:read"), policies.Enforce("users.read", userController.PolicyResource), userController.GetByID)
+		admin.GET("/users", rbac.RequirePermission("users:read"), policies.Enforce("users.list", nil), userController.GetAll)
+		admin.PATCH("/users/:id", rbac.RequirePermission("users:update"), stepUp, policies.Enforce("users.update", userController.PolicyResource), userController.Update)
+		admin.DELETE("/users/:id", rbac.RequirePermission("users:delete"), stepUp, policies.Enforce("users.delete", userController.PolicyResource), userController.Delete)
+		admin.POST("/users/:id/logout", rbac.RequirePermission("users:logout"), policies.Enforce("users.logout", userController.PolicyResource), userController.ForceLogout)
+		admin.GET("/users/:id/profile-history", rbac.RequirePermission("users:read"), policies.Enforce("users.read", userController.PolicyResource), userController.ProfileHistory)
+
+		// impersonasi user (token singkat dengan klaim act) dan audit trail-nya
+		admin.POST("/users/:id/impersonate", middleware.RejectAPIKeys(), rbac.RequirePermission("users:impersonate"), stepUp, policies.Enforce("users.impersonate", userController.PolicyResource), impersonationController.Start)
+		admin.GET("/impersonations", rbac.RequirePermission("impersonations:audit"), impersonationController.GetAll)
+		admin.GET("/impersonations/:id/requests", rbac.RequirePermission("impersonations:audit"), impersonationController.GetRequests)
+		admin.DELETE("/impersonations/:id", rbac.RequirePermission("users:impersonate"), impersonationController.End)
+
+		// undangan registrasi admin
+		admin.POST("/invites", rbac.RequirePermission("invites:manage"), inviteController.Create)
+		admin.GET("/invites", rbac.RequirePermission("invites:manage"), inviteController.GetAll)
+		admin.DELETE("/invites/:id", rbac.RequirePermission("invites:manage"), inviteController.Revoke)
+
+		// role dan permission
+		admin.GET("/roles", rbac.RequirePermission("roles:manage"), roleController.GetAll)
+		admin.POST("/roles", rbac.RequirePermission("roles:manage"), roleController.Create)
+		admin.PUT("/roles/:id/permissions", rbac.RequirePermission("roles:manage"), roleController.SetPermissions)
+		admin.GET("/permissions", rbac.RequirePermission("roles:manage"), roleController.GetPermissions)
+
+		// API key pribadi admin
+		admin.POST("/api-keys", middleware.RejectAPIKeys(), apiKeyController.CreatePersonal)
+		admin.GET("/api-keys", middleware.RejectAPIKeys(), apiKeyController.ListPersonal)
+		admin.DELETE("/api-keys/:key_id", middleware.RejectAPIKeys(), apiKeyController.RevokePersonal)
+
+		// service account dan API key-nya
+		serviceAccounts := admin.Group("/service-accounts", middleware.RejectAPIKeys(), rbac.RequirePermission("service_accounts:manage"))
+		serviceAccounts.POST("", apiKeyController.CreateServiceAccount)
+		serviceAccounts.GET("", apiKeyController.GetAllServiceAccounts)
+		serviceAccounts.DELETE("/:id", apiKeyController.DisableServiceAccount)
+		serviceAccounts.POST("/:id/keys", apiKeyController.CreateServiceAccountKey)
+		serviceAccounts.GET("/:id/keys", apiKeyController.ListServiceAccountKeys)
+		serviceAccounts.DELETE("/:id/keys/:key_id", apiKeyController.RevokeServiceAccountKey)
+
+		// client OIDC
+		if oidcController != nil {
+			oauthClients := admin.Group("/oauth-clients", middleware.RejectAPIKeys(), rbac.RequirePermission("oauth_clients:manage"))
+			oauthClients.POST("", oidcController.RegisterClient)
+			oauthClients.GET("", oidcController.GetAllClients)
+			oauthClients.DELETE("/:client_id", oidcController.DisableClient)
+		}
 	}
 }
\ No newline at end of file
@@ -0,0 +1,321 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrAPIKeyNotFound         = errors.New("api key not found")
+	ErrServiceAccountNotFound = errors.New("service account not found")
+	ErrInvalidScope           = errors.New("scope is not a permission of the key owner")
+	ErrAPIKeyTTL              = errors.New("api key expiry must be between 1 and 365 days")
+	ErrAPIKeyName             = errors.New("api key name is required (max 64 characters)")
+	ErrInvalidServiceAccount  = errors.New("service account realm must be admin or user, name must match [a-z0-9_-]{1,64} and role must exist")
+)
+
+// Masa berlaku API key bila tidak ditentukan, dan batas maksimalnya
+const (
+	DefaultAPIKeyTTL = 90 * 24 * time.Hour
+	MaxAPIKeyTTL     = 365 * 24 * time.Hour
+)
+
+// Format key: "lgk_<prefix>_<secret>". Prefix (12 hex) dipakai untuk mencari
+// row, secret 256-bit hanya diketahui client.
+const apiKeyPrefix = "lgk_"
+
+type APIKeyService struct {
+	Repo *repository.APIKeyRepository
+	// akun pemilik personal key per realm
+	Accounts map[string]repository.AuthRepository
+	Roles    *RoleService
+}
+
+func NewAPIKeyService(repo *repository.APIKeyRepository, adminRepo, userRepo repository.AuthRepository, roles *RoleService) *APIKeyService {
+	return &APIKeyService{
+		Repo: repo,
+		Accounts: map[string]repository.AuthRepository{
+			middleware.RealmAdmin: adminRepo,
+			middleware.RealmUser:  userRepo,
+		},
+		Roles: roles,
+	}
+}
+
+// ----------------------------- PERSONAL KEY ------------------------------
+
+// CreatePersonal membuat API key untuk admin/user yang sedang login. Key
+// hanya dikembalikan sekali di sini.
+func (s *APIKeyService) CreatePersonal(realm string, userID int, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	account, err := s.Accounts[realm].FindByID(strconv.Itoa(userID))
+	if err != nil || account == nil {
+		return nil, "", ErrUserNotFound
+	}
+	return s.create(realm, models.OwnerAccount, userID, account.Role, name, scopes, ttl)
+}
+
+func (s *APIKeyService) ListPersonal(realm string, userID int) ([]models.APIKey, error) {
+	return s.Repo.FindAPIKeysByOwner(realm, models.OwnerAccount, userID)
+}
+
+// RevokePersonal hanya mencabut key milik user itu sendiri
+func (s *APIKeyService) RevokePersonal(realm string, userID, keyID int) error {
+	key, err := s.Repo.FindAPIKeyByID(keyID)
+	if err != nil || key == nil || key.Realm != realm || key.OwnerType != models.OwnerAccount || key.OwnerID != userID {
+		return ErrAPIKeyNotFound
+	}
+	return s.revoke(keyID)
+}
+
+// RevokeAccountKeys dipanggil saat akun dihapus
+func (s *APIKeyService) RevokeAccountKeys(realm string, userID int) error {
+	return s.Repo.RevokeAPIKeysByOwner(realm, models.OwnerAccount, userID)
+}
+
+// ---------------------------- SERVICE ACCOUNT ----------------------------
+
+func (s *APIKeyService) CreateServiceAccount(createdBy int, realm, name, description, role, organization string) (*models.ServiceAccount, error) {
+	if (realm != middleware.RealmAdmin && realm != middleware.RealmUser) || !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidServiceAccount
+	}
+	ok, err := s.Roles.RoleExists(realm, role)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidServiceAccount
+	}
+
+	id, err := s.Repo.CreateServiceAccount(models.ServiceAccount{
+		Realm:        realm,
+		Name:         name,
+		Description:  description,
+		Role:         role,
+		Organization: organization,
+		CreatedBy:    createdBy,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindServiceAccountByID(id)
+}
+
+func (s *APIKeyService) GetAllServiceAccounts() ([]models.ServiceAccount, error) {
+	return s.Repo.GetAllServiceAccounts()
+}
+
+func (s *APIKeyService) DisableServiceAccount(id int) error {
+	ok, err := s.Repo.DisableServiceAccount(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrServiceAccountNotFound
+	}
+	return nil
+}
+
+func (s *APIKeyService) CreateForServiceAccount(serviceAccountID int, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	sa, err := s.Repo.FindServiceAccountByID(serviceAccountID)
+	if err != nil || sa == nil || sa.DisabledAt != nil {
+		return nil, "", ErrServiceAccountNotFound
+	}
+	return s.create(sa.Realm, models.OwnerServiceAccount, sa.ID, sa.Role, name, scopes, ttl)
+}
+
+func (s *APIKeyService) ListForServiceAccount(serviceAccountID int) ([]models.APIKey, error) {
+	sa, err := s.Repo.FindServiceAccountByID(serviceAccountID)
+	if err != nil || sa == nil {
+		return nil, ErrServiceAccountNotFound
+	}
+	return s.Repo.FindAPIKeysByOwner(sa.Realm, models.OwnerServiceAccount, sa.ID)
+}
+
+func (s *APIKeyService) RevokeServiceAccountKey(serviceAccountID, keyID int) error {
+	key, err := s.Repo.FindAPIKeyByID(keyID)
+	if err != nil || key == nil || key.OwnerType != models.OwnerServiceAccount || key.OwnerID != serviceAccountID {
+		return ErrAPIKeyNotFound
+	}
+	return s.revoke(keyID)
+}
+
+// ------------------------------ AUTHENTICATE -----------------------------
+
+// AuthenticateAPIKey dipanggil AuthMiddleware untuk header X-API-Key. Role,
+// email dan organisasi diambil dari pemilik saat ini, bukan saat key dibuat.
+func (s *APIKeyService) AuthenticateAPIKey(key string) (*middleware.APIKeyPrincipal, error) {
+	prefix, ok := parseAPIKey(key)
+	if !ok {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+
+	stored, err := s.Repo.FindAPIKeyByPrefix(prefix)
+	if err != nil || stored == nil {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+	if subtle.ConstantTimeCompare([]byte(stored.KeyHash), []byte(hashAPIKey(key))) != 1 {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+	if stored.RevokedAt != nil || time.Now().After(stored.ExpiresAt) {
+		return nil, middleware.ErrInvalidAPIKey
+	}
+
+	principal := &middleware.APIKeyPrincipal{
+		KeyID:  stored.ID,
+		Realm:  stored.Realm,
+		Scopes: stored.Scopes,
+	}
+
+	switch stored.OwnerType {
+	case models.OwnerAccount:
+		repo, ok := s.Accounts[stored.Realm]
+		if !ok {
+			return nil, middleware.ErrInvalidAPIKey
+		}
+		account, err := repo.FindByID(strconv.Itoa(stored.OwnerID))
+		if err != nil || account == nil {
+			return nil, middleware.ErrInvalidAPIKey
+		}
+		principal.Subject = fmt.Sprintf("%s:%d", stored.Realm, account.ID)
+		principal.UserID = account.ID
+		principal.Email = account.Email
+		principal.Role = account.Role
+		principal.Organization = account.Organization
+
+	case models.OwnerServiceAccount:
+		sa, err := s.Repo.FindServiceAccountByID(stored.OwnerID)
+		if err != nil || sa == nil || sa.DisabledAt != nil {
+			return nil, middleware.ErrInvalidAPIKey
+		}
+		principal.Subject = fmt.Sprintf("%s:service_account:%d", stored.Realm, sa.ID)
+		principal.ServiceAccountID = sa.ID
+		principal.Role = sa.Role
+		principal.Organization = sa.Organization
+
+	default:
+		return nil, middleware.ErrInvalidAPIKey
+	}
+
+	if err := s.Repo.TouchAPIKey(stored.ID); err != nil {
+		log.Println("api key last_used_at update failed:", err)
+	}
+	return principal, nil
+}
+
+// -------------------------------- HELPER ---------------------------------
+
+func (s *APIKeyService) create(realm, ownerType string, ownerID int, role, name string, scopes []string, ttl time.Duration) (*models.APIKey, string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return nil, "", ErrAPIKeyName
+	}
+
+	if ttl == 0 {
+		ttl = DefaultAPIKeyTTL
+	}
+	if ttl < 24*time.Hour || ttl > MaxAPIKeyTTL {
+		return nil, "", ErrAPIKeyTTL
+	}
+
+	// scope harus bagian dari permission role pemilik saat ini
+	allowed, err := s.Roles.Repo.PermissionsForRole(realm, role)
+	if err != nil {
+		return nil, "", err
+	}
+	allowedSet := map[string]bool{}
+	for _, p := range allowed {
+		allowedSet[p] = true
+	}
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, scope := range scopes {
+		if !allowedSet[scope] {
+			return nil, "", ErrInvalidScope
+		}
+		if !seen[scope] {
+			seen[scope] = true
+			unique = append(unique, scope)
+		}
+	}
+
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		return nil, "", err
+	}
+
+	apiKey := models.APIKey{
+		Prefix:    prefix,
+		KeyHash:   hashAPIKey(key),
+		Name:      name,
+		Realm:     realm,
+		OwnerType: ownerType,
+		OwnerID:   ownerID,
+		Scopes:    unique,
+		ExpiresAt: time.Now().Add(ttl),
+		CreatedAt: time.Now(),
+	}
+
+	id, err := s.Repo.CreateAPIKey(apiKey)
+	if err != nil {
+		return nil, "", err
+	}
+	apiKey.ID = id
+
+	return &apiKey, key, nil
+}
+
+func (s *APIKeyService) revoke(keyID int) error {
+	ok, err := s.Repo.RevokeAPIKey(keyID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrAPIKeyNotFound
+	}
+	return nil
+}
+
+func newAPIKey() (string, string, error) {
+	p := make([]byte, 6)
+	if _, err := rand.Read(p); err != nil {
+		return "", "", err
+	}
+	secret := make([]byte, 32)
+	if _, err := rand.Read(secret); err != nil {
+		return "", "", err
+	}
+
+	prefix := hex.EncodeToString(p)
+	return apiKeyPrefix + prefix + "_" + base64.RawURLEncoding.EncodeToString(secret), prefix, nil
+}
+
+func parseAPIKey(key string) (string, bool) {
+	rest, ok := strings.CutPrefix(key, apiKeyPrefix)
+	if !ok {
+		return "", false
+	}
+	prefix, secret, ok := strings.Cut(rest, "_")
+	if !ok || len(prefix) != 12 || secret == "" {
+		return "", false
+	}
+	return prefix, true
+}
+
+// Key 256-bit acak, jadi SHA-256 biasa sudah cukup
+func hashAPIKey(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,129 @@
+package services
+
+import (
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var apiKeyRowColumns = []string{"id", "prefix", "key_hash", "name", "realm", "owner_type", "owner_id", "scopes", "expires_at", "last_used_at", "created_at", "revoked_at"}
+
+func TestParseAPIKey(t *testing.T) {
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	tests := []struct {
+		name       string
+		key        string
+		wantPrefix string
+		wantOK     bool
+	}{
+		{name: "generated key", key: key, wantPrefix: prefix, wantOK: true},
+		{name: "missing lgk_ prefix", key: strings.TrimPrefix(key, apiKeyPrefix), wantOK: false},
+		{name: "short prefix", key: "lgk_abc_secret", wantOK: false},
+		{name: "missing secret", key: "lgk_0123456789ab_", wantOK: false},
+		{name: "missing separator", key: "lgk_0123456789absecret", wantOK: false},
+		{name: "bearer token", key: "eyJhbGciOiJIUzI1NiJ9.e30.sig", wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseAPIKey(tt.key)
+			if ok != tt.wantOK || got != tt.wantPrefix {
+				t.Fatalf("parseAPIKey = (%q, %v), want (%q, %v)", got, ok, tt.wantPrefix, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestHashAPIKeyIsStableAndKeyDependent(t *testing.T) {
+	a, _, _ := newAPIKey()
+	b, _, _ := newAPIKey()
+
+	if hashAPIKey(a) != hashAPIKey(a) {
+		t.Fatal("hash of the same key differs")
+	}
+	if hashAPIKey(a) == hashAPIKey(b) {
+		t.Fatal("two keys share a hash")
+	}
+	if strings.Contains(hashAPIKey(a), a) {
+		t.Fatal("hash contains the key")
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	key, prefix, err := newAPIKey()
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherSecret := apiKeyPrefix + prefix + "_not-the-secret"
+
+	now := time.Now()
+	past := now.Add(-time.Hour)
+
+	tests := []struct {
+		name      string
+		key       string
+		found     bool
+		expiresAt time.Time
+		revokedAt *time.Time
+		wantErr   error
+	}{
+		{name: "valid key", key: key, found: true, expiresAt: now.Add(time.Hour)},
+		{name: "malformed key", key: "not-an-api-key", wantErr: middleware.ErrInvalidAPIKey},
+		{name: "unknown prefix", key: key, wantErr: middleware.ErrInvalidAPIKey},
+		{name: "wrong secret for prefix", key: otherSecret, found: true, expiresAt: now.Add(time.Hour), wantErr: middleware.ErrInvalidAPIKey},
+		{name: "expired", key: key, found: true, expiresAt: past, wantErr: middleware.ErrInvalidAPIKey},
+		{name: "revoked", key: key, found: true, expiresAt: now.Add(time.Hour), revokedAt: &past, wantErr: middleware.ErrInvalidAPIKey},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+
+			users := newMemoryAuthRepository()
+			if err := users.Create(models.BaseUser{GoogleUID: "uid-1", Email: "user@example.com", Role: "user"}); err != nil {
+				t.Fatal(err)
+			}
+			s := NewAPIKeyService(repository.NewAPIKeyRepository(db), newMemoryAuthRepository(), users, nil)
+
+			if _, ok := parseAPIKey(tt.key); ok {
+				query := mock.ExpectQuery(`SELECT .+ FROM api_keys WHERE prefix = \?`).WithArgs(prefix)
+				rows := sqlmock.NewRows(apiKeyRowColumns)
+				if tt.found {
+					rows.AddRow(7, prefix, hashAPIKey(key), "ci", middleware.RealmUser, models.OwnerAccount, 1, "users:read profile:read", tt.expiresAt, nil, past, tt.revokedAt)
+				}
+				query.WillReturnRows(rows)
+			}
+			if tt.wantErr == nil {
+				mock.ExpectExec(`UPDATE api_keys SET last_used_at = NOW\(\)`).WithArgs(7).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			principal, err := s.AuthenticateAPIKey(tt.key)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthenticateAPIKey error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil {
+				if principal.UserID != 1 || principal.Subject != "user:1" || principal.Role != "user" ||
+					strings.Join(principal.Scopes, " ") != "users:read profile:read" {
+					t.Fatalf("principal = %+v", principal)
+				}
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
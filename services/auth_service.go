@@ -2,48 +2,74 @@ package services
 
 import (
 	"context"
+	"crypto/subtle"
 	"encoding/base64"
 	"errors"
+	"fmt"
+	"log"
 	"strconv"
+	"strings"
 	"time"
 
-	firebase "firebase.google.com/go/auth"
-	"github.com/golang-jwt/jwt/v5"
+	firebase "firebase.google.com/go/v4/auth"
 	"github.com/muhammadfarrasfajri/login-google/middleware"
 	"github.com/muhammadfarrasfajri/login-google/models"
 	"github.com/muhammadfarrasfajri/login-google/repository"
 )
 
 var (
-	ErrInvalidToken      = errors.New("invalid or expired token")
-	ErrUserNotRegistered = errors.New("user not registered, please register first")
+	ErrInvalidToken       = errors.New("invalid or expired token")
+	ErrUserNotRegistered  = errors.New("user not registered, please register first")
+	ErrSessionNotFound    = errors.New("session not found")
+	ErrSessionExpired     = errors.New("session expired, please login again")
+	ErrRefreshTokenReused = errors.New("refresh token reuse detected, please login again")
 )
 
+// Jenis security event
+const EventRefreshTokenReuse = "refresh_token_reuse"
+
+// Masa berlaku refresh token (dan session)
+const RefreshTokenTTL = 7 * 24 * time.Hour
+
 type AuthService struct {
-	Repo     repository.AuthRepository
-	FirebaseAuth *firebase.Client
-	JWTSecret    *middleware.JWTManager
+	Repo      repository.AuthRepository
+	Verifier  IDTokenVerifier
+	JWTSecret *middleware.JWTManager
+	// Invites dan MFA hanya diisi untuk realm admin
+	Invites *InviteService
+	MFA     *MFAService
+	// RateLimit opsional, membatasi login/register per Google UID dan
+	// refresh per family (limit per IP dipasang di router)
+	RateLimit *middleware.RateLimiter
+	// ProfileSync opsional, nama/email/foto diperbarui dari ID token saat
+	// login
+	ProfileSync *ProfileSyncRules
 }
 
-func NewAuthService(repository repository.AuthRepository, firebaseAuth *firebase.Client, jwtsecret *middleware.JWTManager) *AuthService{
+func NewAuthService(repository repository.AuthRepository, verifier IDTokenVerifier, jwtsecret *middleware.JWTManager) *AuthService {
 	return &AuthService{
-		Repo: repository,
-		FirebaseAuth: firebaseAuth,
+		Repo:      repository,
+		Verifier:  verifier,
 		JWTSecret: jwtsecret,
 	}
 }
 
 // --------------------------- REGISTER -----------------------------------
 
-func (s *AuthService) Register(idToken string, customName string) (*models.BaseUser, error) {
+// Register membuat akun baru. Bila AuthService punya Invites (realm admin),
+// registrasi wajib memakai kode undangan yang cocok dengan akun Google-nya.
+func (s *AuthService) Register(idToken string, customName string, inviteCode string) (*models.BaseUser, error) {
 	ctx := context.Background()
 
-	// 1. Verifikasi Firebase ID Token
-	token, err := s.FirebaseAuth.VerifyIDToken(ctx, idToken)
+	// 1. Verifikasi ID Token
+	token, err := s.verifyIDToken(ctx, idToken)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, err
 	}
 	googleUID := token.UID
+	if err := s.limit("register", middleware.RateKeyGoogleUID, googleUID); err != nil {
+		return nil, err
+	}
 
 	email, _ := token.Claims["email"].(string)
 	googlePicture, _ := token.Claims["picture"].(string)
@@ -55,41 +81,99 @@ func (s *AuthService) Register(idToken string, customName string) (*models.BaseU
 	}
 
 	// 3. Tentukan nama
+	googleName, _ := token.Claims["name"].(string)
 	name := customName
 	if name == "" {
-		if n, ok := token.Claims["name"].(string); ok {
-			name = n
+		name = googleName
+	}
+
+	// 4. Pakai undangan (khusus admin)
+	var invite *models.AdminInvite
+	if s.Invites != nil {
+		emailVerified, _ := token.Claims["email_verified"].(bool)
+		hostedDomain, _ := token.Claims["hd"].(string)
+		invite, err = s.Invites.Claim(inviteCode, googleUID, email, emailVerified, hostedDomain)
+		if err != nil {
+			return nil, err
 		}
 	}
-	
+
 	// 5. Simpan user
 	newUser := models.BaseUser{
-		GoogleUID: googleUID,
-		Name:      name,
-		Email:     email,
-		GooglePicture:   googlePicture,
+		GoogleUID:     googleUID,
+		Name:          name,
+		Email:         email,
+		GooglePicture: googlePicture,
+		GoogleName:    googleName,
+		GoogleEmail:   email,
 	}
 
 	err = s.Repo.Create(newUser)
 	if err != nil {
+		if invite != nil {
+			_ = s.Invites.Release(invite, googleUID)
+		}
 		return nil, err
 	}
 
 	return &newUser, nil
 }
 
+// ------------------------ AUTHENTICATE ---------------------------------
+
+// Authenticate memverifikasi ID token Google dan mengembalikan akun yang
+// terdaftar tanpa membuat session. Dipakai OIDC provider sebagai login
+// upstream; login tetap dicatat di login history.
+func (s *AuthService) Authenticate(idToken string, deviceInfo string, ip string) (*models.BaseUser, *firebase.Token, error) {
+	token, err := s.verifyIDToken(context.Background(), idToken)
+	if err != nil {
+		return nil, nil, err
+	}
+	// bucket Google UID sama dengan Login, jadi OIDC bukan jalan pintas
+	if err := s.limit("login", middleware.RateKeyGoogleUID, token.UID); err != nil {
+		return nil, nil, err
+	}
+
+	user, err := s.Repo.FindByGoogleUID(token.UID)
+	if err != nil || user == nil {
+		return nil, nil, ErrUserNotRegistered
+	}
+
+	s.syncProfileOnLogin(user, token)
+
+	// login tanpa session tidak punya langkah TOTP, jadi akun yang wajib MFA
+	// ditolak
+	if s.MFA != nil {
+		required, _, err := s.MFA.Required(user.ID)
+		if err != nil {
+			return nil, nil, err
+		}
+		if required {
+			return nil, nil, ErrMFARequired
+		}
+	}
+
+	if err := s.Repo.SaveLoginHistory(user.ID, deviceInfo, ip); err != nil {
+		return nil, nil, err
+	}
+	return user, token, nil
+}
+
 // -------------------------- LOGIN ----------------------------------------
 
 func (s *AuthService) Login(idToken string, deviceInfo string, ip string) (map[string]interface{}, error) {
 	ctx := context.Background()
 
-	// 1. Verifikasi Firebase Token
-	token, err := s.FirebaseAuth.VerifyIDToken(ctx, idToken)
+	// 1. Verifikasi ID Token
+	token, err := s.verifyIDToken(ctx, idToken)
 	if err != nil {
-		return nil, ErrInvalidToken
+		return nil, err
 	}
 
 	googleUID := token.UID
+	if err := s.limit("login", middleware.RateKeyGoogleUID, googleUID); err != nil {
+		return nil, err
+	}
 
 	// 2. Cek user di DB
 	user, err := s.Repo.FindByGoogleUID(googleUID)
@@ -97,171 +181,612 @@ func (s *AuthService) Login(idToken string, deviceInfo string, ip string) (map[s
 		return nil, ErrUserNotRegistered
 	}
 
-	// 3. Cek user login
-	u, err := s.Repo.FindRefreshToken(user.ID)
-	if err != nil || u == nil{
-		// 4. Update status login
-		if err := s.Repo.UpdateLoginStatus(user.ID, 1); err != nil {
-			return nil, err
-		}
-	
-		// 5. Simpan aktivitas login
-		err = s.Repo.SaveLoginHistory(user.ID, deviceInfo, ip)
-		if err != nil {
-			return nil, err
-		}
-	
-		//6. Generate Access token
-		accessToken, err := s.JWTSecret.GenerateAccessToken(user.ID, user.Email, user.Role)
+	// 3. Samakan nama, email dan foto dengan profil Google terbaru
+	s.syncProfileOnLogin(user, token)
+
+	// 4. Akun dengan TOTP (atau MFA wajib) mendapat mfa_token dulu,
+	// session baru dibuat setelah kode diverifikasi
+	if s.MFA != nil {
+		challenge, err := s.MFA.Challenge(user, deviceInfo)
 		if err != nil {
 			return nil, err
 		}
-	
-		//7. Generate Referesh Token
-		refreshToken, err := s.JWTSecret.GenerateRefreshToken(user.ID)
-		if err != nil {
-			return nil, err
+		if challenge != nil {
+			return challenge, nil
 		}
-	
-		//8. encryp Refresh Token
-		encryptedRefresh, err := middleware.Encrypt(refreshToken)
+	}
+
+	return s.startSession(user, deviceInfo, ip)
+}
+
+// startSession mencatat login dan membuat session beserta token-nya
+func (s *AuthService) startSession(user *models.BaseUser, deviceInfo string, ip string) (map[string]interface{}, error) {
+	// 1. Update status login
+	if err := s.Repo.UpdateLoginStatus(user.ID, 1); err != nil {
+		return nil, err
+	}
+
+	// 2. Simpan aktivitas login
+	if err := s.Repo.SaveLoginHistory(user.ID, deviceInfo, ip); err != nil {
+		return nil, err
+	}
+
+	// 3. Bersihkan session lama yang sudah expired
+	if err := s.Repo.DeleteExpiredSessions(user.ID); err != nil {
+		return nil, err
+	}
+
+	// 4. Buat session baru untuk device ini
+	expiresAt := time.Now().Add(RefreshTokenTTL)
+	sessionID, err := s.Repo.CreateSession(models.Session{
+		AdminOrUserID: user.ID,
+		DeviceInfo:    deviceInfo,
+		IPAddress:     ip,
+		ExpiresAt:     expiresAt,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// 5. Generate access + refresh token untuk session ini
+	accessToken, refreshToken, err := s.issueTokens(user, sessionID)
+	if err != nil {
+		return nil, err
+	}
+
+	// 6. Simpan hash refresh token ke session
+	tokenHash := s.JWTSecret.HashRefreshToken(refreshToken)
+	if err := s.Repo.UpdateSessionRefreshToken(sessionID, tokenHash, expiresAt); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"message":       "login success",
+		"session_id":    sessionID,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}, nil
+}
+
+// -------------------------- MFA LOGIN ----------------------------
+
+// CompleteMFALogin menukar mfa_token dan kode TOTP (atau recovery code)
+// dengan session baru. Bila login ini sekaligus enrollment, recovery code
+// ikut dikembalikan.
+func (s *AuthService) CompleteMFALogin(mfaToken, code, ip string) (map[string]interface{}, error) {
+	if s.MFA == nil {
+		return nil, ErrInvalidMFAToken
+	}
+	claims, err := s.MFA.ParseChallenge(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := s.Repo.FindByID(strconv.Itoa(claims.UserID))
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	recoveryCodes, err := s.MFA.CompleteChallenge(claims, code)
+	if err != nil {
+		return nil, err
+	}
+
+	result, err := s.startSession(user, claims.DeviceInfo, ip)
+	if err != nil {
+		return nil, err
+	}
+	if recoveryCodes != nil {
+		result["recovery_codes"] = recoveryCodes
+	}
+	return result, nil
+}
+
+// BeginMFAEnrollment dipakai saat login bila MFA wajib dan admin belum punya
+// TOTP
+func (s *AuthService) BeginMFAEnrollment(mfaToken string) (*TOTPEnrollment, error) {
+	if s.MFA == nil {
+		return nil, ErrInvalidMFAToken
+	}
+	claims, err := s.MFA.ParseChallenge(mfaToken)
+	if err != nil {
+		return nil, err
+	}
+	if !claims.Enroll {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	user, err := s.Repo.FindByID(strconv.Itoa(claims.UserID))
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.MFA.BeginEnrollment(user.ID, user.Email)
+}
+
+// -------------------------- REFRESH TOKEN ------------------------
+
+func (s *AuthService) RefreshToken(refreshToken string, ip string) (map[string]interface{}, error) {
+	// refresh token format lama (JWT terenkripsi) tidak punya pemisah "."
+	if !strings.Contains(refreshToken, ".") {
+		return s.refreshLegacyToken(refreshToken, ip)
+	}
+
+	sessionID, ok := s.JWTSecret.ParseRefreshToken(refreshToken)
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+
+	// cari session (family) milik refresh token ini
+	session, err := s.Repo.FindSessionByID(sessionID)
+	if err != nil || session == nil {
+		return nil, ErrSessionNotFound
+	}
+
+	// family sudah di-revoke karena reuse sebelumnya
+	if session.RevokedAt != nil {
+		return nil, ErrRefreshTokenReused
+	}
+
+	tokenHash := s.JWTSecret.HashRefreshToken(refreshToken)
+	if !middleware.EqualHash(tokenHash, session.RefreshTokenHash) {
+		// token dari generation lama dipakai lagi: kemungkinan token dicuri,
+		// revoke seluruh family dan minta login ulang
+		rotated, err := s.Repo.FindRotatedTokenHashes(session.ID)
 		if err != nil {
 			return nil, err
 		}
-	
-		//9. encode base64 
-		encodedToken := base64.URLEncoding.EncodeToString([]byte(encryptedRefresh))
-	
-		//10. time exp refresh token
-		expiresAt := time.Now().Add(7 * 24 * time.Hour)
-	
-		//11. Send refresh token to database
-		if err := s.Repo.RefreshToken(user.ID, encodedToken, expiresAt); err != nil {
-			return nil, err
+		for _, h := range rotated {
+			if middleware.EqualHash(tokenHash, h) {
+				return nil, s.revokeFamily(session, ip)
+			}
 		}
-	
-		return map[string]interface{}{
-			"message": "login success",
-			"access_token":   accessToken,
-			"refresh_token": encodedToken,
-		}, nil
-	}
-	// 5. Simpan aktivitas login
-	err = s.Repo.SaveLoginHistory(user.ID, deviceInfo, ip)
+		return nil, ErrInvalidToken
+	}
+
+	return s.rotate(session, tokenHash, ip)
+}
+
+// refreshLegacyToken menerima refresh token lama (JWT terenkripsi + base64)
+// yang masih tersimpan di kolom refresh_token, lalu menggantinya dengan
+// refresh token opaque.
+func (s *AuthService) refreshLegacyToken(encodedToken string, ip string) (map[string]interface{}, error) {
+	plaintext, err := decodeLegacyRefreshToken(encodedToken)
 	if err != nil {
-		return nil, err
+		return nil, ErrInvalidToken
+	}
+
+	// parsing token (HS256 dengan REFRESH_SECRET, exp wajib)
+	claims, err := s.JWTSecret.ParseLegacyRefreshToken(plaintext)
+	if err != nil {
+		return nil, ErrInvalidToken
 	}
-	//6. Generate Access token
-	accessToken, err := s.JWTSecret.GenerateAccessToken(user.ID, user.Email, user.Role)
+
+	session, err := s.findLegacySession(claims, plaintext)
 	if err != nil {
 		return nil, err
 	}
-	
-	//7. Generate Referesh Token
-	refreshToken, err := s.JWTSecret.GenerateRefreshToken(user.ID)
+
+	if session.RevokedAt != nil {
+		return nil, ErrRefreshTokenReused
+	}
+
+	// session sudah pindah ke generation/format baru, berarti token ini
+	// sudah pernah dirotasi
+	generation := session.Generation
+	if claims.Generation != nil {
+		generation = *claims.Generation
+	}
+	if generation < session.Generation || session.LegacyRefreshToken == "" {
+		return nil, s.revokeFamily(session, ip)
+	}
+
+	if !legacyTokenMatches(session.LegacyRefreshToken, plaintext) {
+		return nil, errors.New("refresh token not match")
+	}
+
+	return s.rotate(session, s.JWTSecret.HashRefreshToken(encodedToken), ip)
+}
+
+// findLegacySession mencari session dari claim sid. Refresh token dari
+// sebelum ada session tidak punya sid, jadi dicocokkan dengan session user.
+func (s *AuthService) findLegacySession(claims *middleware.LegacyRefreshClaims, plaintext string) (*models.Session, error) {
+	userID := claims.UserID
+	if claims.SessionID != nil {
+		session, err := s.Repo.FindSessionByID(*claims.SessionID)
+		if err != nil || session == nil || session.AdminOrUserID != userID {
+			return nil, ErrSessionNotFound
+		}
+		return session, nil
+	}
+
+	sessions, err := s.Repo.FindSessionsByUserID(userID)
 	if err != nil {
 		return nil, err
 	}
-	
-	//8. encryp Refresh Token
-	encryptedRefresh, err := middleware.Encrypt(refreshToken)
+	for i := range sessions {
+		if legacyTokenMatches(sessions[i].LegacyRefreshToken, plaintext) {
+			return &sessions[i], nil
+		}
+	}
+	return nil, ErrSessionNotFound
+}
+
+// rotate mengganti refresh token session dengan token baru dan menaikkan
+// generation family.
+func (s *AuthService) rotate(session *models.Session, oldTokenHash string, ip string) (map[string]interface{}, error) {
+	// refresh token sudah terbukti valid, baru dihitung per family
+	if err := s.limit("refresh", middleware.RateKeyRefreshFamily, strconv.Itoa(session.ID)); err != nil {
+		return nil, err
+	}
+
+	if time.Now().After(session.ExpiresAt) {
+		_ = s.Repo.DeleteSession(session.AdminOrUserID, session.ID)
+		return nil, ErrSessionExpired
+	}
+
+	// ambil user dari db
+	user, err := s.Repo.FindByID(strconv.Itoa(session.AdminOrUserID))
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+
+	// access token lama dari session ini sudah digantikan, cabut juga
+	if err := s.revokeAccessToken(session); err != nil {
+		return nil, err
+	}
+
+	// generate token baru (access + refresh) untuk session yang sama
+	accessToken, refreshToken, err := s.issueTokens(user, session.ID)
 	if err != nil {
 		return nil, err
 	}
-	
-	//9. encode base64 
-	encodedToken := base64.URLEncoding.EncodeToString([]byte(encryptedRefresh))
-	
-	//10. time exp refresh token
-	expiresAt := time.Now().Add(7 * 24 * time.Hour)
-	
-	//11. Send refresh token to database
-	if err := s.Repo.UpdateRefreshToken(user.ID, encodedToken, expiresAt); err != nil {
+
+	expiresAt := time.Now().Add(RefreshTokenTTL)
+	newTokenHash := s.JWTSecret.HashRefreshToken(refreshToken)
+
+	rotated, err := s.Repo.RotateSessionRefreshToken(session.ID, session.Generation, oldTokenHash, newTokenHash, expiresAt)
+	if err != nil {
 		return nil, err
 	}
+	// token yang sama sudah lebih dulu dirotasi oleh request lain
+	if !rotated {
+		return nil, s.revokeFamily(session, ip)
+	}
+
 	return map[string]interface{}{
-		"message": "login success",
-		"access_token":   accessToken,
-		"refresh_token": encodedToken,
-		}, nil
+		"session_id":    session.ID,
+		"access_token":  accessToken,
+		"refresh_token": refreshToken,
+	}, nil
 }
-	
-	// -------------------------- REFRESH TOKEN ------------------------
-func (s *AuthService) RefreshToken(encryptedToken string) (map[string]interface{}, error) {
-	
-	
-		decodedBytes, err := base64.URLEncoding.DecodeString(encryptedToken)
-		
-		if err != nil {
-			return nil, ErrInvalidToken
+
+// revokeFamily mencabut semua refresh token dalam satu session dan mencatat
+// security event.
+func (s *AuthService) revokeFamily(session *models.Session, ip string) error {
+	if err := s.Repo.RevokeSession(session.ID); err != nil {
+		return err
+	}
+	if err := s.revokeAccessToken(session); err != nil {
+		return err
+	}
+
+	detail := fmt.Sprintf("session %d: rotated refresh token reused (current generation %d)", session.ID, session.Generation)
+	if err := s.Repo.SaveSecurityEvent(session.AdminOrUserID, EventRefreshTokenReuse, detail, ip); err != nil {
+		log.Println("failed to save security event:", err)
+	}
+
+	return ErrRefreshTokenReused
+}
+
+// issueTokens membuat access token dan refresh token opaque untuk session.
+// jti access token dicatat di session supaya bisa dicabut saat logout.
+func (s *AuthService) issueTokens(user *models.BaseUser, sessionID int) (string, string, error) {
+	accessToken, jti, err := s.JWTSecret.GenerateAccessToken(user.ID, sessionID, user.Email, user.Role, user.Organization)
+	if err != nil {
+		return "", "", err
+	}
+
+	accessExpiresAt := time.Now().Add(middleware.AccessTokenTTL)
+	if err := s.Repo.SetSessionAccessToken(sessionID, jti, accessExpiresAt); err != nil {
+		return "", "", err
+	}
+
+	refreshToken, err := s.JWTSecret.GenerateRefreshToken(sessionID)
+	if err != nil {
+		return "", "", err
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// decodeLegacyRefreshToken membuka refresh token format lama:
+// base64url(Encrypt(JWT)).
+func decodeLegacyRefreshToken(encodedToken string) (string, error) {
+	decodedBytes, err := base64.URLEncoding.DecodeString(encodedToken)
+	if err != nil {
+		return "", err
+	}
+	return middleware.Decrypt(string(decodedBytes))
+}
+
+// legacyTokenMatches membandingkan isi (JWT) refresh token lama yang tersimpan
+// dengan yang dikirim client dalam constant time.
+func legacyTokenMatches(stored, plaintext string) bool {
+	if stored == "" {
+		return false
+	}
+	storedPlain, err := decodeLegacyRefreshToken(stored)
+	if err != nil {
+		return false
+	}
+	return subtle.ConstantTimeCompare([]byte(storedPlain), []byte(plaintext)) == 1
+}
+
+// verifyIDToken juga menolak token yang sudah di-revoke dan akun yang
+// di-disable di Firebase bila verifier mendukung pengecekan itu
+func (s *AuthService) verifyIDToken(ctx context.Context, idToken string) (*firebase.Token, error) {
+	var token *firebase.Token
+	var err error
+	if v, ok := s.Verifier.(RevocationCheckingVerifier); ok {
+		token, err = v.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	} else {
+		token, err = s.Verifier.VerifyIDToken(ctx, idToken)
+	}
+
+	if errors.Is(err, ErrAccountDisabled) || errors.Is(err, ErrTokenRevoked) {
+		return nil, err
+	}
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
+
+// limit mengambil token rate limit untuk route realm ini, misalnya
+// "admin.login"
+func (s *AuthService) limit(action, kind, value string) error {
+	if s.RateLimit == nil {
+		return nil
+	}
+	return s.RateLimit.Check(s.JWTSecret.Realm+"."+action, kind, value)
+}
+
+// -------------------------- LOGOUT ------------------------
+
+// Logout hanya menutup session yang sedang dipakai. Access token lama tanpa
+// sid (sessionID 0) menutup semua session user.
+func (s *AuthService) Logout(userID, sessionID int) error {
+	if sessionID == 0 {
+		return s.LogoutAll(userID)
+	}
+
+	session, err := s.Repo.FindSessionByID(sessionID)
+	if err != nil || session == nil || session.AdminOrUserID != userID {
+		return ErrSessionNotFound
+	}
+
+	if err := s.revokeAccessToken(session); err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteSession(userID, sessionID); err != nil {
+		return err
+	}
+
+	// status login hanya 0 bila tidak ada session lain yang aktif
+	sessions, err := s.Repo.FindSessionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	if len(sessions) == 0 {
+		return s.Repo.UpdateLoginStatus(userID, 0)
+	}
+	return nil
+}
+
+// LogoutAll menutup semua session user dan mencabut access token-nya
+// (dipakai juga untuk force logout oleh admin).
+func (s *AuthService) LogoutAll(userID int) error {
+	if err := s.RevokeAccessTokens(userID); err != nil {
+		return err
+	}
+	if err := s.Repo.DeleteSessionsByUserID(userID); err != nil {
+		return err
+	}
+	return s.Repo.UpdateLoginStatus(userID, 0)
+}
+
+// LogoutBefore menutup session yang dibuat sebelum t, misalnya karena
+// refresh token Firebase akun ini di-revoke setelah login tersebut.
+// Mengembalikan jumlah session yang ditutup.
+func (s *AuthService) LogoutBefore(userID int, t time.Time) (int, error) {
+	sessions, err := s.Repo.FindSessionsByUserID(userID)
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for _, session := range sessions {
+		if !session.CreatedAt.Before(t) {
+			continue
 		}
-	
-		refreshToken, err := middleware.Decrypt(string(decodedBytes))
-		if err != nil {
-			return nil, ErrInvalidToken
+		if err := s.Logout(userID, session.ID); err != nil {
+			return closed, err
 		}
-	
-		// parsing token
-		token, err := jwt.Parse(refreshToken, func(t *jwt.Token) (interface{}, error) {
-			return s.JWTSecret.RefreshSecret, nil
-		})
-			
-		claims := token.Claims.(jwt.MapClaims)
-		userID := int(claims["user_id"].(float64))
-	
-	
-		tokenCheck, err := s.Repo.FindRefreshToken(userID)
-	
-	
-		if err != nil || tokenCheck == nil {
-			return nil, errors.New("refresh token not found")
+		closed++
+	}
+	return closed, nil
+}
+
+// RevokeAccessTokens mencabut access token semua session user tanpa menutup
+// session-nya, misalnya setelah role berubah: client cukup refresh untuk
+// mendapat access token dengan role baru.
+func (s *AuthService) RevokeAccessTokens(userID int) error {
+	sessions, err := s.Repo.FindSessionsByUserID(userID)
+	if err != nil {
+		return err
+	}
+	for i := range sessions {
+		if err := s.revokeAccessToken(&sessions[i]); err != nil {
+			return err
 		}
-	
-		if encryptedToken != tokenCheck.RefreshToken {
-			return nil, errors.New("refresh token not match")
-		} 
-	
-		// ambil user dari db
-		user, err := s.Repo.FindByID(strconv.Itoa(userID))
-		if err != nil || user == nil {
-			return nil, err
+	}
+	return nil
+}
+
+// revokeAccessToken memasukkan access token dan elevated token terakhir
+// milik session ke denylist sampai token itu expired.
+func (s *AuthService) revokeAccessToken(session *models.Session) error {
+	if s.JWTSecret.Revocations == nil {
+		return nil
+	}
+	if session.AccessJTI != "" && session.AccessExpiresAt != nil {
+		if err := s.JWTSecret.Revocations.Revoke(session.AccessJTI, *session.AccessExpiresAt); err != nil {
+			return err
 		}
-	
-		// generate token baru (access + refresh)
-		accessToken, err := s.JWTSecret.GenerateAccessToken(user.ID, user.Email, user.Role)
+	}
+	// elevated token (step-up) session ini juga ikut dicabut
+	if session.StepUpJTI != "" && session.StepUpExpiresAt != nil {
+		return s.JWTSecret.Revocations.Revoke(session.StepUpJTI, *session.StepUpExpiresAt)
+	}
+	return nil
+}
+
+// -------------------------- INTROSPECTION ------------------------
+
+// ActiveAccessToken memvalidasi access token seperti AuthMiddleware dan juga
+// memeriksa session-nya: access token dari session yang sudah logout atau
+// di-revoke dianggap tidak aktif walaupun belum expired.
+func (s *AuthService) ActiveAccessToken(token string) (*middleware.AccessClaims, error) {
+	claims, err := s.JWTSecret.ParseAccessToken(token)
+	if err != nil {
+		return nil, ErrInvalidToken
+	}
+
+	if s.JWTSecret.Revocations != nil {
+		revoked, err := s.JWTSecret.Revocations.IsRevoked(claims.ID)
 		if err != nil {
 			return nil, err
 		}
-	
-		newRefreshToken, err := s.JWTSecret.GenerateRefreshToken(user.ID)
-		if err != nil {
-			return nil, err
+		if revoked {
+			return nil, ErrInvalidToken
 		}
-		encryptedRefresh, err := middleware.Encrypt(newRefreshToken)
-		if err != nil {
-			return nil, err
+	}
+
+	// token lama tanpa sid tidak terikat ke session
+	if claims.SessionID != 0 {
+		session, err := s.Repo.FindSessionByID(claims.SessionID)
+		if err != nil || session == nil || session.AdminOrUserID != claims.UserID || session.RevokedAt != nil {
+			return nil, ErrInvalidToken
 		}
-		encodedToken := base64.URLEncoding.EncodeToString([]byte(encryptedRefresh))
-	
-		expiresAt := time.Now().Add(7 * 24 * time.Hour)
-	
-		err = s.Repo.UpdateRefreshToken(user.ID, encodedToken, expiresAt)
-		if err != nil {
-			return nil, err
+	}
+	return claims, nil
+}
+
+// ActiveRefreshToken mengembalikan session dan pemilik refresh token yang
+// masih bisa dipakai (generation terbaru, session belum revoked/expired).
+// Hanya refresh token opaque dengan prefix realm ini yang diterima.
+func (s *AuthService) ActiveRefreshToken(token string) (*models.Session, *models.BaseUser, error) {
+	if !strings.HasPrefix(token, s.JWTSecret.Realm+".") {
+		return nil, nil, ErrInvalidToken
+	}
+	sessionID, ok := s.JWTSecret.ParseRefreshToken(token)
+	if !ok {
+		return nil, nil, ErrInvalidToken
+	}
+
+	session, err := s.Repo.FindSessionByID(sessionID)
+	if err != nil || session == nil || session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+		return nil, nil, ErrInvalidToken
+	}
+	if !middleware.EqualHash(s.JWTSecret.HashRefreshToken(token), session.RefreshTokenHash) {
+		return nil, nil, ErrInvalidToken
+	}
+
+	user, err := s.Repo.FindByID(strconv.Itoa(session.AdminOrUserID))
+	if err != nil || user == nil {
+		return nil, nil, ErrInvalidToken
+	}
+	return session, user, nil
+}
+
+// RevokeAccessToken memasukkan satu access token ke denylist sampai expired
+func (s *AuthService) RevokeAccessToken(claims *middleware.AccessClaims) error {
+	if s.JWTSecret.Revocations == nil || claims.ExpiresAt == nil {
+		return nil
+	}
+	return s.JWTSecret.Revocations.Revoke(claims.ID, claims.ExpiresAt.Time)
+}
+
+// -------------------------- PROFILE (ME) ------------------------
+
+var ErrInvalidProfile = errors.New("name must not be empty (max 100 characters)")
+
+// ActiveSession adalah session yang ditampilkan ke pemiliknya, tanpa hash
+// refresh token dan jti
+type ActiveSession struct {
+	ID         int
+	DeviceInfo string
+	IPAddress  string
+	CreatedAt  time.Time
+	LastUsedAt time.Time
+	ExpiresAt  time.Time
+	Current    bool
+}
+
+// Me mengembalikan akun yang sedang login beserta session aktifnya.
+// currentSessionID menandai session yang dipakai request ini.
+func (s *AuthService) Me(userID, currentSessionID int) (*models.BaseUser, []ActiveSession, error) {
+	user, err := s.Repo.FindByID(strconv.Itoa(userID))
+	if err != nil || user == nil {
+		return nil, nil, ErrUserNotFound
+	}
+
+	sessions, err := s.Repo.FindSessionsByUserID(userID)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	active := make([]ActiveSession, 0, len(sessions))
+	for _, session := range sessions {
+		active = append(active, ActiveSession{
+			ID:         session.ID,
+			DeviceInfo: session.DeviceInfo,
+			IPAddress:  session.IPAddress,
+			CreatedAt:  session.CreatedAt,
+			LastUsedAt: session.LastUsedAt,
+			ExpiresAt:  session.ExpiresAt,
+			Current:    session.ID == currentSessionID,
+		})
+	}
+	return user, active, nil
+}
+
+// UpdateProfile hanya mengubah field yang boleh diubah pemilik akun sendiri
+// (nama dan foto profil). Nilai kosong berarti tidak diubah; email dan role
+// tetap hanya bisa diubah lewat endpoint admin.
+func (s *AuthService) UpdateProfile(userID int, name, profilePicture string) (*models.BaseUser, error) {
+	user, err := s.Repo.FindByID(strconv.Itoa(userID))
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+	before := *user
+
+	if name != "" {
+		name = strings.TrimSpace(name)
+		if name == "" || len([]rune(name)) > 100 {
+			return nil, ErrInvalidProfile
 		}
-	
-		return map[string]interface{}{
-			"access_token":  accessToken,
-			"refresh_token": encodedToken,
-		}, nil
+		user.Name = name
+	}
+	if profilePicture != "" {
+		user.ProfilePicture = profilePicture
 	}
 
-func (s *AuthService) Logout(userID int) error {
-	if err := s.Repo.UpdateLoginStatus(userID, 0); err != nil {
-		return err
+	if err := s.Repo.Update(*user); err != nil {
+		return nil, err
 	}
-	if err := s.Repo.DeleteRefreshToken(userID); err != nil {
-		return err
+	if err := s.RecordProfileChanges(user.ID, ProfileSourceSelf, before, *user); err != nil {
+		log.Println(err)
 	}
-	return nil
+	return user, nil
 }
@@ -0,0 +1,74 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+const testRefreshSecret = "test-refresh-secret"
+
+func newTestAuthService(t *testing.T, realm string) (*AuthService, *memoryAuthRepository, *FakeVerifier) {
+	t.Helper()
+
+	keys := middleware.NewSingleKeyring(middleware.NewHMACKey("test", []byte("test-access-secret")))
+	jwtManager := middleware.NewJWTManager(realm, "login-google-"+realm, keys, testRefreshSecret, middleware.NewMemoryRevocationStore())
+	repo := newMemoryAuthRepository()
+	verifier := NewFakeVerifier()
+	return NewAuthService(repo, verifier, jwtManager), repo, verifier
+}
+
+// registerAndLogin mendaftarkan akun Google lewat fake verifier lalu login
+func registerAndLogin(t *testing.T, s *AuthService, verifier *FakeVerifier, uid string) map[string]interface{} {
+	t.Helper()
+
+	verifier.Add("id-token-"+uid, &firebase.Token{
+		UID: uid,
+		Claims: map[string]interface{}{
+			"email":          uid + "@example.com",
+			"email_verified": true,
+			"name":           "User " + uid,
+		},
+	})
+	if _, err := s.Register("id-token-"+uid, "", ""); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	result, err := s.Login("id-token-"+uid, "test-device", "127.0.0.1")
+	if err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	return result
+}
+
+func TestLoginRejectsUnknownIDToken(t *testing.T) {
+	s, _, _ := newTestAuthService(t, middleware.RealmUser)
+
+	if _, err := s.Login("not-registered-in-fake", "test-device", "127.0.0.1"); !errors.Is(err, ErrInvalidToken) {
+		t.Fatalf("Login error = %v, want %v", err, ErrInvalidToken)
+	}
+}
+
+func TestAuthenticateSharesLoginGoogleUIDLimit(t *testing.T) {
+	s, _, verifier := newTestAuthService(t, middleware.RealmUser)
+	registerAndLogin(t, s, verifier, "uid-1")
+	s.RateLimit = middleware.NewRateLimiter(middleware.NewMemoryRateLimitStore(), map[string]map[string]middleware.RateLimit{
+		"user.login": {middleware.RateKeyGoogleUID: {Burst: 1, Per: time.Minute}},
+	})
+
+	if _, _, err := s.Authenticate("id-token-uid-1", "oidc:test", "127.0.0.1"); err != nil {
+		t.Fatalf("Authenticate: %v", err)
+	}
+
+	// login biasa dan OIDC memakai bucket yang sama
+	var limited *middleware.RateLimitedError
+	if _, _, err := s.Authenticate("id-token-uid-1", "oidc:test", "127.0.0.1"); !errors.As(err, &limited) {
+		t.Fatalf("second Authenticate error = %v, want rate limited", err)
+	}
+	if _, err := s.Login("id-token-uid-1", "test-device", "127.0.0.1"); !errors.As(err, &limited) {
+		t.Fatalf("Login after Authenticate error = %v, want rate limited", err)
+	}
+}
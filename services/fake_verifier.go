@@ -0,0 +1,51 @@
+package services
+
+import (
+	"context"
+	"sync"
+
+	firebase "firebase.google.com/go/v4/auth"
+)
+
+// FakeVerifier adalah IDTokenVerifier in-memory untuk test. Hanya ID token
+// yang didaftarkan lewat Add yang dianggap valid.
+type FakeVerifier struct {
+	mu     sync.RWMutex
+	tokens map[string]*firebase.Token
+}
+
+func NewFakeVerifier() *FakeVerifier {
+	return &FakeVerifier{
+		tokens: make(map[string]*firebase.Token),
+	}
+}
+
+// Add mendaftarkan idToken beserta hasil verifikasinya.
+func (f *FakeVerifier) Add(idToken string, token *firebase.Token) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if token.Claims == nil {
+		token.Claims = map[string]interface{}{}
+	}
+	f.tokens[idToken] = token
+}
+
+// Remove membuat idToken tidak valid lagi.
+func (f *FakeVerifier) Remove(idToken string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	delete(f.tokens, idToken)
+}
+
+func (f *FakeVerifier) VerifyIDToken(ctx context.Context, idToken string) (*firebase.Token, error) {
+	f.mu.RLock()
+	defer f.mu.RUnlock()
+
+	token, ok := f.tokens[idToken]
+	if !ok {
+		return nil, ErrInvalidToken
+	}
+	return token, nil
+}
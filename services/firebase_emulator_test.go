@@ -0,0 +1,164 @@
+package services
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	firebaseapp "firebase.google.com/go/v4"
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+// Test di file ini hanya berjalan dengan Firebase Auth emulator, misalnya:
+//
+//	firebase emulators:start --only auth --project demo-login-google
+//	FIREBASE_AUTH_EMULATOR_HOST=localhost:9099 go test ./services -run Emulator
+//
+// FIREBASE_EMULATOR_PROJECT_ID default "demo-login-google".
+
+func newEmulatorClient(t *testing.T) *firebase.Client {
+	t.Helper()
+
+	if os.Getenv("FIREBASE_AUTH_EMULATOR_HOST") == "" {
+		t.Skip("FIREBASE_AUTH_EMULATOR_HOST not set")
+	}
+	projectID := os.Getenv("FIREBASE_EMULATOR_PROJECT_ID")
+	if projectID == "" {
+		projectID = "demo-login-google"
+	}
+
+	app, err := firebaseapp.NewApp(context.Background(), &firebaseapp.Config{ProjectID: projectID})
+	if err != nil {
+		t.Fatalf("firebase.NewApp: %v", err)
+	}
+	client, err := app.Auth(context.Background())
+	if err != nil {
+		t.Fatalf("app.Auth: %v", err)
+	}
+	return client
+}
+
+// newEmulatorAccount membuat akun email/password di emulator lalu login
+// lewat REST API emulator untuk mendapat ID token
+func newEmulatorAccount(t *testing.T, client *firebase.Client) (string, string) {
+	t.Helper()
+	ctx := context.Background()
+
+	email := fmt.Sprintf("user-%d@example.com", time.Now().UnixNano())
+	password := "emulator-password"
+	user, err := client.CreateUser(ctx, (&firebase.UserToCreate{}).Email(email).Password(password).DisplayName("Emulator User"))
+	if err != nil {
+		t.Fatalf("CreateUser: %v", err)
+	}
+	t.Cleanup(func() { _ = client.DeleteUser(context.Background(), user.UID) })
+
+	return user.UID, signInWithPassword(t, email, password)
+}
+
+func signInWithPassword(t *testing.T, email, password string) string {
+	t.Helper()
+
+	body, _ := json.Marshal(map[string]interface{}{
+		"email":             email,
+		"password":          password,
+		"returnSecureToken": true,
+	})
+	url := fmt.Sprintf("http://%s/identitytoolkit.googleapis.com/v1/accounts:signInWithPassword?key=fake-api-key", os.Getenv("FIREBASE_AUTH_EMULATOR_HOST"))
+	resp, err := http.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		t.Fatalf("emulator sign in: %v", err)
+	}
+	defer resp.Body.Close()
+
+	var result struct {
+		IDToken string `json:"idToken"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&result); err != nil || result.IDToken == "" {
+		t.Fatalf("emulator sign in: status %d, %v", resp.StatusCode, err)
+	}
+	return result.IDToken
+}
+
+func TestFirebaseVerifierEmulatorChecksRevocation(t *testing.T) {
+	client := newEmulatorClient(t)
+	uid, idToken := newEmulatorAccount(t, client)
+	ctx := context.Background()
+	verifier := NewFirebaseVerifier(client)
+
+	token, err := verifier.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	if err != nil {
+		t.Fatalf("VerifyIDTokenAndCheckRevoked: %v", err)
+	}
+	if token.UID != uid {
+		t.Fatalf("uid = %q, want %q", token.UID, uid)
+	}
+
+	if _, err := client.UpdateUser(ctx, uid, (&firebase.UserToUpdate{}).Disabled(true)); err != nil {
+		t.Fatalf("disable user: %v", err)
+	}
+	if _, err := verifier.VerifyIDTokenAndCheckRevoked(ctx, idToken); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("disabled account error = %v, want %v", err, ErrAccountDisabled)
+	}
+	if _, err := client.UpdateUser(ctx, uid, (&firebase.UserToUpdate{}).Disabled(false)); err != nil {
+		t.Fatalf("enable user: %v", err)
+	}
+
+	// validSince Firebase dalam detik, token harus lebih tua dari revocation
+	time.Sleep(1100 * time.Millisecond)
+	if err := client.RevokeRefreshTokens(ctx, uid); err != nil {
+		t.Fatalf("RevokeRefreshTokens: %v", err)
+	}
+	if _, err := verifier.VerifyIDTokenAndCheckRevoked(ctx, idToken); !errors.Is(err, ErrTokenRevoked) {
+		t.Fatalf("revoked token error = %v, want %v", err, ErrTokenRevoked)
+	}
+}
+
+func TestFirebaseReconciliationEmulatorClosesDisabledAccountSessions(t *testing.T) {
+	client := newEmulatorClient(t)
+	uid, idToken := newEmulatorAccount(t, client)
+	ctx := context.Background()
+
+	s, repo, _ := newTestAuthService(t, middleware.RealmUser)
+	s.Verifier = NewFirebaseVerifier(client)
+
+	if _, err := s.Register(idToken, "", ""); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+	if _, err := s.Login(idToken, "test-device", "127.0.0.1"); err != nil {
+		t.Fatalf("Login: %v", err)
+	}
+	user, _ := repo.FindByGoogleUID(uid)
+
+	if _, err := client.UpdateUser(ctx, uid, (&firebase.UserToUpdate{}).Disabled(true)); err != nil {
+		t.Fatalf("disable user: %v", err)
+	}
+
+	job := NewFirebaseReconciliationJob(time.Minute)
+	closed, err := job.Reconcile(ctx, FirebaseRealm{Name: middleware.RealmUser, Auth: s, Lookup: client})
+	if err != nil {
+		t.Fatalf("Reconcile: %v", err)
+	}
+	if closed != 1 {
+		t.Fatalf("closed = %d, want 1", closed)
+	}
+
+	sessions, _ := repo.FindSessionsByUserID(user.ID)
+	if len(sessions) != 0 {
+		t.Fatalf("%d sessions still active after reconciliation", len(sessions))
+	}
+	if len(repo.securityEvents) != 1 || repo.securityEvents[0] != EventFirebaseAccountDisabled {
+		t.Fatalf("security events = %v, want [%s]", repo.securityEvents, EventFirebaseAccountDisabled)
+	}
+
+	// login berikutnya juga ditolak
+	if _, err := s.Login(idToken, "test-device", "127.0.0.1"); !errors.Is(err, ErrAccountDisabled) {
+		t.Fatalf("Login after disable error = %v, want %v", err, ErrAccountDisabled)
+	}
+}
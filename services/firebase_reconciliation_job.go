@@ -0,0 +1,151 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"time"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+// Jenis security event dari rekonsiliasi Firebase
+const (
+	EventFirebaseAccountDisabled = "firebase_account_disabled"
+	EventFirebaseAccountDeleted  = "firebase_account_deleted"
+	EventFirebaseTokensRevoked   = "firebase_tokens_revoked"
+)
+
+// Batas identifier per panggilan GetUsers
+const firebaseLookupBatch = 100
+
+// FirebaseUserLookup diimplementasikan *auth.Client, termasuk saat diarahkan
+// ke Firebase Auth emulator lewat FIREBASE_AUTH_EMULATOR_HOST
+type FirebaseUserLookup interface {
+	GetUsers(ctx context.Context, identifiers []firebase.UserIdentifier) (*firebase.GetUsersResult, error)
+}
+
+// FirebaseRealm menghubungkan AuthService satu realm dengan project
+// Firebase-nya
+type FirebaseRealm struct {
+	Name   string
+	Auth   *AuthService
+	Lookup FirebaseUserLookup
+}
+
+// FirebaseReconciliationJob menutup session lokal milik akun yang di-disable
+// atau dihapus di Firebase, dan session yang dibuat sebelum refresh token
+// Firebase akun itu di-revoke. Login sudah menolak akun seperti ini, job ini
+// menangani session yang sudah terlanjur ada (refresh token berlaku 7 hari).
+type FirebaseReconciliationJob struct {
+	Realms   []FirebaseRealm
+	Interval time.Duration
+}
+
+func NewFirebaseReconciliationJob(interval time.Duration, realms ...FirebaseRealm) *FirebaseReconciliationJob {
+	return &FirebaseReconciliationJob{
+		Realms:   realms,
+		Interval: interval,
+	}
+}
+
+// Start menjalankan Run langsung lalu setiap Interval sampai ctx selesai
+func (j *FirebaseReconciliationJob) Start(ctx context.Context) {
+	ticker := time.NewTicker(j.Interval)
+	defer ticker.Stop()
+
+	for {
+		j.Run(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (j *FirebaseReconciliationJob) Run(ctx context.Context) {
+	for _, realm := range j.Realms {
+		closed, err := j.Reconcile(ctx, realm)
+		if err != nil {
+			log.Printf("firebase reconciliation job (%s) failed: %v", realm.Name, err)
+		}
+		if closed > 0 {
+			log.Printf("firebase reconciliation job (%s): %d accounts logged out", realm.Name, closed)
+		}
+	}
+}
+
+// Reconcile memeriksa semua akun realm yang punya session aktif dan
+// mengembalikan jumlah akun yang session-nya ditutup
+func (j *FirebaseReconciliationJob) Reconcile(ctx context.Context, realm FirebaseRealm) (int, error) {
+	accounts, err := realm.Auth.Repo.FindAccountsWithActiveSessions()
+	if err != nil {
+		return 0, err
+	}
+
+	closed := 0
+	for start := 0; start < len(accounts); start += firebaseLookupBatch {
+		end := min(start+firebaseLookupBatch, len(accounts))
+		n, err := j.reconcileBatch(ctx, realm, accounts[start:end])
+		closed += n
+		if err != nil {
+			return closed, err
+		}
+	}
+	return closed, nil
+}
+
+func (j *FirebaseReconciliationJob) reconcileBatch(ctx context.Context, realm FirebaseRealm, accounts []models.BaseUser) (int, error) {
+	ids := make([]firebase.UserIdentifier, 0, len(accounts))
+	for _, a := range accounts {
+		ids = append(ids, firebase.UIDIdentifier{UID: a.GoogleUID})
+	}
+
+	result, err := realm.Lookup.GetUsers(ctx, ids)
+	if err != nil {
+		return 0, err
+	}
+	records := map[string]*firebase.UserRecord{}
+	for _, u := range result.Users {
+		records[u.UID] = u
+	}
+
+	closed := 0
+	for _, account := range accounts {
+		record := records[account.GoogleUID]
+
+		var event, detail string
+		var err error
+		switch {
+		case record == nil:
+			event, detail = EventFirebaseAccountDeleted, "account deleted in Firebase, all sessions closed"
+			err = realm.Auth.LogoutAll(account.ID)
+
+		case record.Disabled:
+			event, detail = EventFirebaseAccountDisabled, "account disabled in Firebase, all sessions closed"
+			err = realm.Auth.LogoutAll(account.ID)
+
+		case record.TokensValidAfterMillis > 0:
+			validAfter := time.UnixMilli(record.TokensValidAfterMillis)
+			var n int
+			n, err = realm.Auth.LogoutBefore(account.ID, validAfter)
+			if n > 0 {
+				event, detail = EventFirebaseTokensRevoked, fmt.Sprintf("Firebase tokens revoked at %s, %d sessions closed", validAfter.Format(time.RFC3339), n)
+			}
+		}
+
+		if err != nil {
+			return closed, err
+		}
+		if event == "" {
+			continue
+		}
+		closed++
+		if err := realm.Auth.Repo.SaveSecurityEvent(account.ID, event, detail, ""); err != nil {
+			log.Println("firebase reconciliation job: save security event failed:", err)
+		}
+	}
+	return closed, nil
+}
@@ -0,0 +1,60 @@
+package services
+
+import (
+	"context"
+	"errors"
+
+	firebase "firebase.google.com/go/v4/auth"
+)
+
+var (
+	ErrAccountDisabled = errors.New("account is disabled")
+	ErrTokenRevoked    = errors.New("token has been revoked, please sign in again")
+)
+
+// IDTokenVerifier memverifikasi ID token Google/Firebase yang dikirim client
+// saat register dan login. AuthService hanya bergantung pada interface ini,
+// jadi service bisa dijalankan tanpa kredensial Firebase.
+type IDTokenVerifier interface {
+	VerifyIDToken(ctx context.Context, idToken string) (*firebase.Token, error)
+}
+
+// RevocationCheckingVerifier diimplementasikan verifier yang bisa bertanya ke
+// Firebase apakah token sudah di-revoke atau akunnya di-disable. Verifier
+// offline (JWKS) tidak bisa; untuk verifier itu revocation hanya ditegakkan
+// oleh FirebaseReconciliationJob, dan job itu butuh kredensial Firebase Admin
+// (FIREBASE_RECONCILE=true). Tanpa job, akun yang di-disable tetap bisa
+// login dan session-nya tidak ditutup.
+type RevocationCheckingVerifier interface {
+	VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*firebase.Token, error)
+}
+
+// --------------------------- FIREBASE VERIFIER ---------------------------
+
+// FirebaseVerifier memakai Firebase Admin SDK untuk verifikasi token.
+type FirebaseVerifier struct {
+	Client *firebase.Client
+}
+
+func NewFirebaseVerifier(client *firebase.Client) *FirebaseVerifier {
+	return &FirebaseVerifier{
+		Client: client,
+	}
+}
+
+func (v *FirebaseVerifier) VerifyIDToken(ctx context.Context, idToken string) (*firebase.Token, error) {
+	return v.Client.VerifyIDToken(ctx, idToken)
+}
+
+// VerifyIDTokenAndCheckRevoked butuh satu RPC ke Firebase (atau emulator bila
+// FIREBASE_AUTH_EMULATOR_HOST diisi)
+func (v *FirebaseVerifier) VerifyIDTokenAndCheckRevoked(ctx context.Context, idToken string) (*firebase.Token, error) {
+	token, err := v.Client.VerifyIDTokenAndCheckRevoked(ctx, idToken)
+	switch {
+	case firebase.IsUserDisabled(err):
+		return nil, ErrAccountDisabled
+	case firebase.IsIDTokenRevoked(err):
+		return nil, ErrTokenRevoked
+	}
+	return token, err
+}
@@ -0,0 +1,167 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrImpersonationReason   = errors.New("reason is required (max 255 characters)")
+	ErrImpersonationNotFound = errors.New("impersonation not found or already ended")
+)
+
+// Masa berlaku token impersonasi bila tidak dikonfigurasi, dan batas
+// maksimalnya
+const (
+	DefaultImpersonationTTL = 15 * time.Minute
+	MaxImpersonationTTL     = time.Hour
+)
+
+// Jenis security event impersonasi (dicatat di akun admin dan user)
+const (
+	EventImpersonationStarted = "impersonation_started"
+	EventImpersonationEnded   = "impersonation_ended"
+)
+
+// ImpersonationService membuat token "act as user" untuk admin dan mencatat
+// setiap request yang memakainya
+type ImpersonationService struct {
+	Repo     *repository.ImpersonationRepository
+	Admins   repository.AuthRepository
+	Users    repository.AuthRepository
+	AdminJWT *middleware.JWTManager
+	UserJWT  *middleware.JWTManager
+	TTL      time.Duration
+}
+
+func NewImpersonationService(repo *repository.ImpersonationRepository, admins, users repository.AuthRepository, adminJWT, userJWT *middleware.JWTManager, ttl time.Duration) *ImpersonationService {
+	return &ImpersonationService{
+		Repo:     repo,
+		Admins:   admins,
+		Users:    users,
+		AdminJWT: adminJWT,
+		UserJWT:  userJWT,
+		TTL:      ttl,
+	}
+}
+
+// Start membuat token impersonasi untuk user. Token hanya muncul di sini,
+// tidak punya refresh token dan membawa klaim act berisi admin.
+func (s *ImpersonationService) Start(adminID int, userID, reason, ip string) (*models.Impersonation, string, error) {
+	reason = strings.TrimSpace(reason)
+	if reason == "" || len(reason) > 255 {
+		return nil, "", ErrImpersonationReason
+	}
+
+	admin, err := s.Admins.FindByID(strconv.Itoa(adminID))
+	if err != nil || admin == nil {
+		return nil, "", ErrUserNotFound
+	}
+	user, err := s.Users.FindByID(userID)
+	if err != nil || user == nil {
+		return nil, "", ErrUserNotFound
+	}
+
+	actor := middleware.ActorClaim{
+		Subject: s.AdminJWT.Subject(admin.ID),
+		Email:   admin.Email,
+	}
+	token, jti, err := s.UserJWT.GenerateImpersonationToken(user.ID, user.Email, user.Role, user.Organization, actor, s.TTL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	now := time.Now()
+	imp := models.Impersonation{
+		AdminID:   admin.ID,
+		UserID:    user.ID,
+		Reason:    reason,
+		TokenID:   jti,
+		IPAddress: ip,
+		CreatedAt: now,
+		ExpiresAt: now.Add(s.TTL),
+	}
+	id, err := s.Repo.CreateImpersonation(imp)
+	if err != nil {
+		return nil, "", err
+	}
+	imp.ID = id
+
+	detail := fmt.Sprintf("impersonation %d: %s as %s, reason: %s", id, actor.Subject, s.UserJWT.Subject(user.ID), reason)
+	if err := s.Admins.SaveSecurityEvent(admin.ID, EventImpersonationStarted, detail, ip); err != nil {
+		return nil, "", err
+	}
+	if err := s.Users.SaveSecurityEvent(user.ID, EventImpersonationStarted, detail, ip); err != nil {
+		return nil, "", err
+	}
+
+	return &imp, token, nil
+}
+
+// End mencabut token impersonasi sebelum expired. jti dicabut lebih dulu,
+// jadi impersonasi tidak pernah tercatat selesai sementara token-nya masih
+// berlaku.
+func (s *ImpersonationService) End(adminID, id int, ip string) error {
+	imp, err := s.Repo.FindImpersonationByID(id)
+	if err != nil || imp == nil || imp.EndedAt != nil {
+		return ErrImpersonationNotFound
+	}
+
+	if s.UserJWT.Revocations != nil && time.Now().Before(imp.ExpiresAt) {
+		if err := s.UserJWT.Revocations.Revoke(imp.TokenID, imp.ExpiresAt); err != nil {
+			return err
+		}
+	}
+
+	ok, err := s.Repo.EndImpersonation(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrImpersonationNotFound
+	}
+
+	detail := fmt.Sprintf("impersonation %d ended by %s", id, s.AdminJWT.Subject(adminID))
+	return s.Users.SaveSecurityEvent(imp.UserID, EventImpersonationEnded, detail, ip)
+}
+
+func (s *ImpersonationService) GetAll() ([]models.Impersonation, error) {
+	return s.Repo.GetAllImpersonations()
+}
+
+// GetRequests mengembalikan audit trail satu impersonasi
+func (s *ImpersonationService) GetRequests(id int) (*models.Impersonation, []models.ImpersonationRequest, error) {
+	imp, err := s.Repo.FindImpersonationByID(id)
+	if err != nil || imp == nil {
+		return nil, nil, ErrImpersonationNotFound
+	}
+	reqs, err := s.Repo.FindImpersonationRequests(id)
+	if err != nil {
+		return nil, nil, err
+	}
+	return imp, reqs, nil
+}
+
+// ImpersonationActive dipanggil AuthMiddleware realm user sebelum request
+// dengan token impersonasi diproses: token harus tercatat, belum diakhiri dan
+// belum expired
+func (s *ImpersonationService) ImpersonationActive(tokenID string) (bool, error) {
+	imp, err := s.Repo.FindImpersonationByTokenID(tokenID)
+	if err != nil || imp == nil {
+		return false, err
+	}
+	return imp.EndedAt == nil && time.Now().Before(imp.ExpiresAt), nil
+}
+
+// RecordImpersonatedRequest dipanggil AuthMiddleware realm user setelah
+// request dengan token impersonasi selesai
+func (s *ImpersonationService) RecordImpersonatedRequest(tokenID, method, path string, status int, ip string) error {
+	return s.Repo.SaveImpersonationRequest(tokenID, method, path, status, ip)
+}
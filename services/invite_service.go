@@ -0,0 +1,148 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrInviteRequired = errors.New("invite code is required")
+	ErrInvalidInvite  = errors.New("invalid, used or expired invite code")
+	ErrInviteMismatch = errors.New("invite code is not valid for this account")
+	ErrInviteTarget   = errors.New("invite must be bound to exactly one of email or hosted_domain")
+	ErrInviteTTL      = errors.New("invite expiry must be between 1 hour and 30 days")
+	ErrInviteNotFound = errors.New("invite not found or already used/revoked")
+)
+
+// Masa berlaku undangan bila tidak ditentukan, dan batas maksimalnya
+const (
+	DefaultInviteTTL = 72 * time.Hour
+	MaxInviteTTL     = 30 * 24 * time.Hour
+)
+
+type InviteService struct {
+	Repo *repository.InviteRepository
+}
+
+func NewInviteService(repo *repository.InviteRepository) *InviteService {
+	return &InviteService{
+		Repo: repo,
+	}
+}
+
+// Create membuat undangan baru. Kode undangan hanya dikembalikan sekali di
+// sini, database hanya menyimpan hash-nya.
+func (s *InviteService) Create(createdBy int, email, hostedDomain string, ttl time.Duration) (*models.AdminInvite, string, error) {
+	email = strings.ToLower(strings.TrimSpace(email))
+	hostedDomain = strings.ToLower(strings.TrimSpace(hostedDomain))
+	if (email == "") == (hostedDomain == "") {
+		return nil, "", ErrInviteTarget
+	}
+
+	if ttl == 0 {
+		ttl = DefaultInviteTTL
+	}
+	if ttl < time.Hour || ttl > MaxInviteTTL {
+		return nil, "", ErrInviteTTL
+	}
+
+	b := make([]byte, 24)
+	if _, err := rand.Read(b); err != nil {
+		return nil, "", err
+	}
+	code := base64.RawURLEncoding.EncodeToString(b)
+
+	invite := models.AdminInvite{
+		CodeHash:     hashInviteCode(code),
+		Email:        email,
+		HostedDomain: hostedDomain,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+		ExpiresAt:    time.Now().Add(ttl),
+	}
+
+	id, err := s.Repo.CreateInvite(invite)
+	if err != nil {
+		return nil, "", err
+	}
+	invite.ID = id
+
+	return &invite, code, nil
+}
+
+// Claim memvalidasi kode undangan untuk akun Google (email, hosted domain)
+// lalu menandainya terpakai. Kembalikan undangan dengan Release bila
+// registrasi gagal setelahnya.
+func (s *InviteService) Claim(code, googleUID, email string, emailVerified bool, hostedDomain string) (*models.AdminInvite, error) {
+	if code == "" {
+		return nil, ErrInviteRequired
+	}
+
+	invite, err := s.Repo.FindInviteByCodeHash(hashInviteCode(code))
+	if err != nil || invite == nil {
+		return nil, ErrInvalidInvite
+	}
+	if invite.UsedAt != nil || invite.RevokedAt != nil || time.Now().After(invite.ExpiresAt) {
+		return nil, ErrInvalidInvite
+	}
+
+	// email harus terverifikasi oleh Google supaya undangan tidak bisa
+	// dipakai akun yang sekadar mengaku memiliki alamat tersebut
+	if !emailVerified {
+		return nil, ErrInviteMismatch
+	}
+	switch {
+	case invite.Email != "":
+		if !strings.EqualFold(invite.Email, email) {
+			return nil, ErrInviteMismatch
+		}
+	case invite.HostedDomain != "":
+		if !strings.EqualFold(invite.HostedDomain, hostedDomain) {
+			return nil, ErrInviteMismatch
+		}
+	default:
+		return nil, ErrInvalidInvite
+	}
+
+	ok, err := s.Repo.ConsumeInvite(invite.ID, googleUID)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrInvalidInvite
+	}
+	return invite, nil
+}
+
+func (s *InviteService) Release(invite *models.AdminInvite, googleUID string) error {
+	return s.Repo.ReleaseInvite(invite.ID, googleUID)
+}
+
+func (s *InviteService) GetAll() ([]models.AdminInvite, error) {
+	return s.Repo.GetAllInvites()
+}
+
+func (s *InviteService) Revoke(id int) error {
+	ok, err := s.Repo.RevokeInvite(id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrInviteNotFound
+	}
+	return nil
+}
+
+// Kode undangan 192-bit acak, jadi SHA-256 biasa sudah cukup
+func hashInviteCode(code string) string {
+	sum := sha256.Sum256([]byte(code))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,118 @@
+package services
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var inviteRowColumns = []string{"id", "code_hash", "email", "hosted_domain", "created_by", "created_at", "expires_at", "used_at", "used_by_uid", "revoked_at"}
+
+func newTestInviteService(t *testing.T) (*InviteService, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+	return NewInviteService(repository.NewInviteRepository(db)), mock
+}
+
+func TestInviteClaim(t *testing.T) {
+	now := time.Now()
+	past := now.Add(-time.Hour)
+	future := now.Add(time.Hour)
+
+	tests := []struct {
+		name          string
+		code          string
+		found         bool
+		email, hd     string
+		expiresAt     time.Time
+		usedAt        *time.Time
+		revokedAt     *time.Time
+		claimEmail    string
+		emailVerified bool
+		claimHD       string
+		consumed      int64 // baris yang diubah ConsumeInvite, -1 bila tidak dipanggil
+		want          error
+	}{
+		{name: "email invite", code: "code", found: true, email: "new@example.com", expiresAt: future, claimEmail: "NEW@example.com", emailVerified: true, consumed: 1},
+		{name: "hosted domain invite", code: "code", found: true, hd: "example.com", expiresAt: future, claimEmail: "a@example.com", emailVerified: true, claimHD: "example.com", consumed: 1},
+		{name: "missing code", consumed: -1, want: ErrInviteRequired},
+		{name: "unknown code", code: "code", consumed: -1, want: ErrInvalidInvite},
+		{name: "expired", code: "code", found: true, email: "new@example.com", expiresAt: past, claimEmail: "new@example.com", emailVerified: true, consumed: -1, want: ErrInvalidInvite},
+		{name: "already used", code: "code", found: true, email: "new@example.com", expiresAt: future, usedAt: &past, claimEmail: "new@example.com", emailVerified: true, consumed: -1, want: ErrInvalidInvite},
+		{name: "revoked", code: "code", found: true, email: "new@example.com", expiresAt: future, revokedAt: &past, claimEmail: "new@example.com", emailVerified: true, consumed: -1, want: ErrInvalidInvite},
+		{name: "other email", code: "code", found: true, email: "new@example.com", expiresAt: future, claimEmail: "other@example.com", emailVerified: true, consumed: -1, want: ErrInviteMismatch},
+		{name: "unverified email", code: "code", found: true, email: "new@example.com", expiresAt: future, claimEmail: "new@example.com", consumed: -1, want: ErrInviteMismatch},
+		{name: "other hosted domain", code: "code", found: true, hd: "example.com", expiresAt: future, claimEmail: "a@evil.com", emailVerified: true, claimHD: "evil.com", consumed: -1, want: ErrInviteMismatch},
+		{name: "consumed concurrently", code: "code", found: true, email: "new@example.com", expiresAt: future, claimEmail: "new@example.com", emailVerified: true, consumed: 0, want: ErrInvalidInvite},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock := newTestInviteService(t)
+
+			if tt.code != "" {
+				query := mock.ExpectQuery(`SELECT .+ FROM admin_invites WHERE code_hash = \?`).WithArgs(hashInviteCode(tt.code))
+				if tt.found {
+					query.WillReturnRows(sqlmock.NewRows(inviteRowColumns).
+						AddRow(1, hashInviteCode(tt.code), tt.email, tt.hd, 1, now, tt.expiresAt, tt.usedAt, "", tt.revokedAt))
+				} else {
+					query.WillReturnRows(sqlmock.NewRows(inviteRowColumns))
+				}
+			}
+			if tt.consumed >= 0 {
+				mock.ExpectExec(`UPDATE admin_invites SET used_at = NOW\(\), used_by_uid = \? WHERE id = \? AND used_at IS NULL`).
+					WithArgs("uid-1", 1).
+					WillReturnResult(sqlmock.NewResult(0, tt.consumed))
+			}
+
+			invite, err := s.Claim(tt.code, "uid-1", tt.claimEmail, tt.emailVerified, tt.claimHD)
+			if !errors.Is(err, tt.want) {
+				t.Fatalf("Claim error = %v, want %v", err, tt.want)
+			}
+			if tt.want == nil && (invite == nil || invite.ID != 1) {
+				t.Fatalf("Claim invite = %+v, want invite 1", invite)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestRegisterReleasesInviteWhenCreateFails(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmAdmin)
+	invites, mock := newTestInviteService(t)
+	s.Invites = invites
+	repo.createErr = errors.New("insert failed")
+
+	verifier.Add("id-token-admin", &firebase.Token{UID: "admin-1", Claims: map[string]interface{}{
+		"email":          "new@example.com",
+		"email_verified": true,
+	}})
+
+	mock.ExpectQuery(`SELECT .+ FROM admin_invites WHERE code_hash = \?`).
+		WillReturnRows(sqlmock.NewRows(inviteRowColumns).
+			AddRow(1, hashInviteCode("code"), "new@example.com", "", 1, time.Now(), time.Now().Add(time.Hour), nil, "", nil))
+	mock.ExpectExec(`UPDATE admin_invites SET used_at = NOW\(\)`).WithArgs("admin-1", 1).WillReturnResult(sqlmock.NewResult(0, 1))
+	// undangan dikembalikan hanya untuk akun yang memakainya
+	mock.ExpectExec(`UPDATE admin_invites SET used_at = NULL, used_by_uid = NULL WHERE id = \? AND used_by_uid = \?`).
+		WithArgs(1, "admin-1").
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	if _, err := s.Register("id-token-admin", "", "code"); err == nil {
+		t.Fatal("Register succeeded although the account could not be created")
+	}
+	if err := mock.ExpectationsWereMet(); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,227 @@
+package services
+
+import (
+	"context"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// JWKS publik yang dipakai Firebase Auth untuk menandatangani ID token.
+const FirebaseJWKSURL = "https://www.googleapis.com/service_accounts/v1/jwk/securetoken@system.gserviceaccount.com"
+
+var ErrUnknownSigningKey = errors.New("unknown signing key")
+
+// Jarak minimal antar reload karena kid tidak dikenal. kid acak dari token
+// palsu tidak boleh membuat server terus-menerus mengambil JWKS.
+const DefaultJWKSMinRefreshInterval = 5 * time.Minute
+
+// JWKSVerifier memverifikasi ID token Google/Firebase secara offline
+// menggunakan JWKS dari file atau URL, tanpa Firebase Admin SDK.
+type JWKSVerifier struct {
+	Source   string // URL (http/https) atau path file JWKS
+	Issuer   string
+	Audience string
+
+	HTTPClient *http.Client
+	CacheTTL   time.Duration
+	// MinRefreshInterval membatasi reload untuk kid yang tidak dikenal
+	MinRefreshInterval time.Duration
+
+	mu        sync.RWMutex
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// reloadMu memastikan hanya satu reload berjalan sekaligus
+	reloadMu sync.Mutex
+	triedAt  time.Time
+}
+
+func NewJWKSVerifier(source, issuer, audience string) *JWKSVerifier {
+	return &JWKSVerifier{
+		Source:             source,
+		Issuer:             issuer,
+		Audience:           audience,
+		HTTPClient:         &http.Client{Timeout: 10 * time.Second},
+		CacheTTL:           time.Hour,
+		MinRefreshInterval: DefaultJWKSMinRefreshInterval,
+	}
+}
+
+func (v *JWKSVerifier) VerifyIDToken(ctx context.Context, idToken string) (*firebase.Token, error) {
+	claims := jwt.MapClaims{}
+
+	_, err := jwt.ParseWithClaims(idToken, claims, func(t *jwt.Token) (interface{}, error) {
+		kid, _ := t.Header["kid"].(string)
+		return v.key(ctx, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256"}),
+		jwt.WithIssuer(v.Issuer),
+		jwt.WithAudience(v.Audience),
+		jwt.WithExpirationRequired(),
+		jwt.WithIssuedAt(),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	sub, _ := claims.GetSubject()
+	if sub == "" || len(sub) > 128 {
+		return nil, errors.New("id token has invalid subject")
+	}
+
+	token := &firebase.Token{
+		Issuer:   v.Issuer,
+		Audience: v.Audience,
+		Subject:  sub,
+		UID:      sub,
+		Claims:   claims,
+	}
+	if exp, err := claims.GetExpirationTime(); err == nil && exp != nil {
+		token.Expires = exp.Unix()
+	}
+	if iat, err := claims.GetIssuedAt(); err == nil && iat != nil {
+		token.IssuedAt = iat.Unix()
+	}
+	if authTime, ok := claims["auth_time"].(float64); ok {
+		token.AuthTime = int64(authTime)
+	}
+	if fb, ok := claims["firebase"].(map[string]interface{}); ok {
+		token.Firebase.SignInProvider, _ = fb["sign_in_provider"].(string)
+		token.Firebase.Tenant, _ = fb["tenant"].(string)
+		token.Firebase.Identities, _ = fb["identities"].(map[string]interface{})
+	}
+
+	return token, nil
+}
+
+// key mengambil public key untuk kid, memuat ulang JWKS bila cache sudah
+// kadaluarsa atau kid belum dikenal (misalnya setelah Google rotasi key).
+// Reload paling sering sekali per MinRefreshInterval; di antaranya kid yang
+// tidak dikenal langsung ditolak dengan key set yang ada di cache.
+func (v *JWKSVerifier) key(ctx context.Context, kid string) (*rsa.PublicKey, error) {
+	v.mu.RLock()
+	key, ok := v.keys[kid]
+	fresh := time.Since(v.fetchedAt) < v.CacheTTL
+	v.mu.RUnlock()
+
+	if ok && fresh {
+		return key, nil
+	}
+
+	if err := v.reload(ctx); err != nil {
+		if ok {
+			return key, nil
+		}
+		return nil, err
+	}
+
+	v.mu.RLock()
+	defer v.mu.RUnlock()
+	key, ok = v.keys[kid]
+	if !ok {
+		return nil, ErrUnknownSigningKey
+	}
+	return key, nil
+}
+
+// reload mengambil JWKS kecuali percobaan terakhir belum lewat
+// MinRefreshInterval (kecuali cache masih kosong)
+func (v *JWKSVerifier) reload(ctx context.Context) error {
+	v.reloadMu.Lock()
+	defer v.reloadMu.Unlock()
+
+	v.mu.RLock()
+	loaded := v.keys != nil
+	v.mu.RUnlock()
+	if loaded && time.Since(v.triedAt) < v.MinRefreshInterval {
+		return nil
+	}
+	v.triedAt = time.Now()
+
+	data, err := v.fetch(ctx)
+	if err != nil {
+		return err
+	}
+
+	keys, err := parseRSAJWKS(data)
+	if err != nil {
+		return err
+	}
+
+	v.mu.Lock()
+	v.keys = keys
+	v.fetchedAt = time.Now()
+	v.mu.Unlock()
+	return nil
+}
+
+func (v *JWKSVerifier) fetch(ctx context.Context) ([]byte, error) {
+	if !strings.HasPrefix(v.Source, "http://") && !strings.HasPrefix(v.Source, "https://") {
+		return os.ReadFile(v.Source)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.Source, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := v.HTTPClient.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetch jwks: unexpected status %d", resp.StatusCode)
+	}
+	return io.ReadAll(resp.Body)
+}
+
+func parseRSAJWKS(data []byte) (map[string]*rsa.PublicKey, error) {
+	var set struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	if err := json.Unmarshal(data, &set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, k := range set.Keys {
+		if k.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: invalid modulus", k.Kid)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, fmt.Errorf("jwks key %q: invalid exponent", k.Kid)
+		}
+		keys[k.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+
+	if len(keys) == 0 {
+		return nil, errors.New("jwks contains no RSA keys")
+	}
+	return keys, nil
+}
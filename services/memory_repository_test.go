@@ -0,0 +1,375 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+// memoryAuthRepository adalah AuthRepository in-memory untuk test, mengikuti
+// perilaku query MySQL di package repository
+type memoryAuthRepository struct {
+	mu             sync.Mutex
+	users          map[int]*models.BaseUser
+	sessions       map[int]*models.Session
+	rotated        map[int][]string
+	securityEvents []string
+	loginHistory   int
+	profileChanges []models.ProfileChange
+	nextUserID     int
+	nextSessionID  int
+
+	// beforeRotate dipanggil sebelum CAS rotasi, untuk mensimulasikan
+	// refresh paralel
+	beforeRotate func(sessionID int)
+	// createErr membuat Create gagal
+	createErr error
+}
+
+func newMemoryAuthRepository() *memoryAuthRepository {
+	return &memoryAuthRepository{
+		users:    make(map[int]*models.BaseUser),
+		sessions: make(map[int]*models.Session),
+		rotated:  make(map[int][]string),
+	}
+}
+
+func (r *memoryAuthRepository) Create(user models.BaseUser) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.createErr != nil {
+		return r.createErr
+	}
+	for _, u := range r.users {
+		if u.GoogleUID == user.GoogleUID {
+			return errors.New("duplicate google_uid")
+		}
+	}
+	r.nextUserID++
+	user.ID = r.nextUserID
+	user.CreatedAt = time.Now()
+	r.users[user.ID] = &user
+	return nil
+}
+
+func (r *memoryAuthRepository) SaveLoginHistory(userID int, deviceInfo, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.loginHistory++
+	return nil
+}
+
+func (r *memoryAuthRepository) UpdateLoginStatus(id int, status int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[id]; ok {
+		u.IsLoggedIn = status
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) FindByGoogleUID(uid string) (*models.BaseUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, u := range r.users {
+		if u.GoogleUID == uid {
+			copy := *u
+			return &copy, nil
+		}
+	}
+	return nil, sql.ErrNoRows
+}
+
+func (r *memoryAuthRepository) FindByID(id string) (*models.BaseUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, _ := strconv.Atoi(id)
+	u, ok := r.users[n]
+	if !ok {
+		return nil, nil
+	}
+	copy := *u
+	return &copy, nil
+}
+
+func (r *memoryAuthRepository) GetAll() ([]models.BaseUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	users := []models.BaseUser{}
+	for _, u := range r.users {
+		users = append(users, *u)
+	}
+	return users, nil
+}
+
+func (r *memoryAuthRepository) Update(user models.BaseUser) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	u, ok := r.users[user.ID]
+	if !ok {
+		return nil
+	}
+	u.Name, u.Email, u.Role, u.ProfilePicture = user.Name, user.Email, user.Role, user.ProfilePicture
+	return nil
+}
+
+func (r *memoryAuthRepository) Delete(id string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	n, _ := strconv.Atoi(id)
+	delete(r.users, n)
+	return nil
+}
+
+func (r *memoryAuthRepository) UpdatePhotoURL(userID int, url string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[userID]; ok {
+		u.ProfilePicture = url
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) CreateSession(session models.Session) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.nextSessionID++
+	session.ID = r.nextSessionID
+	session.CreatedAt = time.Now()
+	session.LastUsedAt = session.CreatedAt
+	r.sessions[session.ID] = &session
+	return session.ID, nil
+}
+
+func (r *memoryAuthRepository) FindSessionByID(sessionID int) (*models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok {
+		return nil, errors.New("session not found")
+	}
+	copy := *s
+	return &copy, nil
+}
+
+func (r *memoryAuthRepository) FindSessionsByUserID(userID int) ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []models.Session{}
+	for _, s := range r.sessions {
+		if s.AdminOrUserID == userID && s.RevokedAt == nil && s.ExpiresAt.After(time.Now()) {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memoryAuthRepository) UpdateSessionRefreshToken(sessionID int, tokenHash string, exp time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.RefreshTokenHash, s.LegacyRefreshToken, s.ExpiresAt = tokenHash, "", exp
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) RotateSessionRefreshToken(sessionID, generation int, oldTokenHash, newTokenHash string, exp time.Time) (bool, error) {
+	if r.beforeRotate != nil {
+		r.beforeRotate(sessionID)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	s, ok := r.sessions[sessionID]
+	if !ok || s.Generation != generation || s.RevokedAt != nil {
+		return false, nil
+	}
+	s.RefreshTokenHash, s.LegacyRefreshToken, s.ExpiresAt = newTokenHash, "", exp
+	s.Generation++
+	r.rotated[sessionID] = append(r.rotated[sessionID], oldTokenHash)
+	return true, nil
+}
+
+func (r *memoryAuthRepository) FindRotatedTokenHashes(sessionID int) ([]string, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]string{}, r.rotated[sessionID]...), nil
+}
+
+func (r *memoryAuthRepository) SetSessionAccessToken(sessionID int, jti string, exp time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.AccessJTI, s.AccessExpiresAt = jti, &exp
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) SetSessionStepUpToken(sessionID int, jti string, exp time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok {
+		s.StepUpJTI, s.StepUpExpiresAt = jti, &exp
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) FindLegacyRefreshTokens() ([]models.Session, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	sessions := []models.Session{}
+	for _, s := range r.sessions {
+		if s.LegacyRefreshToken != "" {
+			sessions = append(sessions, *s)
+		}
+	}
+	return sessions, nil
+}
+
+func (r *memoryAuthRepository) UpdateLegacyRefreshToken(sessionID int, oldToken, newToken string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok && s.LegacyRefreshToken == oldToken {
+		s.LegacyRefreshToken = newToken
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) RevokeSession(sessionID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok && s.RevokedAt == nil {
+		now := time.Now()
+		s.RevokedAt = &now
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) DeleteSession(userID, sessionID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if s, ok := r.sessions[sessionID]; ok && s.AdminOrUserID == userID {
+		delete(r.sessions, sessionID)
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) DeleteSessionsByUserID(userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if s.AdminOrUserID == userID {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) DeleteExpiredSessions(userID int) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for id, s := range r.sessions {
+		if s.AdminOrUserID == userID && !s.ExpiresAt.After(time.Now()) {
+			delete(r.sessions, id)
+		}
+	}
+	return nil
+}
+
+func (r *memoryAuthRepository) FindAccountsWithActiveSessions() ([]models.BaseUser, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := map[int]bool{}
+	accounts := []models.BaseUser{}
+	for _, s := range r.sessions {
+		u, ok := r.users[s.AdminOrUserID]
+		if !ok || seen[u.ID] || s.RevokedAt != nil || !s.ExpiresAt.After(time.Now()) {
+			continue
+		}
+		seen[u.ID] = true
+		accounts = append(accounts, models.BaseUser{ID: u.ID, GoogleUID: u.GoogleUID, Email: u.Email})
+	}
+	return accounts, nil
+}
+
+func (r *memoryAuthRepository) SaveSecurityEvent(userID int, eventType, detail, ip string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.securityEvents = append(r.securityEvents, eventType)
+	return nil
+}
+
+func (r *memoryAuthRepository) UpdateGoogleProfile(user models.BaseUser, changes []models.ProfileChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if u, ok := r.users[user.ID]; ok {
+		u.Name, u.Email, u.GooglePicture, u.GoogleName, u.GoogleEmail = user.Name, user.Email, user.GooglePicture, user.GoogleName, user.GoogleEmail
+	}
+	r.profileChanges = append(r.profileChanges, changes...)
+	return nil
+}
+
+func (r *memoryAuthRepository) SaveProfileChange(change models.ProfileChange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.profileChanges = append(r.profileChanges, change)
+	return nil
+}
+
+func (r *memoryAuthRepository) FindProfileChanges(userID int) ([]models.ProfileChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	changes := []models.ProfileChange{}
+	for _, c := range r.profileChanges {
+		if c.AdminOrUserID == userID {
+			changes = append(changes, c)
+		}
+	}
+	return changes, nil
+}
+
+func (r *memoryAuthRepository) session(id int) models.Session {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.sessions[id]
+}
+
+// ------------------------------ EMPTY DB ------------------------------
+
+// emptyDriver adalah driver database/sql yang tidak pernah mengembalikan row,
+// untuk repository berbasis *sql.DB yang hanya perlu "belum ada data"
+type emptyDriver struct{}
+
+type emptyConn struct{}
+type emptyStmt struct{}
+type emptyRows struct{}
+type emptyResult struct{}
+
+func (emptyDriver) Open(name string) (driver.Conn, error) { return emptyConn{}, nil }
+
+func (emptyConn) Prepare(query string) (driver.Stmt, error) { return emptyStmt{}, nil }
+func (emptyConn) Close() error                              { return nil }
+func (emptyConn) Begin() (driver.Tx, error)                 { return nil, errors.New("not supported") }
+
+func (emptyStmt) Close() error                                    { return nil }
+func (emptyStmt) NumInput() int                                   { return -1 }
+func (emptyStmt) Exec(args []driver.Value) (driver.Result, error) { return emptyResult{}, nil }
+func (emptyStmt) Query(args []driver.Value) (driver.Rows, error)  { return emptyRows{}, nil }
+
+func (emptyRows) Columns() []string              { return nil }
+func (emptyRows) Close() error                   { return nil }
+func (emptyRows) Next(dest []driver.Value) error { return io.EOF }
+
+func (emptyResult) LastInsertId() (int64, error) { return 0, nil }
+func (emptyResult) RowsAffected() (int64, error) { return 0, nil }
+
+func init() {
+	sql.Register("empty", emptyDriver{})
+}
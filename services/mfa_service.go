@@ -0,0 +1,417 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha1"
+	"crypto/subtle"
+	"encoding/base32"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrInvalidMFAToken    = errors.New("invalid or expired mfa token, please login again")
+	ErrInvalidMFACode     = errors.New("invalid authentication code")
+	ErrMFALocked          = errors.New("too many invalid codes, try again later")
+	ErrMFANotEnrolled     = errors.New("totp is not enrolled")
+	ErrMFAAlreadyEnrolled = errors.New("totp is already enabled")
+	ErrMFARequired        = errors.New("this account requires a second factor")
+)
+
+// Mode MFA admin (ADMIN_MFA)
+const (
+	MFAOptional = "optional"
+	MFARequired = "required"
+)
+
+// Parameter TOTP (RFC 6238) yang didukung semua authenticator app
+const (
+	totpPeriod = 30
+	totpDigits = 6
+	totpSkew   = 1 // toleransi satu time step sebelum/sesudah
+)
+
+const (
+	MFAChallengeTTL   = 5 * time.Minute
+	RecoveryCodeCount = 10
+	// MFAMaxAttempts kode salah berturut-turut sebelum TOTP dikunci
+	MFAMaxAttempts = 5
+	MFALockout     = 15 * time.Minute
+)
+
+// MFAChallengeClaims adalah token sementara dari LoginAdmin sebelum kode TOTP
+// diverifikasi. Audience-nya berbeda dari access token, jadi tidak diterima
+// AuthMiddleware.
+type MFAChallengeClaims struct {
+	Realm      string `json:"realm"`
+	UserID     int    `json:"user_id"`
+	DeviceInfo string `json:"device_info,omitempty"`
+	// Enroll true bila MFA wajib tetapi admin belum punya TOTP
+	Enroll bool `json:"enroll,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// TOTPEnrollment adalah data untuk authenticator app. URI otpauth:// bisa
+// langsung dijadikan QR code oleh frontend.
+type TOTPEnrollment struct {
+	Secret     string
+	OTPAuthURI string
+}
+
+type MFAService struct {
+	Repo *repository.MFARepository
+	JWT  *middleware.JWTManager
+	// Mode MFAOptional atau MFARequired
+	Mode string
+	// Issuer yang tampil di authenticator app
+	Issuer string
+}
+
+func NewMFAService(repo *repository.MFARepository, jwtManager *middleware.JWTManager, mode, issuer string) *MFAService {
+	return &MFAService{
+		Repo:   repo,
+		JWT:    jwtManager,
+		Mode:   mode,
+		Issuer: issuer,
+	}
+}
+
+// --------------------------- LOGIN CHALLENGE ---------------------------
+
+// Challenge mengembalikan response login yang meminta kode TOTP, atau nil
+// bila akun tidak butuh MFA (TOTP tidak aktif dan mode optional).
+func (s *MFAService) Challenge(user *models.BaseUser, deviceInfo string) (map[string]interface{}, error) {
+	required, enroll, err := s.Required(user.ID)
+	if err != nil || !required {
+		return nil, err
+	}
+
+	jti, err := middleware.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	now := time.Now()
+	token, err := s.JWT.Sign(MFAChallengeClaims{
+		Realm:      s.JWT.Realm,
+		UserID:     user.ID,
+		DeviceInfo: deviceInfo,
+		Enroll:     enroll,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.JWT.Issuer,
+			Subject:   s.JWT.Subject(user.ID),
+			Audience:  jwt.ClaimStrings{s.challengeAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(MFAChallengeTTL)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"message":             "mfa required",
+		"mfa_required":        true,
+		"enrollment_required": enroll,
+		"mfa_token":           token,
+		"expires_in":          int(MFAChallengeTTL.Seconds()),
+	}, nil
+}
+
+// Required: apakah login akun butuh kode TOTP, dan apakah admin harus enroll
+// dulu (mode required tanpa TOTP aktif)
+func (s *MFAService) Required(adminID int) (bool, bool, error) {
+	factor, err := s.Repo.FindTOTP(adminID)
+	if err != nil {
+		return false, false, err
+	}
+	if factor.Enabled() {
+		return true, false, nil
+	}
+	return s.Mode == MFARequired, s.Mode == MFARequired, nil
+}
+
+// ParseChallenge memvalidasi mfa_token yang belum pernah dipakai
+func (s *MFAService) ParseChallenge(token string) (*MFAChallengeClaims, error) {
+	claims := &MFAChallengeClaims{}
+	if err := s.JWT.ParseClaims(token, claims, s.JWT.Issuer, s.challengeAudience()); err != nil {
+		return nil, ErrInvalidMFAToken
+	}
+	if claims.Realm != s.JWT.Realm || claims.Subject != s.JWT.Subject(claims.UserID) || claims.ID == "" {
+		return nil, ErrInvalidMFAToken
+	}
+
+	if s.JWT.Revocations != nil {
+		used, err := s.JWT.Revocations.IsRevoked(claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, ErrInvalidMFAToken
+		}
+	}
+	return claims, nil
+}
+
+// CompleteChallenge memverifikasi kode (TOTP atau recovery code) untuk
+// mfa_token. Bila challenge adalah enrollment, TOTP diaktifkan dan recovery
+// code baru dikembalikan. mfa_token hanya bisa dipakai sekali.
+func (s *MFAService) CompleteChallenge(claims *MFAChallengeClaims, code string) ([]string, error) {
+	var recoveryCodes []string
+	var err error
+	if claims.Enroll {
+		recoveryCodes, err = s.ConfirmEnrollment(claims.UserID, code)
+	} else {
+		err = s.Verify(claims.UserID, code)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if s.JWT.Revocations != nil {
+		if err := s.JWT.Revocations.Revoke(claims.ID, claims.ExpiresAt.Time); err != nil {
+			return nil, err
+		}
+	}
+	return recoveryCodes, nil
+}
+
+func (s *MFAService) challengeAudience() string {
+	return s.JWT.Audience + ":mfa"
+}
+
+// ------------------------------ ENROLLMENT -----------------------------
+
+// BeginEnrollment membuat secret TOTP baru (belum aktif sampai dikonfirmasi
+// dengan kode pertama). Enrollment yang belum selesai akan ditimpa.
+func (s *MFAService) BeginEnrollment(adminID int, email string) (*TOTPEnrollment, error) {
+	raw := make([]byte, 20)
+	if _, err := rand.Read(raw); err != nil {
+		return nil, err
+	}
+	secret := base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(raw)
+
+	encrypted, err := middleware.Encrypt(secret)
+	if err != nil {
+		return nil, err
+	}
+	ok, err := s.Repo.SavePendingTOTP(adminID, encrypted)
+	if err != nil {
+		return nil, err
+	}
+	if !ok {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	label := url.PathEscape(s.Issuer + ":" + email)
+	q := url.Values{}
+	q.Set("secret", secret)
+	q.Set("issuer", s.Issuer)
+	q.Set("algorithm", "SHA1")
+	q.Set("digits", fmt.Sprint(totpDigits))
+	q.Set("period", fmt.Sprint(totpPeriod))
+
+	return &TOTPEnrollment{
+		Secret:     secret,
+		OTPAuthURI: "otpauth://totp/" + label + "?" + q.Encode(),
+	}, nil
+}
+
+// ConfirmEnrollment mengaktifkan TOTP dengan kode pertama dari authenticator
+// app dan mengembalikan recovery code (hanya sekali ditampilkan)
+func (s *MFAService) ConfirmEnrollment(adminID int, code string) ([]string, error) {
+	factor, err := s.Repo.FindTOTP(adminID)
+	if err != nil {
+		return nil, err
+	}
+	if factor == nil {
+		return nil, ErrMFANotEnrolled
+	}
+	if factor.Enabled() {
+		return nil, ErrMFAAlreadyEnrolled
+	}
+
+	if err := s.checkTOTP(factor, code); err != nil {
+		return nil, err
+	}
+
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.EnableTOTP(adminID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Disable menghapus TOTP setelah kode valid. Pada mode required admin akan
+// diminta enroll lagi saat login berikutnya.
+func (s *MFAService) Disable(adminID int, code string) error {
+	if err := s.Verify(adminID, code); err != nil {
+		return err
+	}
+	return s.Repo.DeleteTOTP(adminID)
+}
+
+// RegenerateRecoveryCodes mengganti semua recovery code lama
+func (s *MFAService) RegenerateRecoveryCodes(adminID int, code string) ([]string, error) {
+	if err := s.Verify(adminID, code); err != nil {
+		return nil, err
+	}
+	codes, hashes, err := newRecoveryCodes()
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.ReplaceRecoveryCodes(adminID, hashes); err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Status untuk GET /api/auth/admin/mfa
+func (s *MFAService) Status(adminID int) (map[string]interface{}, error) {
+	factor, err := s.Repo.FindTOTP(adminID)
+	if err != nil {
+		return nil, err
+	}
+	remaining := 0
+	if factor.Enabled() {
+		if remaining, err = s.Repo.CountRecoveryCodes(adminID); err != nil {
+			return nil, err
+		}
+	}
+
+	status := map[string]interface{}{
+		"mode":                     s.Mode,
+		"totp_enabled":             factor.Enabled(),
+		"recovery_codes_remaining": remaining,
+	}
+	if factor.Enabled() {
+		status["enabled_at"] = factor.EnabledAt
+	}
+	return status, nil
+}
+
+// ------------------------------- VERIFY --------------------------------
+
+// Verify menerima kode TOTP 6 digit atau recovery code
+func (s *MFAService) Verify(adminID int, code string) error {
+	factor, err := s.Repo.FindTOTP(adminID)
+	if err != nil {
+		return err
+	}
+	if !factor.Enabled() {
+		return ErrMFANotEnrolled
+	}
+
+	code = strings.TrimSpace(code)
+	if len(code) == totpDigits {
+		return s.checkTOTP(factor, code)
+	}
+
+	if factor.LockedUntil != nil && time.Now().Before(*factor.LockedUntil) {
+		return ErrMFALocked
+	}
+	ok, err := s.Repo.UseRecoveryCode(adminID, hashRecoveryCode(code))
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return s.failed(adminID)
+	}
+	return nil
+}
+
+// checkTOTP memeriksa kode terhadap time step sekarang ±totpSkew. Step yang
+// sudah dipakai ditolak supaya kode yang sama tidak bisa di-replay.
+func (s *MFAService) checkTOTP(factor *models.TOTPFactor, code string) error {
+	if factor.LockedUntil != nil && time.Now().Before(*factor.LockedUntil) {
+		return ErrMFALocked
+	}
+
+	secret, err := middleware.Decrypt(factor.Secret)
+	if err != nil {
+		return err
+	}
+	key, err := base32.StdEncoding.WithPadding(base32.NoPadding).DecodeString(secret)
+	if err != nil {
+		return err
+	}
+
+	current := time.Now().Unix() / totpPeriod
+	for step := current - totpSkew; step <= current+totpSkew; step++ {
+		if step <= factor.LastUsedStep {
+			continue
+		}
+		if subtle.ConstantTimeCompare([]byte(hotp(key, uint64(step))), []byte(code)) != 1 {
+			continue
+		}
+		ok, err := s.Repo.UseTOTPStep(factor.AdminID, step)
+		if err != nil {
+			return err
+		}
+		if ok {
+			return nil
+		}
+	}
+	return s.failed(factor.AdminID)
+}
+
+func (s *MFAService) failed(adminID int) error {
+	if err := s.Repo.RecordFailedAttempt(adminID, MFAMaxAttempts, time.Now().Add(MFALockout)); err != nil {
+		return err
+	}
+	return ErrInvalidMFACode
+}
+
+// -------------------------------- HELPER --------------------------------
+
+// hotp menghitung kode HOTP (RFC 4226) dengan HMAC-SHA1
+func hotp(key []byte, counter uint64) string {
+	msg := make([]byte, 8)
+	binary.BigEndian.PutUint64(msg, counter)
+
+	mac := hmac.New(sha1.New, key)
+	mac.Write(msg)
+	sum := mac.Sum(nil)
+
+	offset := sum[len(sum)-1] & 0x0f
+	value := binary.BigEndian.Uint32(sum[offset:offset+4]) & 0x7fffffff
+	return fmt.Sprintf("%0*d", totpDigits, value%1000000)
+}
+
+// newRecoveryCodes membuat recovery code "xxxxx-xxxxx" (50 bit acak) beserta
+// hash-nya
+func newRecoveryCodes() ([]string, []string, error) {
+	encoding := base32.StdEncoding.WithPadding(base32.NoPadding)
+	codes := make([]string, 0, RecoveryCodeCount)
+	hashes := make([]string, 0, RecoveryCodeCount)
+	for i := 0; i < RecoveryCodeCount; i++ {
+		b := make([]byte, 7)
+		if _, err := rand.Read(b); err != nil {
+			return nil, nil, err
+		}
+		raw := strings.ToLower(encoding.EncodeToString(b))[:10]
+		code := raw[:5] + "-" + raw[5:]
+		codes = append(codes, code)
+		hashes = append(hashes, hashRecoveryCode(code))
+	}
+	return codes, hashes, nil
+}
+
+// Recovery code dibandingkan tanpa tanda hubung, spasi dan huruf besar
+func hashRecoveryCode(code string) string {
+	code = strings.ToLower(strings.NewReplacer("-", "", " ", "").Replace(code))
+	return sha256Hex(code)
+}
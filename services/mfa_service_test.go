@@ -0,0 +1,153 @@
+package services
+
+import (
+	"database/sql"
+	"database/sql/driver"
+	"encoding/base32"
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var totpRowColumns = []string{"admin_id", "secret", "enabled_at", "last_used_step", "failed_attempts", "locked_until", "created_at"}
+
+// lockUntilArg cocok dengan waktu kunci now+MFALockout
+type lockUntilArg struct{}
+
+func (lockUntilArg) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	return ok && time.Until(t) > MFALockout-time.Minute && time.Until(t) <= MFALockout
+}
+
+func TestHOTP(t *testing.T) {
+	// RFC 4226 Appendix D
+	key := []byte("12345678901234567890")
+	want := []string{"755224", "287082", "359152", "969429", "338314", "254676", "287922", "162583", "399871", "520489"}
+
+	for counter, code := range want {
+		if got := hotp(key, uint64(counter)); got != code {
+			t.Fatalf("hotp(%d) = %s, want %s", counter, got, code)
+		}
+	}
+}
+
+func TestVerifyTOTP(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEYS", "")
+	t.Setenv("ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	middleware.InitEncryptionKey()
+
+	key := []byte("12345678901234567890")
+	secret, err := middleware.Encrypt(base32.StdEncoding.WithPadding(base32.NoPadding).EncodeToString(key))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := time.Now().Unix() / totpPeriod
+	validCode := hotp(key, uint64(current))
+	past := time.Now().Add(-time.Minute)
+	future := time.Now().Add(time.Minute)
+
+	tests := []struct {
+		name         string
+		code         string
+		enrolled     bool
+		lastUsedStep int64
+		lockedUntil  *time.Time
+		stepUsed     int64 // baris yang diubah UseTOTPStep, -1 bila tidak dipanggil
+		recoveryUsed int64 // baris yang diubah UseRecoveryCode, -1 bila tidak dipanggil
+		failed       bool  // RecordFailedAttempt dipanggil
+		want         error
+	}{
+		{name: "current code", code: validCode, enrolled: true, stepUsed: 1, recoveryUsed: -1},
+		{name: "expired lock", code: validCode, enrolled: true, lockedUntil: &past, stepUsed: 1, recoveryUsed: -1},
+		{name: "wrong code", code: "000000", enrolled: true, stepUsed: -1, recoveryUsed: -1, failed: true, want: ErrInvalidMFACode},
+		// step yang sudah dipakai tidak diterima lagi walaupun kodenya benar
+		{name: "replayed step", code: validCode, enrolled: true, lastUsedStep: current, stepUsed: -1, recoveryUsed: -1, failed: true, want: ErrInvalidMFACode},
+		{name: "step used concurrently", code: validCode, enrolled: true, stepUsed: 0, recoveryUsed: -1, failed: true, want: ErrInvalidMFACode},
+		{name: "locked", code: validCode, enrolled: true, lockedUntil: &future, stepUsed: -1, recoveryUsed: -1, want: ErrMFALocked},
+		{name: "recovery code", code: "abcde-fghij", enrolled: true, stepUsed: -1, recoveryUsed: 1},
+		{name: "used recovery code", code: "abcde-fghij", enrolled: true, stepUsed: -1, recoveryUsed: 0, failed: true, want: ErrInvalidMFACode},
+		{name: "recovery code while locked", code: "abcde-fghij", enrolled: true, lockedUntil: &future, stepUsed: -1, recoveryUsed: -1, want: ErrMFALocked},
+		{name: "not enrolled", code: validCode, stepUsed: -1, recoveryUsed: -1, want: ErrMFANotEnrolled},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer db.Close()
+			s := NewMFAService(repository.NewMFARepository(db), nil, MFAOptional, "test")
+
+			rows := sqlmock.NewRows(totpRowColumns)
+			if tt.enrolled {
+				rows.AddRow(1, secret, past, tt.lastUsedStep, 0, tt.lockedUntil, past)
+			}
+			mock.ExpectQuery(`SELECT .+ FROM admin_totp WHERE admin_id = \?`).WithArgs(1).WillReturnRows(rows)
+
+			if tt.stepUsed >= 0 {
+				mock.ExpectExec(`UPDATE admin_totp SET last_used_step = \?, failed_attempts = 0, locked_until = NULL WHERE admin_id = \? AND last_used_step < \?`).
+					WithArgs(sqlmock.AnyArg(), 1, sqlmock.AnyArg()).
+					WillReturnResult(sqlmock.NewResult(0, tt.stepUsed))
+			}
+			if tt.recoveryUsed >= 0 {
+				mock.ExpectExec(`UPDATE admin_recovery_codes SET used_at = NOW\(\)`).
+					WithArgs(1, hashRecoveryCode(tt.code)).
+					WillReturnResult(sqlmock.NewResult(0, tt.recoveryUsed))
+				if tt.recoveryUsed == 1 {
+					mock.ExpectExec(`UPDATE admin_totp SET failed_attempts = 0, locked_until = NULL WHERE admin_id = \?`).
+						WithArgs(1).
+						WillReturnResult(sqlmock.NewResult(0, 1))
+				}
+			}
+			if tt.failed {
+				mock.ExpectExec(`UPDATE admin_totp SET failed_attempts = failed_attempts \+ 1`).
+					WithArgs(MFAMaxAttempts, lockUntilArg{}, 1).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			if err := s.Verify(1, tt.code); !errors.Is(err, tt.want) {
+				t.Fatalf("Verify error = %v, want %v", err, tt.want)
+			}
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+		})
+	}
+}
+
+func TestAuthenticateRequiresMFA(t *testing.T) {
+	db, err := sql.Open("empty", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer db.Close()
+
+	s, repo, verifier := newTestAuthService(t, middleware.RealmAdmin)
+	s.MFA = NewMFAService(&repository.MFARepository{DB: db}, s.JWTSecret, MFARequired, "test")
+
+	verifier.Add("id-token-admin", &firebase.Token{UID: "admin-1", Claims: map[string]interface{}{"email": "admin@example.com"}})
+	if _, err := s.Register("id-token-admin", "", ""); err != nil {
+		t.Fatalf("Register: %v", err)
+	}
+
+	// login tanpa session (OIDC) tidak punya langkah TOTP
+	if _, _, err := s.Authenticate("id-token-admin", "test-device", "127.0.0.1"); !errors.Is(err, ErrMFARequired) {
+		t.Fatalf("Authenticate error = %v, want %v", err, ErrMFARequired)
+	}
+	if repo.loginHistory != 0 {
+		t.Fatal("rejected login was recorded in login history")
+	}
+
+	s.MFA.Mode = MFAOptional
+	if _, _, err := s.Authenticate("id-token-admin", "test-device", "127.0.0.1"); err != nil {
+		t.Fatalf("Authenticate with optional MFA: %v", err)
+	}
+}
@@ -0,0 +1,822 @@
+package services
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+// Masa berlaku artefak OIDC
+const (
+	AuthorizationRequestTTL = 10 * time.Minute // login di halaman upstream
+	AuthorizationCodeTTL    = time.Minute
+	IDTokenTTL              = 10 * time.Minute
+)
+
+// Scope yang didukung provider
+var SupportedScopes = []string{"openid", "email", "profile"}
+
+var (
+	ErrClientNotFound      = errors.New("oauth client not found")
+	ErrInvalidClientConfig = errors.New("client needs a name, realm admin or user, supported scopes and https (or localhost) redirect uris without fragment")
+)
+
+// OAuthError adalah error OAuth 2.0 (RFC 6749 bagian 4.1.2.1 dan 5.2)
+type OAuthError struct {
+	Code        string
+	Description string
+}
+
+func (e *OAuthError) Error() string {
+	return e.Code + ": " + e.Description
+}
+
+func oauthError(code, description string) *OAuthError {
+	return &OAuthError{Code: code, Description: description}
+}
+
+// RedirectError adalah error /authorize yang dikirim balik ke redirect_uri
+// client. Error sebelum client dan redirect_uri tervalidasi tidak boleh
+// di-redirect.
+type RedirectError struct {
+	*OAuthError
+	RedirectURI string
+	State       string
+}
+
+// Location berisi redirect_uri dengan parameter error
+func (e *RedirectError) Location(issuer string) string {
+	q := url.Values{}
+	q.Set("error", e.Code)
+	q.Set("error_description", e.Description)
+	if e.State != "" {
+		q.Set("state", e.State)
+	}
+	q.Set("iss", issuer)
+	return appendQuery(e.RedirectURI, q)
+}
+
+// AuthorizeRequest adalah parameter GET /oauth/authorize
+type AuthorizeRequest struct {
+	ResponseType        string
+	ClientID            string
+	RedirectURI         string
+	Scope               string
+	State               string
+	Nonce               string
+	CodeChallenge       string
+	CodeChallengeMethod string
+}
+
+// TokenRequest adalah parameter POST /oauth/token
+type TokenRequest struct {
+	GrantType    string
+	Code         string
+	RedirectURI  string
+	CodeVerifier string
+	ClientID     string
+	ClientSecret string
+}
+
+// authorizationRequestClaims menyimpan request /authorize yang sudah valid
+// selama user login di halaman upstream (stateless, ditandatangani HMAC)
+type authorizationRequestClaims struct {
+	ClientID      string `json:"client_id"`
+	RedirectURI   string `json:"redirect_uri"`
+	Scope         string `json:"scope"`
+	State         string `json:"state,omitempty"`
+	Nonce         string `json:"nonce,omitempty"`
+	CodeChallenge string `json:"code_challenge"`
+	jwt.RegisteredClaims
+}
+
+// IDTokenClaims adalah isi ID token OIDC
+type IDTokenClaims struct {
+	Realm    string `json:"realm"`
+	AuthTime int64  `json:"auth_time"`
+	Nonce    string `json:"nonce,omitempty"`
+	Email    string `json:"email,omitempty"`
+	Name     string `json:"name,omitempty"`
+	Picture  string `json:"picture,omitempty"`
+	jwt.RegisteredClaims
+}
+
+// OIDCAccessClaims adalah access token untuk userinfo. Audience-nya endpoint
+// userinfo, jadi token ini tidak diterima AuthMiddleware API admin/user.
+type OIDCAccessClaims struct {
+	Realm    string `json:"realm"`
+	UserID   int    `json:"user_id"`
+	ClientID string `json:"client_id"`
+	Scope    string `json:"scope"`
+	jwt.RegisteredClaims
+}
+
+type OIDCService struct {
+	Repo *repository.OAuthRepository
+	// Issuer berupa URL, misalnya https://auth.example.com
+	Issuer string
+	// LoginURL adalah halaman yang menjalankan login Google/Firebase lalu
+	// mengirim ID token ke /oauth/authorize/complete
+	LoginURL      string
+	RequestSecret []byte
+	// AuthService per realm ("admin", "user")
+	Realms map[string]*AuthService
+	// Permissions mengisi scope access token API di introspection
+	Permissions middleware.PermissionStore
+}
+
+func NewOIDCService(repo *repository.OAuthRepository, issuer, loginURL string, requestSecret []byte, adminAuth, userAuth *AuthService) *OIDCService {
+	return &OIDCService{
+		Repo:          repo,
+		Issuer:        strings.TrimRight(issuer, "/"),
+		LoginURL:      loginURL,
+		RequestSecret: requestSecret,
+		Realms: map[string]*AuthService{
+			middleware.RealmAdmin: adminAuth,
+			middleware.RealmUser:  userAuth,
+		},
+	}
+}
+
+func (s *OIDCService) UserInfoEndpoint() string {
+	return s.Issuer + "/oauth/userinfo"
+}
+
+// ------------------------------- DISCOVERY -------------------------------
+
+// Discovery adalah isi /.well-known/openid-configuration
+func (s *OIDCService) Discovery() map[string]interface{} {
+	algs := []string{}
+	seen := map[string]bool{}
+	for _, realm := range []string{middleware.RealmAdmin, middleware.RealmUser} {
+		for _, alg := range s.Realms[realm].JWTSecret.AccessKeys.Algorithms() {
+			if alg != "HS256" && !seen[alg] {
+				seen[alg] = true
+				algs = append(algs, alg)
+			}
+		}
+	}
+
+	return map[string]interface{}{
+		"issuer":                                         s.Issuer,
+		"authorization_endpoint":                         s.Issuer + "/oauth/authorize",
+		"token_endpoint":                                 s.Issuer + "/oauth/token",
+		"userinfo_endpoint":                              s.UserInfoEndpoint(),
+		"jwks_uri":                                       s.Issuer + "/.well-known/jwks.json",
+		"response_types_supported":                       []string{"code"},
+		"grant_types_supported":                          []string{"authorization_code"},
+		"subject_types_supported":                        []string{"public"},
+		"id_token_signing_alg_values_supported":          algs,
+		"scopes_supported":                               SupportedScopes,
+		"token_endpoint_auth_methods_supported":          []string{"client_secret_basic", "client_secret_post", "none"},
+		"code_challenge_methods_supported":               []string{"S256"},
+		"claims_supported":                               []string{"iss", "sub", "aud", "exp", "iat", "auth_time", "nonce", "realm", "email", "name", "picture"},
+		"authorization_response_iss_parameter_supported": true,
+	}
+}
+
+// ------------------------------- AUTHORIZE -------------------------------
+
+// Authorize memvalidasi request /authorize dan mengembalikan URL halaman
+// login upstream. Error *OAuthError berarti client/redirect_uri tidak valid
+// (tampilkan ke user), *RedirectError dikirim ke redirect_uri client.
+func (s *OIDCService) Authorize(req AuthorizeRequest) (string, error) {
+	client, err := s.activeClient(req.ClientID)
+	if err != nil {
+		return "", oauthError("invalid_client", "unknown or disabled client_id")
+	}
+	if !contains(client.RedirectURIs, req.RedirectURI) {
+		return "", oauthError("invalid_request", "redirect_uri is not registered for this client")
+	}
+
+	redirectErr := func(code, description string) error {
+		return &RedirectError{OAuthError: oauthError(code, description), RedirectURI: req.RedirectURI, State: req.State}
+	}
+
+	if req.ResponseType != "code" {
+		return "", redirectErr("unsupported_response_type", "only response_type=code is supported")
+	}
+
+	scopes := strings.Fields(req.Scope)
+	if !contains(scopes, "openid") {
+		return "", redirectErr("invalid_scope", "scope must include openid")
+	}
+	for _, scope := range scopes {
+		if !contains(client.Scopes, scope) || !contains(SupportedScopes, scope) {
+			return "", redirectErr("invalid_scope", "scope "+scope+" is not allowed for this client")
+		}
+	}
+
+	// PKCE wajib untuk semua client, hanya S256
+	if req.CodeChallenge == "" || req.CodeChallengeMethod != "S256" {
+		return "", redirectErr("invalid_request", "code_challenge with code_challenge_method=S256 is required")
+	}
+
+	now := time.Now()
+	claims := authorizationRequestClaims{
+		ClientID:      client.ClientID,
+		RedirectURI:   req.RedirectURI,
+		Scope:         strings.Join(scopes, " "),
+		State:         req.State,
+		Nonce:         req.Nonce,
+		CodeChallenge: req.CodeChallenge,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.Issuer,
+			Audience:  jwt.ClaimStrings{s.Issuer + "/oauth/authorize"},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(AuthorizationRequestTTL)),
+		},
+	}
+	request, err := jwt.NewWithClaims(jwt.SigningMethodHS256, claims).SignedString(s.RequestSecret)
+	if err != nil {
+		return "", err
+	}
+
+	return appendQuery(s.LoginURL, url.Values{"request": {request}}), nil
+}
+
+// CompleteAuthorization dipanggil halaman login setelah user login Google.
+// Mengembalikan URL redirect ke client (berisi code atau error).
+func (s *OIDCService) CompleteAuthorization(request, idToken, ip string) (string, error) {
+	claims := &authorizationRequestClaims{}
+	_, err := jwt.ParseWithClaims(request, claims, func(t *jwt.Token) (interface{}, error) {
+		return s.RequestSecret, nil
+	},
+		jwt.WithValidMethods([]string{"HS256"}),
+		jwt.WithIssuer(s.Issuer),
+		jwt.WithAudience(s.Issuer+"/oauth/authorize"),
+		jwt.WithExpirationRequired(),
+	)
+	if err != nil {
+		return "", oauthError("invalid_request", "authorization request is invalid or expired, start again from the application")
+	}
+
+	client, err := s.activeClient(claims.ClientID)
+	if err != nil {
+		return "", oauthError("invalid_client", "unknown or disabled client_id")
+	}
+
+	redirectErr := &RedirectError{RedirectURI: claims.RedirectURI, State: claims.State}
+
+	auth := s.Realms[client.Realm]
+	user, token, err := auth.Authenticate(idToken, "oidc:"+client.ClientID, ip)
+	if errors.Is(err, ErrInvalidToken) {
+		return "", oauthError("invalid_request", "invalid google id token")
+	}
+	if errors.Is(err, ErrUserNotRegistered) {
+		redirectErr.OAuthError = oauthError("access_denied", "account is not registered")
+		return "", redirectErr
+	}
+	if errors.Is(err, ErrMFARequired) {
+		redirectErr.OAuthError = oauthError("access_denied", "account requires a second factor, which this login flow does not support")
+		return "", redirectErr
+	}
+	if err != nil {
+		return "", err
+	}
+
+	authTime := time.Unix(token.AuthTime, 0)
+	if token.AuthTime == 0 {
+		authTime = time.Now()
+	}
+
+	code, err := randomToken(32)
+	if err != nil {
+		return "", err
+	}
+	err = s.Repo.CreateAuthorizationCode(models.AuthorizationCode{
+		CodeHash:      sha256Hex(code),
+		ClientID:      client.ClientID,
+		Realm:         client.Realm,
+		UserID:        user.ID,
+		RedirectURI:   claims.RedirectURI,
+		Scope:         claims.Scope,
+		Nonce:         claims.Nonce,
+		CodeChallenge: claims.CodeChallenge,
+		AuthTime:      authTime,
+		ExpiresAt:     time.Now().Add(AuthorizationCodeTTL),
+	})
+	if err != nil {
+		return "", err
+	}
+
+	q := url.Values{}
+	q.Set("code", code)
+	if claims.State != "" {
+		q.Set("state", claims.State)
+	}
+	q.Set("iss", s.Issuer)
+	return appendQuery(claims.RedirectURI, q), nil
+}
+
+// --------------------------------- TOKEN ---------------------------------
+
+// Exchange menukar authorization code dengan ID token dan access token
+func (s *OIDCService) Exchange(req TokenRequest) (map[string]interface{}, error) {
+	if req.GrantType != "authorization_code" {
+		return nil, oauthError("unsupported_grant_type", "only authorization_code is supported")
+	}
+
+	client, err := s.AuthenticateClient(req.ClientID, req.ClientSecret)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Code == "" || req.CodeVerifier == "" {
+		return nil, oauthError("invalid_request", "code and code_verifier are required")
+	}
+
+	invalidGrant := oauthError("invalid_grant", "authorization code is invalid, expired or already used")
+	code, err := s.Repo.ConsumeAuthorizationCode(sha256Hex(req.Code))
+	if err != nil || code == nil {
+		return nil, invalidGrant
+	}
+	if code.UsedAt != nil || time.Now().After(code.ExpiresAt) || code.ClientID != client.ClientID {
+		return nil, invalidGrant
+	}
+	if code.RedirectURI != req.RedirectURI {
+		return nil, oauthError("invalid_grant", "redirect_uri does not match the authorization request")
+	}
+	if !verifyPKCE(req.CodeVerifier, code.CodeChallenge) {
+		return nil, oauthError("invalid_grant", "code_verifier does not match code_challenge")
+	}
+
+	auth := s.Realms[code.Realm]
+	user, err := auth.Repo.FindByID(strconv.Itoa(code.UserID))
+	if err != nil || user == nil {
+		return nil, invalidGrant
+	}
+
+	now := time.Now()
+	subject := auth.JWTSecret.Subject(user.ID)
+	scopes := strings.Fields(code.Scope)
+
+	idClaims := IDTokenClaims{
+		Realm:    code.Realm,
+		AuthTime: code.AuthTime.Unix(),
+		Nonce:    code.Nonce,
+		RegisteredClaims: jwt.RegisteredClaims{
+			Issuer:    s.Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{client.ClientID},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(IDTokenTTL)),
+		},
+	}
+	if contains(scopes, "email") {
+		idClaims.Email = user.Email
+	}
+	if contains(scopes, "profile") {
+		idClaims.Name = user.Name
+		idClaims.Picture = user.GooglePicture
+	}
+
+	idToken, err := auth.JWTSecret.SignPublic(idClaims)
+	if err != nil {
+		return nil, err
+	}
+
+	jti, err := middleware.NewTokenID()
+	if err != nil {
+		return nil, err
+	}
+	accessToken, err := auth.JWTSecret.Sign(OIDCAccessClaims{
+		Realm:    code.Realm,
+		UserID:   user.ID,
+		ClientID: client.ClientID,
+		Scope:    code.Scope,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.Issuer,
+			Subject:   subject,
+			Audience:  jwt.ClaimStrings{s.UserInfoEndpoint()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(now.Add(middleware.AccessTokenTTL)),
+		},
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"access_token": accessToken,
+		"token_type":   "Bearer",
+		"expires_in":   int(middleware.AccessTokenTTL.Seconds()),
+		"id_token":     idToken,
+		"scope":        code.Scope,
+	}, nil
+}
+
+// AuthenticateClient memeriksa client_id dan client_secret. Client public
+// tidak punya secret dan tidak boleh mengirim secret.
+func (s *OIDCService) AuthenticateClient(clientID, clientSecret string) (*models.OAuthClient, error) {
+	invalidClient := oauthError("invalid_client", "client authentication failed")
+
+	client, err := s.activeClient(clientID)
+	if err != nil {
+		return nil, invalidClient
+	}
+
+	if !client.Confidential() {
+		if clientSecret != "" {
+			return nil, invalidClient
+		}
+		return client, nil
+	}
+
+	if clientSecret == "" || subtle.ConstantTimeCompare([]byte(client.ClientSecretHash), []byte(sha256Hex(clientSecret))) != 1 {
+		return nil, invalidClient
+	}
+	return client, nil
+}
+
+// ------------------------------- USERINFO --------------------------------
+
+// ParseOIDCAccessToken memvalidasi access token OIDC untuk realm mana pun
+func (s *OIDCService) ParseOIDCAccessToken(token string) (*OIDCAccessClaims, *AuthService, error) {
+	for _, realm := range []string{middleware.RealmAdmin, middleware.RealmUser} {
+		auth := s.Realms[realm]
+		claims := &OIDCAccessClaims{}
+		if err := auth.JWTSecret.ParseClaims(token, claims, s.Issuer, s.UserInfoEndpoint()); err != nil {
+			continue
+		}
+		if claims.Realm != realm || claims.Subject != auth.JWTSecret.Subject(claims.UserID) || claims.ID == "" {
+			continue
+		}
+
+		if auth.JWTSecret.Revocations != nil {
+			revoked, err := auth.JWTSecret.Revocations.IsRevoked(claims.ID)
+			if err != nil {
+				return nil, nil, err
+			}
+			if revoked {
+				return nil, nil, ErrInvalidToken
+			}
+		}
+		return claims, auth, nil
+	}
+	return nil, nil, ErrInvalidToken
+}
+
+// UserInfo mengembalikan claim user sesuai scope access token
+func (s *OIDCService) UserInfo(token string) (map[string]interface{}, error) {
+	claims, auth, err := s.ParseOIDCAccessToken(token)
+	if err != nil {
+		return nil, err
+	}
+
+	user, err := auth.Repo.FindByID(strconv.Itoa(claims.UserID))
+	if err != nil || user == nil {
+		return nil, ErrInvalidToken
+	}
+
+	info := map[string]interface{}{
+		"sub":   claims.Subject,
+		"realm": claims.Realm,
+	}
+	scopes := strings.Fields(claims.Scope)
+	if contains(scopes, "email") {
+		info["email"] = user.Email
+	}
+	if contains(scopes, "profile") {
+		info["name"] = user.Name
+		info["picture"] = user.GooglePicture
+	}
+	return info, nil
+}
+
+// ----------------------------- INTROSPECTION -----------------------------
+
+// introspectedToken adalah token aktif yang ditemukan lookupToken. Tepat satu
+// dari Access, OIDC atau Session terisi.
+type introspectedToken struct {
+	Auth    *AuthService
+	Access  *middleware.AccessClaims
+	OIDC    *OIDCAccessClaims
+	Session *models.Session
+	User    *models.BaseUser
+}
+
+// Introspect menjawab POST /oauth/introspect (RFC 7662). Hanya client
+// confidential yang boleh bertanya, dan hanya untuk token realm client itu.
+// Token yang tidak aktif (atau bukan milik realm client) cukup dijawab
+// {"active": false}.
+func (s *OIDCService) Introspect(clientID, clientSecret, token, tokenTypeHint string) (map[string]interface{}, error) {
+	client, err := s.AuthenticateClient(clientID, clientSecret)
+	if err != nil {
+		return nil, err
+	}
+	if !client.Confidential() {
+		return nil, oauthError("invalid_client", "public clients cannot introspect tokens")
+	}
+
+	inactive := map[string]interface{}{"active": false}
+	found := s.lookupToken(client.Realm, token, tokenTypeHint)
+	if found == nil {
+		return inactive, nil
+	}
+
+	resp := map[string]interface{}{
+		"active": true,
+		"realm":  client.Realm,
+	}
+
+	switch {
+	case found.Access != nil:
+		claims := found.Access
+		resp["token_type"] = "access_token"
+		resp["sub"] = claims.Subject
+		resp["role"] = claims.Role
+		resp["email"] = claims.Email
+		resp["sid"] = claims.SessionID
+		resp["iss"] = claims.Issuer
+		resp["aud"] = claims.Audience
+		resp["jti"] = claims.ID
+		resp["exp"] = claims.ExpiresAt.Unix()
+		resp["iat"] = claims.IssuedAt.Unix()
+		resp["scope"] = s.roleScope(client.Realm, claims.Role)
+
+	case found.OIDC != nil:
+		claims := found.OIDC
+		user, err := found.Auth.Repo.FindByID(strconv.Itoa(claims.UserID))
+		if err != nil || user == nil {
+			return inactive, nil
+		}
+		resp["token_type"] = "access_token"
+		resp["sub"] = claims.Subject
+		resp["role"] = user.Role
+		resp["client_id"] = claims.ClientID
+		resp["iss"] = claims.Issuer
+		resp["aud"] = claims.Audience
+		resp["jti"] = claims.ID
+		resp["exp"] = claims.ExpiresAt.Unix()
+		resp["iat"] = claims.IssuedAt.Unix()
+		resp["scope"] = claims.Scope
+
+	case found.Session != nil:
+		resp["token_type"] = "refresh_token"
+		resp["sub"] = found.Auth.JWTSecret.Subject(found.User.ID)
+		resp["role"] = found.User.Role
+		resp["email"] = found.User.Email
+		resp["sid"] = found.Session.ID
+		resp["exp"] = found.Session.ExpiresAt.Unix()
+		resp["iat"] = found.Session.CreatedAt.Unix()
+		resp["scope"] = s.roleScope(client.Realm, found.User.Role)
+	}
+	return resp, nil
+}
+
+// ------------------------------ REVOCATION -------------------------------
+
+// Revoke menjawab POST /oauth/revoke (RFC 7009). Token yang tidak dikenal atau
+// sudah tidak aktif tetap dianggap berhasil. Access token OIDC hanya boleh
+// dicabut client penerimanya; access/refresh token API hanya oleh client
+// confidential di realm yang sama. Refresh token menutup session-nya,
+// termasuk access token terakhir session itu.
+func (s *OIDCService) Revoke(clientID, clientSecret, token, tokenTypeHint string) error {
+	client, err := s.AuthenticateClient(clientID, clientSecret)
+	if err != nil {
+		return err
+	}
+
+	found := s.lookupToken(client.Realm, token, tokenTypeHint)
+	if found == nil {
+		return nil
+	}
+
+	notIssued := oauthError("unauthorized_client", "token was not issued to this client")
+	switch {
+	case found.OIDC != nil:
+		if found.OIDC.ClientID != client.ClientID {
+			return notIssued
+		}
+		if found.Auth.JWTSecret.Revocations == nil {
+			return nil
+		}
+		return found.Auth.JWTSecret.Revocations.Revoke(found.OIDC.ID, found.OIDC.ExpiresAt.Time)
+
+	case found.Access != nil:
+		if !client.Confidential() {
+			return notIssued
+		}
+		return found.Auth.RevokeAccessToken(found.Access)
+
+	case found.Session != nil:
+		if !client.Confidential() {
+			return notIssued
+		}
+		return found.Auth.Logout(found.Session.AdminOrUserID, found.Session.ID)
+	}
+	return nil
+}
+
+// lookupToken mencari token aktif di realm client. token_type_hint hanya
+// menentukan urutan pencarian (RFC 7662 bagian 2.1).
+func (s *OIDCService) lookupToken(realm, token, tokenTypeHint string) *introspectedToken {
+	auth, ok := s.Realms[realm]
+	if !ok || token == "" {
+		return nil
+	}
+
+	findAccess := func() *introspectedToken {
+		if claims, err := auth.ActiveAccessToken(token); err == nil {
+			return &introspectedToken{Auth: auth, Access: claims}
+		}
+		if claims, oidcAuth, err := s.ParseOIDCAccessToken(token); err == nil && claims.Realm == realm {
+			return &introspectedToken{Auth: oidcAuth, OIDC: claims}
+		}
+		return nil
+	}
+	findRefresh := func() *introspectedToken {
+		if session, user, err := auth.ActiveRefreshToken(token); err == nil {
+			return &introspectedToken{Auth: auth, Session: session, User: user}
+		}
+		return nil
+	}
+
+	lookups := []func() *introspectedToken{findAccess, findRefresh}
+	if tokenTypeHint == "refresh_token" {
+		lookups = []func() *introspectedToken{findRefresh, findAccess}
+	}
+	for _, lookup := range lookups {
+		if found := lookup(); found != nil {
+			return found
+		}
+	}
+	return nil
+}
+
+// roleScope berisi permission role, dipisah spasi
+func (s *OIDCService) roleScope(realm, role string) string {
+	if s.Permissions == nil {
+		return ""
+	}
+	permissions, err := s.Permissions.PermissionsForRole(realm, role)
+	if err != nil {
+		return ""
+	}
+	return strings.Join(permissions, " ")
+}
+
+// ---------------------------- CLIENT REGISTRY ----------------------------
+
+// RegisterClient mendaftarkan client baru. Secret (untuk client
+// confidential) hanya dikembalikan sekali di sini.
+func (s *OIDCService) RegisterClient(createdBy int, name, realm string, redirectURIs, scopes []string, confidential bool) (*models.OAuthClient, string, error) {
+	if strings.TrimSpace(name) == "" || (realm != middleware.RealmAdmin && realm != middleware.RealmUser) || len(redirectURIs) == 0 {
+		return nil, "", ErrInvalidClientConfig
+	}
+	for _, uri := range redirectURIs {
+		if !validRedirectURI(uri) {
+			return nil, "", ErrInvalidClientConfig
+		}
+	}
+
+	if len(scopes) == 0 {
+		scopes = SupportedScopes
+	}
+	for _, scope := range scopes {
+		if !contains(SupportedScopes, scope) {
+			return nil, "", ErrInvalidClientConfig
+		}
+	}
+	if !contains(scopes, "openid") {
+		scopes = append([]string{"openid"}, scopes...)
+	}
+
+	id := make([]byte, 16)
+	if _, err := rand.Read(id); err != nil {
+		return nil, "", err
+	}
+
+	client := models.OAuthClient{
+		ClientID:     hex.EncodeToString(id),
+		Name:         strings.TrimSpace(name),
+		Realm:        realm,
+		RedirectURIs: redirectURIs,
+		Scopes:       scopes,
+		CreatedBy:    createdBy,
+		CreatedAt:    time.Now(),
+	}
+
+	var secret string
+	if confidential {
+		var err error
+		secret, err = randomToken(32)
+		if err != nil {
+			return nil, "", err
+		}
+		client.ClientSecretHash = sha256Hex(secret)
+	}
+
+	if err := s.Repo.CreateClient(client); err != nil {
+		return nil, "", err
+	}
+	return &client, secret, nil
+}
+
+func (s *OIDCService) GetAllClients() ([]models.OAuthClient, error) {
+	return s.Repo.GetAllClients()
+}
+
+func (s *OIDCService) DisableClient(clientID string) error {
+	ok, err := s.Repo.DisableClient(clientID)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrClientNotFound
+	}
+	return nil
+}
+
+// -------------------------------- HELPER ---------------------------------
+
+func (s *OIDCService) activeClient(clientID string) (*models.OAuthClient, error) {
+	if clientID == "" {
+		return nil, ErrClientNotFound
+	}
+	client, err := s.Repo.FindClientByID(clientID)
+	if err != nil || client == nil || client.DisabledAt != nil {
+		return nil, ErrClientNotFound
+	}
+	if _, ok := s.Realms[client.Realm]; !ok {
+		return nil, ErrClientNotFound
+	}
+	return client, nil
+}
+
+// verifyPKCE: BASE64URL(SHA256(code_verifier)) == code_challenge (RFC 7636)
+func verifyPKCE(verifier, challenge string) bool {
+	if len(verifier) < 43 || len(verifier) > 128 {
+		return false
+	}
+	sum := sha256.Sum256([]byte(verifier))
+	expected := base64.RawURLEncoding.EncodeToString(sum[:])
+	return subtle.ConstantTimeCompare([]byte(expected), []byte(challenge)) == 1
+}
+
+// Redirect URI harus https, kecuali http ke localhost untuk development
+func validRedirectURI(raw string) bool {
+	u, err := url.Parse(raw)
+	if err != nil || u.Host == "" || u.Fragment != "" {
+		return false
+	}
+	switch u.Scheme {
+	case "https":
+		return true
+	case "http":
+		host := u.Hostname()
+		if host == "localhost" {
+			return true
+		}
+		ip := net.ParseIP(host)
+		return ip != nil && ip.IsLoopback()
+	}
+	return false
+}
+
+func appendQuery(rawURL string, q url.Values) string {
+	sep := "?"
+	if strings.Contains(rawURL, "?") {
+		sep = "&"
+	}
+	return fmt.Sprintf("%s%s%s", rawURL, sep, q.Encode())
+}
+
+func randomToken(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(b), nil
+}
+
+func sha256Hex(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
+
+func contains(list []string, value string) bool {
+	for _, v := range list {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,218 @@
+package services
+
+import (
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+// Contoh dari RFC 7636 Appendix B
+const (
+	testCodeVerifier  = "dBjftJeZ4CVP-mB92K27uhbUJU1p1r_wW1gFWFOEjXk"
+	testCodeChallenge = "E9Melhoa2OwvFrEMTJguCHaoeK1t8URWbuGJSstw-cM"
+)
+
+const testRedirectURI = "https://app.example.com/callback"
+
+var (
+	oauthClientRowColumns = []string{"client_id", "client_secret_hash", "name", "realm", "redirect_uris", "scopes", "created_by", "created_at", "disabled_at"}
+	authCodeRowColumns    = []string{"code_hash", "client_id", "realm", "user_id", "redirect_uri", "scope", "nonce", "code_challenge", "auth_time", "created_at", "expires_at", "used_at"}
+)
+
+// newTestOIDCService memakai OAuthRepository di atas sqlmock dan AuthService
+// realm user dengan key EdDSA untuk ID token, public key-nya ikut dikembalikan
+func newTestOIDCService(t *testing.T) (*OIDCService, sqlmock.Sqlmock, ed25519.PublicKey) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { db.Close() })
+
+	pub, priv, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	der, err := x509.MarshalPKCS8PrivateKey(priv)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "ed25519.pem")
+	if err := os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: "PRIVATE KEY", Bytes: der}), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	key, err := middleware.LoadSigningKeyPEM(path, "EdDSA")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	admins, _, _ := newTestAuthService(t, middleware.RealmAdmin)
+	users, repo, _ := newTestAuthService(t, middleware.RealmUser)
+	users.JWTSecret.AccessKeys = middleware.NewSingleKeyring(key)
+	if err := repo.Create(models.BaseUser{GoogleUID: "uid-1", Name: "User", Email: "user@example.com"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s := NewOIDCService(repository.NewOAuthRepository(db), "https://auth.example.com", "https://login.example.com", []byte("request-secret"), admins, users)
+	return s, mock, pub
+}
+
+func expectPublicClient(mock sqlmock.Sqlmock) {
+	mock.ExpectQuery(`SELECT .+ FROM oauth_clients WHERE client_id = \?`).
+		WithArgs("client-1").
+		WillReturnRows(sqlmock.NewRows(oauthClientRowColumns).
+			AddRow("client-1", "", "App", middleware.RealmUser, testRedirectURI, "openid email profile", 1, time.Now(), nil))
+}
+
+func TestVerifyPKCE(t *testing.T) {
+	tests := []struct {
+		name      string
+		verifier  string
+		challenge string
+		want      bool
+	}{
+		{name: "S256 match", verifier: testCodeVerifier, challenge: testCodeChallenge, want: true},
+		{name: "other verifier", verifier: strings.Repeat("a", 43), challenge: testCodeChallenge},
+		{name: "plain challenge", verifier: testCodeVerifier, challenge: testCodeVerifier},
+		{name: "verifier too short", verifier: testCodeVerifier[:42], challenge: testCodeChallenge},
+		{name: "verifier too long", verifier: strings.Repeat("a", 129), challenge: testCodeChallenge},
+		{name: "empty challenge", verifier: testCodeVerifier},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := verifyPKCE(tt.verifier, tt.challenge); got != tt.want {
+				t.Fatalf("verifyPKCE = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuthorizeRequiresS256(t *testing.T) {
+	tests := []struct {
+		name      string
+		challenge string
+		method    string
+		wantErr   bool
+	}{
+		{name: "S256", challenge: testCodeChallenge, method: "S256"},
+		{name: "plain method", challenge: testCodeChallenge, method: "plain", wantErr: true},
+		{name: "missing method", challenge: testCodeChallenge, wantErr: true},
+		{name: "missing challenge", method: "S256", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, _ := newTestOIDCService(t)
+			expectPublicClient(mock)
+
+			location, err := s.Authorize(AuthorizeRequest{
+				ClientID:            "client-1",
+				RedirectURI:         testRedirectURI,
+				ResponseType:        "code",
+				Scope:               "openid email",
+				CodeChallenge:       tt.challenge,
+				CodeChallengeMethod: tt.method,
+			})
+			if !tt.wantErr {
+				if err != nil || !strings.HasPrefix(location, "https://login.example.com?request=") {
+					t.Fatalf("Authorize = (%q, %v), want login redirect", location, err)
+				}
+				return
+			}
+
+			var redirectErr *RedirectError
+			if !errors.As(err, &redirectErr) || redirectErr.OAuthError.Code != "invalid_request" {
+				t.Fatalf("Authorize error = %v, want invalid_request redirect", err)
+			}
+		})
+	}
+}
+
+func TestExchangeAuthorizationCode(t *testing.T) {
+	past := time.Now().Add(-time.Minute)
+
+	tests := []struct {
+		name        string
+		usedAt      *time.Time
+		expiresAt   time.Time
+		clientID    string
+		redirectURI string
+		verifier    string
+		wantErr     string
+	}{
+		{name: "valid code", expiresAt: time.Now().Add(time.Minute), clientID: "client-1", redirectURI: testRedirectURI, verifier: testCodeVerifier},
+		{name: "code already used", usedAt: &past, expiresAt: time.Now().Add(time.Minute), clientID: "client-1", redirectURI: testRedirectURI, verifier: testCodeVerifier, wantErr: "invalid_grant"},
+		{name: "expired code", expiresAt: past, clientID: "client-1", redirectURI: testRedirectURI, verifier: testCodeVerifier, wantErr: "invalid_grant"},
+		{name: "code of another client", expiresAt: time.Now().Add(time.Minute), clientID: "client-2", redirectURI: testRedirectURI, verifier: testCodeVerifier, wantErr: "invalid_grant"},
+		{name: "other redirect_uri", expiresAt: time.Now().Add(time.Minute), clientID: "client-1", redirectURI: "https://app.example.com/other", verifier: testCodeVerifier, wantErr: "invalid_grant"},
+		{name: "wrong code_verifier", expiresAt: time.Now().Add(time.Minute), clientID: "client-1", redirectURI: testRedirectURI, verifier: strings.Repeat("a", 43), wantErr: "invalid_grant"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s, mock, pub := newTestOIDCService(t)
+			expectPublicClient(mock)
+
+			mock.ExpectBegin()
+			mock.ExpectQuery(`SELECT .+ FROM oauth_authorization_codes WHERE code_hash = \? FOR UPDATE`).
+				WithArgs(sha256Hex("code-1")).
+				WillReturnRows(sqlmock.NewRows(authCodeRowColumns).
+					AddRow(sha256Hex("code-1"), tt.clientID, middleware.RealmUser, 1, testRedirectURI, "openid email", "nonce-1", testCodeChallenge, time.Now(), time.Now(), tt.expiresAt, tt.usedAt))
+			// code ditandai terpakai sebelum divalidasi, jadi tidak bisa dicoba dua kali
+			if tt.usedAt == nil {
+				mock.ExpectExec(`UPDATE oauth_authorization_codes SET used_at = NOW\(\) WHERE code_hash = \?`).
+					WithArgs(sha256Hex("code-1")).
+					WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+			mock.ExpectCommit()
+
+			tokens, err := s.Exchange(TokenRequest{
+				GrantType:    "authorization_code",
+				Code:         "code-1",
+				RedirectURI:  tt.redirectURI,
+				CodeVerifier: tt.verifier,
+				ClientID:     "client-1",
+			})
+			if err := mock.ExpectationsWereMet(); err != nil {
+				t.Fatal(err)
+			}
+
+			if tt.wantErr != "" {
+				var oauthErr *OAuthError
+				if !errors.As(err, &oauthErr) || oauthErr.Code != tt.wantErr {
+					t.Fatalf("Exchange error = %v, want %s", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Exchange: %v", err)
+			}
+
+			claims := &IDTokenClaims{}
+			_, err = jwt.ParseWithClaims(tokens["id_token"].(string), claims, func(token *jwt.Token) (interface{}, error) {
+				return pub, nil
+			})
+			if err != nil {
+				t.Fatalf("parse id_token: %v", err)
+			}
+			if claims.Nonce != "nonce-1" || claims.Email != "user@example.com" || claims.Audience[0] != "client-1" {
+				t.Fatalf("id_token claims = %+v", claims)
+			}
+		})
+	}
+}
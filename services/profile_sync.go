@@ -0,0 +1,160 @@
+package services
+
+import (
+	"fmt"
+	"log"
+
+	firebase "firebase.google.com/go/v4/auth"
+	"github.com/muhammadfarrasfajri/login-google/models"
+)
+
+// Aturan sinkronisasi per field profil
+const (
+	SyncAlways           = "always"
+	SyncUnlessCustomized = "unless_customized"
+	SyncNever            = "never"
+)
+
+// Sumber perubahan di riwayat profil
+const (
+	ProfileSourceGoogle = "google"
+	ProfileSourceSelf   = "self"
+	ProfileSourceAdmin  = "admin"
+)
+
+// ProfileSyncRules menentukan field mana yang diperbarui dari klaim ID token
+// Google saat login. Picture adalah google_picture; foto yang di-upload
+// sendiri (profile_picture) tidak pernah ditimpa dan dianggap sebagai
+// kustomisasi foto.
+type ProfileSyncRules struct {
+	Name    string
+	Email   string
+	Picture string
+}
+
+// DefaultProfileSyncRules: email dan foto selalu mengikuti Google, nama hanya
+// bila belum diubah sendiri
+var DefaultProfileSyncRules = ProfileSyncRules{
+	Name:    SyncUnlessCustomized,
+	Email:   SyncAlways,
+	Picture: SyncAlways,
+}
+
+func ValidProfileSyncRule(rule string) bool {
+	return rule == SyncAlways || rule == SyncUnlessCustomized || rule == SyncNever
+}
+
+// syncProfile menyamakan nama, email dan foto akun dengan klaim ID token
+// sesuai ProfileSync. Profil dan riwayat perubahannya disimpan dalam satu
+// transaksi; bila gagal, tidak ada yang tersimpan dan user dikembalikan ke
+// nilai sebelumnya.
+func (s *AuthService) syncProfile(user *models.BaseUser, token *firebase.Token) error {
+	if s.ProfileSync == nil {
+		return nil
+	}
+	rules := s.ProfileSync
+	before := *user
+
+	name, _ := token.Claims["name"].(string)
+	email, _ := token.Claims["email"].(string)
+	emailVerified, _ := token.Claims["email_verified"].(bool)
+	picture, _ := token.Claims["picture"].(string)
+
+	if name != "" {
+		user.Name = syncValue(rules.Name, user.Name, name, isCustomized(user.Name, user.GoogleName, name))
+		user.GoogleName = name
+	}
+	// email yang belum diverifikasi Google tidak dipakai
+	if email != "" && emailVerified {
+		user.Email = syncValue(rules.Email, user.Email, email, isCustomized(user.Email, user.GoogleEmail, email))
+		user.GoogleEmail = email
+	}
+	if picture != "" {
+		user.GooglePicture = syncValue(rules.Picture, user.GooglePicture, picture, user.ProfilePicture != "")
+	}
+
+	if user.Name == before.Name && user.Email == before.Email && user.GooglePicture == before.GooglePicture &&
+		user.GoogleName == before.GoogleName && user.GoogleEmail == before.GoogleEmail {
+		return nil
+	}
+
+	changes := profileChanges(user.ID, ProfileSourceGoogle, before, *user)
+	if err := s.Repo.UpdateGoogleProfile(*user, changes); err != nil {
+		*user = before
+		return err
+	}
+	return nil
+}
+
+// isCustomized: nilai di akun berbeda dengan nilai terakhir dari Google. Akun
+// yang belum pernah disinkronkan (last kosong) hanya dianggap customised bila
+// nilainya berbeda dengan klaim sekarang.
+func isCustomized(current, last, claim string) bool {
+	if last == "" {
+		return current != claim
+	}
+	return current != last
+}
+
+func syncValue(rule, current, claim string, customized bool) string {
+	switch rule {
+	case SyncAlways:
+		return claim
+	case SyncUnlessCustomized:
+		if !customized {
+			return claim
+		}
+	}
+	return current
+}
+
+// RecordProfileChanges mencatat field profil yang berbeda antara before dan
+// after ke riwayat profil
+func (s *AuthService) RecordProfileChanges(userID int, source string, before, after models.BaseUser) error {
+	for _, change := range profileChanges(userID, source, before, after) {
+		if err := s.Repo.SaveProfileChange(change); err != nil {
+			return fmt.Errorf("record profile change %s: %w", change.Field, err)
+		}
+	}
+	return nil
+}
+
+// profileChanges mengembalikan satu entri riwayat untuk setiap field profil
+// yang berbeda antara before dan after
+func profileChanges(userID int, source string, before, after models.BaseUser) []models.ProfileChange {
+	fields := []struct {
+		name     string
+		old, new string
+	}{
+		{"name", before.Name, after.Name},
+		{"email", before.Email, after.Email},
+		{"google_picture", before.GooglePicture, after.GooglePicture},
+		{"profile_picture", before.ProfilePicture, after.ProfilePicture},
+	}
+
+	changes := []models.ProfileChange{}
+	for _, f := range fields {
+		if f.old == f.new {
+			continue
+		}
+		changes = append(changes, models.ProfileChange{
+			AdminOrUserID: userID,
+			Field:         f.name,
+			OldValue:      f.old,
+			NewValue:      f.new,
+			Source:        source,
+		})
+	}
+	return changes
+}
+
+func (s *AuthService) ProfileHistory(userID int) ([]models.ProfileChange, error) {
+	return s.Repo.FindProfileChanges(userID)
+}
+
+// syncProfileOnLogin: profil yang gagal disinkronkan tidak membatalkan login
+func (s *AuthService) syncProfileOnLogin(user *models.BaseUser, token *firebase.Token) {
+	if err := s.syncProfile(user, token); err != nil {
+		log.Printf("profile sync failed for %s %d: %v", s.JWTSecret.Realm, user.ID, err)
+	}
+}
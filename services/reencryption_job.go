@@ -0,0 +1,65 @@
+package services
+
+import (
+	"encoding/base64"
+	"log"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+// ReEncryptionJob mengenkripsi ulang refresh token format lama yang masih
+// tersimpan di tabel session dengan ENCRYPTION_KEY versi aktif. Key lama
+// tetap harus ada di ENCRYPTION_KEYS selama token lama di client belum
+// expired, karena token yang dikirim client tidak ikut berubah.
+type ReEncryptionJob struct {
+	Repos []repository.AuthRepository
+}
+
+func NewReEncryptionJob(repos ...repository.AuthRepository) *ReEncryptionJob {
+	return &ReEncryptionJob{
+		Repos: repos,
+	}
+}
+
+func (j *ReEncryptionJob) Run() {
+	for _, repo := range j.Repos {
+		upgraded, err := reEncryptLegacyRefreshTokens(repo)
+		if err != nil {
+			log.Println("re-encryption job failed:", err)
+			continue
+		}
+		if upgraded > 0 {
+			log.Printf("re-encryption job: %d refresh tokens upgraded", upgraded)
+		}
+	}
+}
+
+func reEncryptLegacyRefreshTokens(repo repository.AuthRepository) (int, error) {
+	sessions, err := repo.FindLegacyRefreshTokens()
+	if err != nil {
+		return 0, err
+	}
+
+	upgraded := 0
+	for _, session := range sessions {
+		// format kolom: base64url(Encrypt(JWT))
+		decoded, err := base64.URLEncoding.DecodeString(session.LegacyRefreshToken)
+		if err != nil || !middleware.NeedsReEncryption(string(decoded)) {
+			continue
+		}
+
+		encrypted, err := middleware.ReEncrypt(string(decoded))
+		if err != nil {
+			log.Printf("re-encryption job: session %d: %v", session.ID, err)
+			continue
+		}
+
+		encoded := base64.URLEncoding.EncodeToString([]byte(encrypted))
+		if err := repo.UpdateLegacyRefreshToken(session.ID, session.LegacyRefreshToken, encoded); err != nil {
+			return upgraded, err
+		}
+		upgraded++
+	}
+	return upgraded, nil
+}
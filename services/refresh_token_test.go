@@ -0,0 +1,143 @@
+package services
+
+import (
+	"encoding/base64"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+func TestRefreshTokenRotates(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	login := registerAndLogin(t, s, verifier, "uid-1")
+
+	first := login["refresh_token"].(string)
+	result, err := s.RefreshToken(first, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	second := result["refresh_token"].(string)
+	if second == first {
+		t.Fatal("refresh token was not rotated")
+	}
+
+	session := repo.session(login["session_id"].(int))
+	if session.Generation != 1 {
+		t.Fatalf("generation = %d, want 1", session.Generation)
+	}
+	if _, err := s.RefreshToken(second, "127.0.0.1"); err != nil {
+		t.Fatalf("RefreshToken with rotated token: %v", err)
+	}
+}
+
+func TestRefreshTokenReuseRevokesFamily(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	login := registerAndLogin(t, s, verifier, "uid-1")
+
+	first := login["refresh_token"].(string)
+	result, err := s.RefreshToken(first, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("RefreshToken: %v", err)
+	}
+	second := result["refresh_token"].(string)
+
+	// token generation lama dipakai lagi
+	if _, err := s.RefreshToken(first, "10.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("reuse error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+
+	session := repo.session(login["session_id"].(int))
+	if session.RevokedAt == nil {
+		t.Fatal("session was not revoked after reuse")
+	}
+	if len(repo.securityEvents) != 1 || repo.securityEvents[0] != EventRefreshTokenReuse {
+		t.Fatalf("security events = %v, want [%s]", repo.securityEvents, EventRefreshTokenReuse)
+	}
+
+	// token terbaru dari family yang sama ikut tidak berlaku
+	if _, err := s.RefreshToken(second, "127.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("refresh after revoke error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+
+	// access token terakhir session ini masuk denylist
+	claims, err := s.JWTSecret.ParseAccessToken(result["access_token"].(string))
+	if err != nil {
+		t.Fatalf("ParseAccessToken: %v", err)
+	}
+	if revoked, _ := s.JWTSecret.Revocations.IsRevoked(claims.ID); !revoked {
+		t.Fatal("access token of the revoked family is still active")
+	}
+}
+
+func TestRefreshTokenConcurrentRotationRevokesFamily(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	login := registerAndLogin(t, s, verifier, "uid-1")
+
+	// request lain dengan token yang sama lebih dulu merotasi session
+	repo.beforeRotate = func(sessionID int) {
+		repo.mu.Lock()
+		repo.sessions[sessionID].Generation++
+		repo.mu.Unlock()
+	}
+
+	if _, err := s.RefreshToken(login["refresh_token"].(string), "127.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("RefreshToken error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+	if session := repo.session(login["session_id"].(int)); session.RevokedAt == nil {
+		t.Fatal("session was not revoked after losing the rotation race")
+	}
+}
+
+func TestRefreshTokenLegacyFallback(t *testing.T) {
+	t.Setenv("ENCRYPTION_KEYS", "")
+	t.Setenv("ENCRYPTION_KEY", base64.StdEncoding.EncodeToString(make([]byte, 32)))
+	middleware.InitEncryptionKey()
+
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	login := registerAndLogin(t, s, verifier, "uid-1")
+	sessionID := login["session_id"].(int)
+	user, _ := repo.FindByGoogleUID("uid-1")
+
+	// refresh token format lama: base64url(Encrypt(JWT HS256))
+	generation := 0
+	signed, err := jwt.NewWithClaims(jwt.SigningMethodHS256, middleware.LegacyRefreshClaims{
+		UserID:     user.ID,
+		SessionID:  &sessionID,
+		Generation: &generation,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Hour)),
+		},
+	}).SignedString([]byte(testRefreshSecret))
+	if err != nil {
+		t.Fatal(err)
+	}
+	encrypted, err := middleware.Encrypt(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	legacy := base64.URLEncoding.EncodeToString([]byte(encrypted))
+
+	repo.mu.Lock()
+	repo.sessions[sessionID].RefreshTokenHash = ""
+	repo.sessions[sessionID].LegacyRefreshToken = legacy
+	repo.mu.Unlock()
+
+	result, err := s.RefreshToken(legacy, "127.0.0.1")
+	if err != nil {
+		t.Fatalf("RefreshToken with legacy token: %v", err)
+	}
+	if _, ok := s.JWTSecret.ParseRefreshToken(result["refresh_token"].(string)); !ok {
+		t.Fatal("legacy refresh did not return an opaque refresh token")
+	}
+	if session := repo.session(sessionID); session.LegacyRefreshToken != "" {
+		t.Fatal("legacy refresh token was not cleared after rotation")
+	}
+
+	// token lama yang sudah dirotasi dipakai lagi
+	if _, err := s.RefreshToken(legacy, "127.0.0.1"); !errors.Is(err, ErrRefreshTokenReused) {
+		t.Fatalf("legacy reuse error = %v, want %v", err, ErrRefreshTokenReused)
+	}
+}
@@ -0,0 +1,102 @@
+package services
+
+import (
+	"errors"
+	"regexp"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrRoleNotFound      = errors.New("role not found")
+	ErrUnknownRole       = errors.New("role does not exist")
+	ErrUnknownPermission = errors.New("unknown permission")
+	ErrInvalidRole       = errors.New("role realm must be admin or user and name must match [a-z0-9_-]{1,64}")
+	ErrRoleLockout       = errors.New("built-in admin role must keep roles:manage")
+)
+
+// Permission yang tidak boleh dilepas dari role admin bawaan, supaya selalu
+// ada yang bisa mengatur role
+const PermissionRolesManage = "roles:manage"
+
+var roleNamePattern = regexp.MustCompile(`^[a-z0-9_-]{1,64}$`)
+
+type RoleService struct {
+	Repo *repository.RoleRepository
+	RBAC *middleware.RBAC
+}
+
+func NewRoleService(repo *repository.RoleRepository, rbac *middleware.RBAC) *RoleService {
+	return &RoleService{
+		Repo: repo,
+		RBAC: rbac,
+	}
+}
+
+func (s *RoleService) GetAll() ([]models.Role, error) {
+	return s.Repo.GetAllRoles()
+}
+
+func (s *RoleService) GetPermissions() ([]models.Permission, error) {
+	return s.Repo.GetAllPermissions()
+}
+
+func (s *RoleService) Create(realm, name, description string) (*models.Role, error) {
+	if (realm != middleware.RealmAdmin && realm != middleware.RealmUser) || !roleNamePattern.MatchString(name) {
+		return nil, ErrInvalidRole
+	}
+
+	id, err := s.Repo.CreateRole(models.Role{Realm: realm, Name: name, Description: description})
+	if err != nil {
+		return nil, err
+	}
+	return s.Repo.FindRoleByID(id)
+}
+
+// SetPermissions mengganti seluruh permission role
+func (s *RoleService) SetPermissions(roleID int, permissions []string) (*models.Role, error) {
+	role, err := s.Repo.FindRoleByID(roleID)
+	if err != nil || role == nil {
+		return nil, ErrRoleNotFound
+	}
+
+	known, err := s.Repo.GetAllPermissions()
+	if err != nil {
+		return nil, err
+	}
+	valid := map[string]bool{}
+	for _, p := range known {
+		valid[p.Name] = true
+	}
+
+	unique := []string{}
+	seen := map[string]bool{}
+	for _, p := range permissions {
+		if !valid[p] {
+			return nil, ErrUnknownPermission
+		}
+		if !seen[p] {
+			seen[p] = true
+			unique = append(unique, p)
+		}
+	}
+
+	if role.Realm == middleware.RealmAdmin && role.Name == "admin" && !seen[PermissionRolesManage] {
+		return nil, ErrRoleLockout
+	}
+
+	if err := s.Repo.SetRolePermissions(roleID, unique); err != nil {
+		return nil, err
+	}
+	s.RBAC.Invalidate()
+
+	role.Permissions = unique
+	return role, nil
+}
+
+// RoleExists dipakai saat role user diganti
+func (s *RoleService) RoleExists(realm, name string) (bool, error) {
+	return s.Repo.RoleExists(realm, name)
+}
@@ -0,0 +1,71 @@
+package services
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+)
+
+func TestLoginCreatesSessionPerDevice(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	laptop := registerAndLogin(t, s, verifier, "uid-1")
+	phone, err := s.Login("id-token-uid-1", "phone", "10.0.0.1")
+	if err != nil {
+		t.Fatalf("Login from second device: %v", err)
+	}
+
+	laptopID, phoneID := laptop["session_id"].(int), phone["session_id"].(int)
+	if laptopID == phoneID {
+		t.Fatal("second device reused the first session")
+	}
+
+	user, _ := repo.FindByGoogleUID("uid-1")
+	_, sessions, err := s.Me(user.ID, phoneID)
+	if err != nil {
+		t.Fatalf("Me: %v", err)
+	}
+	if len(sessions) != 2 {
+		t.Fatalf("%d active sessions, want 2", len(sessions))
+	}
+	for _, session := range sessions {
+		if session.Current != (session.ID == phoneID) {
+			t.Fatalf("session %d current = %v", session.ID, session.Current)
+		}
+	}
+
+	// logout di satu device tidak menutup device lain
+	if err := s.Logout(user.ID, laptopID); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if _, err := s.RefreshToken(laptop["refresh_token"].(string), "127.0.0.1"); err == nil {
+		t.Fatal("refresh token of the closed session still works")
+	}
+	if _, err := s.RefreshToken(phone["refresh_token"].(string), "10.0.0.1"); err != nil {
+		t.Fatalf("RefreshToken on the other device: %v", err)
+	}
+	if user, _ = repo.FindByGoogleUID("uid-1"); user.IsLoggedIn != 1 {
+		t.Fatal("account marked logged out while a session is still active")
+	}
+
+	if err := s.Logout(user.ID, phoneID); err != nil {
+		t.Fatalf("Logout: %v", err)
+	}
+	if user, _ = repo.FindByGoogleUID("uid-1"); user.IsLoggedIn != 0 {
+		t.Fatal("account still marked logged in after its last session closed")
+	}
+}
+
+func TestLogoutRejectsSessionOfAnotherUser(t *testing.T) {
+	s, repo, verifier := newTestAuthService(t, middleware.RealmUser)
+	registerAndLogin(t, s, verifier, "uid-1")
+	other := registerAndLogin(t, s, verifier, "uid-2")
+
+	user, _ := repo.FindByGoogleUID("uid-1")
+	if err := s.Logout(user.ID, other["session_id"].(int)); !errors.Is(err, ErrSessionNotFound) {
+		t.Fatalf("Logout error = %v, want %v", err, ErrSessionNotFound)
+	}
+	if _, err := s.RefreshToken(other["refresh_token"].(string), "127.0.0.1"); err != nil {
+		t.Fatalf("RefreshToken of the other user: %v", err)
+	}
+}
@@ -2,22 +2,31 @@ package services
 
 import (
 	"errors"
+	"log"
 
+	"github.com/muhammadfarrasfajri/login-google/middleware"
 	"github.com/muhammadfarrasfajri/login-google/models"
 	"github.com/muhammadfarrasfajri/login-google/repository"
 )
 
 var (
-	ErrUserNotFound = errors.New("user not found")
+	ErrUserNotFound        = errors.New("user not found")
+	ErrRoleChangeForbidden = errors.New("forbidden: missing permission users:assign_role")
 )
 
 type UserService struct {
 	UserRepo *repository.UserRepository
+	Auth     *AuthService
+	Roles    *RoleService
+	// APIKeys opsional, key user yang dihapus ikut dicabut
+	APIKeys *APIKeyService
 }
 
-func NewUserSevice(userRepo *repository.UserRepository) *UserService {
+func NewUserSevice(userRepo *repository.UserRepository, auth *AuthService, roles *RoleService) *UserService {
 	return &UserService{
 		UserRepo: userRepo,
+		Auth:     auth,
+		Roles:    roles,
 	}
 }
 
@@ -43,14 +52,35 @@ func (s *UserService) GetByID(id string) (*models.BaseUser, error) {
 
 // --------------------------- UPDATE USER -----------------------------
 
-func (s *UserService) Update(id, name, email, role, ProfilePicture string) (*models.BaseUser, error) {
+// Role kosong berarti role tidak diubah. Ganti role butuh canAssignRole
+// (permission users:assign_role) dan role harus terdaftar di realm user.
+func (s *UserService) Update(id, name, email, role, ProfilePicture string, canAssignRole bool) (*models.BaseUser, error) {
 	// cek apakah user ada
 	existing, err := s.UserRepo.FindByID(id)
 	if err != nil || existing == nil {
 		return nil, ErrUserNotFound
 	}
 
+	if role == "" {
+		role = existing.Role
+	}
+	roleChanged := existing.Role != role
+
+	if roleChanged {
+		if !canAssignRole {
+			return nil, ErrRoleChangeForbidden
+		}
+		ok, err := s.Roles.RoleExists(middleware.RealmUser, role)
+		if err != nil {
+			return nil, err
+		}
+		if !ok {
+			return nil, ErrUnknownRole
+		}
+	}
+
 	// update field
+	before := *existing
 	existing.Name = name
 	existing.Email = email
 	existing.Role = role
@@ -60,10 +90,41 @@ func (s *UserService) Update(id, name, email, role, ProfilePicture string) (*mod
 	if err != nil {
 		return nil, err
 	}
+	if err := s.Auth.RecordProfileChanges(existing.ID, ProfileSourceAdmin, before, *existing); err != nil {
+		log.Println(err)
+	}
+
+	// access token yang masih membawa role lama dicabut
+	if roleChanged {
+		if err := s.Auth.RevokeAccessTokens(existing.ID); err != nil {
+			return nil, err
+		}
+	}
 
 	return existing, nil
 }
 
+// ------------------------ PROFILE HISTORY ----------------------------
+
+func (s *UserService) ProfileHistory(id string) ([]models.ProfileChange, error) {
+	user, err := s.UserRepo.FindByID(id)
+	if err != nil || user == nil {
+		return nil, ErrUserNotFound
+	}
+	return s.Auth.ProfileHistory(user.ID)
+}
+
+// --------------------------- FORCE LOGOUT ----------------------------
+
+func (s *UserService) ForceLogout(id string) error {
+	user, err := s.UserRepo.FindByID(id)
+	if err != nil || user == nil {
+		return ErrUserNotFound
+	}
+
+	return s.Auth.LogoutAll(user.ID)
+}
+
 // --------------------------- DELETE USER -----------------------------
 
 func (s *UserService) Delete(id string) error {
@@ -73,5 +134,15 @@ func (s *UserService) Delete(id string) error {
 		return ErrUserNotFound
 	}
 
+	// access token dan API key user yang dihapus tidak boleh dipakai lagi
+	if err := s.Auth.RevokeAccessTokens(user.ID); err != nil {
+		return err
+	}
+	if s.APIKeys != nil {
+		if err := s.APIKeys.RevokeAccountKeys(middleware.RealmUser, user.ID); err != nil {
+			return err
+		}
+	}
+
 	return s.UserRepo.Delete(id)
 }
@@ -0,0 +1,357 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"log"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-webauthn/webauthn/protocol"
+	"github.com/go-webauthn/webauthn/webauthn"
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/muhammadfarrasfajri/login-google/middleware"
+	"github.com/muhammadfarrasfajri/login-google/models"
+	"github.com/muhammadfarrasfajri/login-google/repository"
+)
+
+var (
+	ErrInvalidCeremony    = errors.New("invalid or expired webauthn session, start again")
+	ErrNoPasskey          = errors.New("no passkey registered, register one first")
+	ErrPasskeyNotFound    = errors.New("passkey not found")
+	ErrPasskeyName        = errors.New("passkey name is required (max 64 characters)")
+	ErrWebAuthnFailed     = errors.New("passkey verification failed")
+	ErrPasskeyCloneWarned = errors.New("passkey sign counter went backwards, the authenticator may be cloned")
+)
+
+// Jenis ceremony WebAuthn
+const (
+	ceremonyRegister = "register"
+	ceremonyStepUp   = "step_up"
+)
+
+// webAuthnCeremonyClaims menyimpan SessionData WebAuthn (challenge) di antara
+// begin dan finish. Token ini ditandatangani dan hanya bisa dipakai sekali.
+type webAuthnCeremonyClaims struct {
+	Purpose string               `json:"purpose"`
+	UserID  int                  `json:"user_id"`
+	Session webauthn.SessionData `json:"session"`
+	jwt.RegisteredClaims
+}
+
+// webAuthnAdmin mengimplementasikan webauthn.User
+type webAuthnAdmin struct {
+	id          []byte
+	name        string
+	displayName string
+	credentials []webauthn.Credential
+}
+
+func (u *webAuthnAdmin) WebAuthnID() []byte                         { return u.id }
+func (u *webAuthnAdmin) WebAuthnName() string                       { return u.name }
+func (u *webAuthnAdmin) WebAuthnDisplayName() string                { return u.displayName }
+func (u *webAuthnAdmin) WebAuthnCredentials() []webauthn.Credential { return u.credentials }
+
+// WebAuthnService mengelola passkey admin dan step-up
+type WebAuthnService struct {
+	WebAuthn *webauthn.WebAuthn
+	Repo     *repository.WebAuthnRepository
+	Admins   repository.AuthRepository
+	JWT      *middleware.JWTManager
+	// StepUpTTL adalah masa berlaku elevated token
+	StepUpTTL time.Duration
+}
+
+func NewWebAuthnService(wa *webauthn.WebAuthn, repo *repository.WebAuthnRepository, admins repository.AuthRepository, jwtManager *middleware.JWTManager, stepUpTTL time.Duration) *WebAuthnService {
+	return &WebAuthnService{
+		WebAuthn:  wa,
+		Repo:      repo,
+		Admins:    admins,
+		JWT:       jwtManager,
+		StepUpTTL: stepUpTTL,
+	}
+}
+
+// ----------------------------- REGISTRATION ------------------------------
+
+// BeginRegistration mengembalikan opsi navigator.credentials.create() dan
+// token ceremony untuk FinishRegistration
+func (s *WebAuthnService) BeginRegistration(adminID int) (*protocol.CredentialCreation, string, error) {
+	user, err := s.loadAdmin(adminID)
+	if err != nil {
+		return nil, "", err
+	}
+
+	creation, session, err := s.WebAuthn.BeginRegistration(user,
+		webauthn.WithExclusions(webauthn.Credentials(user.credentials).CredentialDescriptors()),
+		webauthn.WithResidentKeyRequirement(protocol.ResidentKeyRequirementPreferred),
+	)
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.signCeremony(ceremonyRegister, adminID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return creation, token, nil
+}
+
+// FinishRegistration memverifikasi respons authenticator lalu menyimpan
+// passkey baru
+func (s *WebAuthnService) FinishRegistration(adminID int, ceremony, name string, response []byte) (*models.WebAuthnCredential, error) {
+	name = strings.TrimSpace(name)
+	if name == "" || len(name) > 64 {
+		return nil, ErrPasskeyName
+	}
+
+	claims, err := s.parseCeremony(ceremony, ceremonyRegister, adminID)
+	if err != nil {
+		return nil, err
+	}
+	user, err := s.loadAdmin(adminID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialCreationResponseBytes(response)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	credential, err := s.WebAuthn.CreateCredential(user, claims.Session, parsed)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	if err := s.useCeremony(claims); err != nil {
+		return nil, err
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	stored := models.WebAuthnCredential{
+		AdminID:      adminID,
+		CredentialID: base64.RawURLEncoding.EncodeToString(credential.ID),
+		Name:         name,
+		Credential:   string(data),
+		CreatedAt:    time.Now(),
+	}
+	id, err := s.Repo.CreateCredential(stored)
+	if err != nil {
+		return nil, err
+	}
+	stored.ID = id
+	return &stored, nil
+}
+
+func (s *WebAuthnService) ListCredentials(adminID int) ([]models.WebAuthnCredential, error) {
+	return s.Repo.FindCredentialsByAdmin(adminID)
+}
+
+// HasPasskey: admin sudah punya minimal satu passkey
+func (s *WebAuthnService) HasPasskey(adminID int) (bool, error) {
+	credentials, err := s.Repo.FindCredentialsByAdmin(adminID)
+	if err != nil {
+		return false, err
+	}
+	return len(credentials) > 0, nil
+}
+
+func (s *WebAuthnService) DeleteCredential(adminID, id int) error {
+	ok, err := s.Repo.DeleteCredential(adminID, id)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrPasskeyNotFound
+	}
+	return nil
+}
+
+// -------------------------------- STEP-UP --------------------------------
+
+// BeginStepUp mengembalikan opsi navigator.credentials.get() untuk passkey
+// milik admin
+func (s *WebAuthnService) BeginStepUp(adminID int) (*protocol.CredentialAssertion, string, error) {
+	user, err := s.loadAdmin(adminID)
+	if err != nil {
+		return nil, "", err
+	}
+	if len(user.credentials) == 0 {
+		return nil, "", ErrNoPasskey
+	}
+
+	assertion, session, err := s.WebAuthn.BeginLogin(user, webauthn.WithUserVerification(protocol.VerificationPreferred))
+	if err != nil {
+		return nil, "", err
+	}
+
+	token, err := s.signCeremony(ceremonyStepUp, adminID, session)
+	if err != nil {
+		return nil, "", err
+	}
+	return assertion, token, nil
+}
+
+// FinishStepUp memverifikasi assertion passkey dan membuat elevated token
+// dari access token yang sedang dipakai
+func (s *WebAuthnService) FinishStepUp(current *middleware.AccessClaims, ceremony string, response []byte) (map[string]interface{}, error) {
+	claims, err := s.parseCeremony(ceremony, ceremonyStepUp, current.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	// elevated token dicatat di session supaya ikut dicabut saat logout,
+	// jadi access token tanpa session (sid) tidak bisa step-up
+	session, err := s.Admins.FindSessionByID(current.SessionID)
+	if err != nil || session == nil || session.AdminOrUserID != current.UserID || session.RevokedAt != nil {
+		return nil, ErrSessionNotFound
+	}
+	user, err := s.loadAdmin(current.UserID)
+	if err != nil {
+		return nil, err
+	}
+
+	parsed, err := protocol.ParseCredentialRequestResponseBytes(response)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	credential, err := s.WebAuthn.ValidateLogin(user, claims.Session, parsed)
+	if err != nil {
+		return nil, ErrWebAuthnFailed
+	}
+	if err := s.useCeremony(claims); err != nil {
+		return nil, err
+	}
+
+	// sign count mundur: kemungkinan authenticator diduplikasi
+	if credential.Authenticator.CloneWarning {
+		log.Printf("webauthn clone warning for admin %d credential %s", current.UserID, base64.RawURLEncoding.EncodeToString(credential.ID))
+		return nil, ErrPasskeyCloneWarned
+	}
+
+	data, err := json.Marshal(credential)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.Repo.UpdateCredentialUsage(base64.RawURLEncoding.EncodeToString(credential.ID), string(data)); err != nil {
+		return nil, err
+	}
+
+	amr := []string{middleware.AMRHardwareKey}
+	token, jti, err := s.JWT.GenerateStepUpToken(current, amr, s.StepUpTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	// elevated token sebelumnya dari session yang sama tidak dipakai lagi
+	if s.JWT.Revocations != nil && session.StepUpJTI != "" && session.StepUpExpiresAt != nil {
+		if err := s.JWT.Revocations.Revoke(session.StepUpJTI, *session.StepUpExpiresAt); err != nil {
+			return nil, err
+		}
+	}
+	if err := s.Admins.SetSessionStepUpToken(session.ID, jti, time.Now().Add(s.StepUpTTL)); err != nil {
+		return nil, err
+	}
+
+	return map[string]interface{}{
+		"message":      "step-up success",
+		"access_token": token,
+		"token_type":   "Bearer",
+		"expires_in":   int(s.StepUpTTL.Seconds()),
+		"amr":          amr,
+	}, nil
+}
+
+// -------------------------------- HELPER ---------------------------------
+
+func (s *WebAuthnService) loadAdmin(adminID int) (*webAuthnAdmin, error) {
+	admin, err := s.Admins.FindByID(strconv.Itoa(adminID))
+	if err != nil || admin == nil {
+		return nil, ErrUserNotFound
+	}
+
+	stored, err := s.Repo.FindCredentialsByAdmin(adminID)
+	if err != nil {
+		return nil, err
+	}
+	credentials := make([]webauthn.Credential, 0, len(stored))
+	for _, c := range stored {
+		var credential webauthn.Credential
+		if err := json.Unmarshal([]byte(c.Credential), &credential); err != nil {
+			return nil, err
+		}
+		credentials = append(credentials, credential)
+	}
+
+	return &webAuthnAdmin{
+		// user handle tidak berisi email atau data pribadi lain
+		id:          []byte(s.JWT.Subject(admin.ID)),
+		name:        admin.Email,
+		displayName: admin.Name,
+		credentials: credentials,
+	}, nil
+}
+
+func (s *WebAuthnService) signCeremony(purpose string, adminID int, session *webauthn.SessionData) (string, error) {
+	jti, err := middleware.NewTokenID()
+	if err != nil {
+		return "", err
+	}
+
+	now := time.Now()
+	expires := session.Expires
+	if expires.IsZero() {
+		expires = now.Add(5 * time.Minute)
+	}
+	return s.JWT.Sign(webAuthnCeremonyClaims{
+		Purpose: purpose,
+		UserID:  adminID,
+		Session: *session,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			Issuer:    s.JWT.Issuer,
+			Subject:   s.JWT.Subject(adminID),
+			Audience:  jwt.ClaimStrings{s.ceremonyAudience()},
+			IssuedAt:  jwt.NewNumericDate(now),
+			ExpiresAt: jwt.NewNumericDate(expires),
+		},
+	})
+}
+
+// parseCeremony memastikan token ceremony milik admin yang sama, untuk
+// langkah yang sama, dan belum pernah dipakai
+func (s *WebAuthnService) parseCeremony(token, purpose string, adminID int) (*webAuthnCeremonyClaims, error) {
+	claims := &webAuthnCeremonyClaims{}
+	if err := s.JWT.ParseClaims(token, claims, s.JWT.Issuer, s.ceremonyAudience()); err != nil {
+		return nil, ErrInvalidCeremony
+	}
+	if claims.Purpose != purpose || claims.UserID != adminID || claims.ID == "" {
+		return nil, ErrInvalidCeremony
+	}
+
+	if s.JWT.Revocations != nil {
+		used, err := s.JWT.Revocations.IsRevoked(claims.ID)
+		if err != nil {
+			return nil, err
+		}
+		if used {
+			return nil, ErrInvalidCeremony
+		}
+	}
+	return claims, nil
+}
+
+func (s *WebAuthnService) useCeremony(claims *webAuthnCeremonyClaims) error {
+	if s.JWT.Revocations == nil {
+		return nil
+	}
+	return s.JWT.Revocations.Revoke(claims.ID, claims.ExpiresAt.Time)
+}
+
+func (s *WebAuthnService) ceremonyAudience() string {
+	return s.JWT.Audience + ":webauthn"
+}